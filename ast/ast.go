@@ -1,14 +1,35 @@
 package ast
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"reflect"
+	"strings"
+
+	"github.com/ekhabarov/jsg/metaschema"
 )
 
 // Schema is an Abstract Syntax Tree (AST) representation of JSON schema.
+//
+// Keywords with pointer values are nil if they're absent in the schema, so
+// "minimum": 0 differs from no minimum at all.
 type Schema struct {
 
+	// 8.1.1. The "$schema" Keyword
+	//
+	// The "$schema" keyword is both used as a JSON Schema dialect identifier and
+	// as the identifier of a resource which is itself a JSON Schema, which
+	// describes the set of valid schemas written for this particular dialect.
+	//
+	// The value of this keyword MUST be a URI [RFC3986] (containing a scheme)
+	// and this URI MUST be normalized.
+	//
+	// https://json-schema.org/draft/2020-12/json-schema-core.html#rfc.section.8.1.1
+	Schema string `json:"$schema"`
+
 	//   8.2.1. The "$id" Keyword
 	//
 	// The "$id" keyword identifies a schema resource with its canonical URI.
@@ -45,6 +66,36 @@ type Schema struct {
 	// steps given in the previous section.
 	ID string `json:"$id"`
 
+	// 8.2.4. Schema Re-Use With "$defs"
+	//
+	// The "$defs" keyword reserves a location for schema authors to inline
+	// re-usable JSON Schemas into a more general schema. The keyword does not
+	// directly affect the validation result.
+	//
+	// This keyword's value MUST be an object. Each member value of this object
+	// MUST be a valid JSON Schema.
+	//
+	// https://json-schema.org/draft/2020-12/json-schema-core.html#rfc.section.8.2.4
+	Defs map[string]Schema `json:"$defs"`
+
+	// DefOrder keeps names of $defs in order of the source document.
+	// MarshalJSON writes $defs in this order, followed by the rest of them in
+	// alphabetical order.
+	DefOrder []string `json:"-"`
+
+	// 9.1. "title" and "description"
+	//
+	// The value of both of these keywords MUST be a string.
+	//
+	// Both of these keywords can be used to decorate a user interface with
+	// information about the data produced by this user interface. A title will
+	// preferably be short, whereas a description will provide explanation about
+	// the purpose of the instance described by this schema.
+	//
+	// https://json-schema.org/draft/2020-12/json-schema-validation.html#rfc.section.9.1
+	Title       string `json:"title"`
+	Description string `json:"description"`
+
 	// 6.1.1. type
 	//
 	// The value of this keyword MUST be either a string or an array. If it is an
@@ -57,6 +108,22 @@ type Schema struct {
 	// https://json-schema.org/draft/2020-12/json-schema-validation.html#rfc.section.6.1.1
 	Type SchemaType `json:"type"`
 
+	// 6.1.2. enum
+	//
+	// The value of this keyword MUST be an array. This array SHOULD have at
+	// least one element. Elements in the array SHOULD be unique.
+	//
+	// An instance validates successfully against this keyword if its value is
+	// equal to one of the elements in this keyword's array value.
+	//
+	// Elements in the array might be of any type, including null.
+	//
+	// Elements are kept as raw JSON, so numbers keep their exact values, e.g.
+	// 9007199254740993.
+	//
+	// https://json-schema.org/draft/2020-12/json-schema-validation.html#rfc.section.6.1.2
+	Enum []json.RawMessage `json:"enum"`
+
 	// 6.2. Validation Keywords for Numeric Instances (number and integer)
 
 	// 6.2.1. multipleOf
@@ -66,7 +133,7 @@ type Schema struct {
 	// in an integer.
 	//
 	// https://json-schema.org/draft/2020-12/json-schema-validation.html#rfc.section.6.2.1
-	MultipleOf float64 `json:"multipleOf"`
+	MultipleOf *Decimal `json:"multipleOf"`
 
 	// 6.2.2. maximum
 	//
@@ -76,7 +143,7 @@ type Schema struct {
 	// "maximum".
 	//
 	// https://json-schema.org/draft/2020-12/json-schema-validation.html#rfc.section.6.2.2
-	Maximum float64 `json:"maximum"`
+	Maximum *Decimal `json:"maximum"`
 
 	// 6.2.3. exclusiveMaximum
 	//
@@ -86,7 +153,7 @@ type Schema struct {
 	// equal to) "exclusiveMaximum".
 	//
 	// https://json-schema.org/draft/2020-12/json-schema-validation.html#rfc.section.6.2.3
-	ExclusiveMaximum float64 `json:"exclusiveMaximum"`
+	ExclusiveMaximum *Decimal `json:"exclusiveMaximum"`
 
 	// 6.2.4. minimum
 	//
@@ -96,7 +163,7 @@ type Schema struct {
 	// "minimum".
 	//
 	// https://json-schema.org/draft/2020-12/json-schema-validation.html#rfc.section.6.2.4
-	Minimum float64 `json:"minimum"`
+	Minimum *Decimal `json:"minimum"`
 
 	// 6.2.5. exclusiveMinimum
 	//
@@ -106,7 +173,7 @@ type Schema struct {
 	// (not equal to) "exclusiveMinimum".
 	//
 	// https://json-schema.org/draft/2020-12/json-schema-validation.html#rfc.section.6.2.5
-	ExclusiveMinimum float64 `json:"exclusiveMinimum"`
+	ExclusiveMinimum *Decimal `json:"exclusiveMinimum"`
 
 	// 6.3. Validation Keywords for Strings
 
@@ -118,7 +185,7 @@ type Schema struct {
 	// defined as the number of its characters as defined by RFC 8259.
 	//
 	// https://json-schema.org/draft/2020-12/json-schema-validation.html#rfc.section.6.3.1
-	MaxLength uint32 `json:"maxLength"`
+	MaxLength *uint32 `json:"maxLength"`
 
 	// 6.3.2. minLength
 	//
@@ -129,7 +196,7 @@ type Schema struct {
 	// this keyword has the same behavior as a value of 0.
 	//
 	// https://json-schema.org/draft/2020-12/json-schema-validation.html#rfc.section.6.3.2
-	MinLength uint32 `json:"minLength"`
+	MinLength *uint32 `json:"minLength"`
 
 	// 6.3.3. pattern
 	//
@@ -142,6 +209,69 @@ type Schema struct {
 	// https://json-schema.org/draft/2020-12/json-schema-validation.html#rfc.section.6.3.3
 	Pattern string `json:"pattern"`
 
+	// 6.4. Validation Keywords for Arrays
+
+	// 6.4.1. maxItems
+	//
+	// The value of this keyword MUST be a non-negative integer. An array
+	// instance is valid against "maxItems" if its size is less than, or equal
+	// to, the value of this keyword.
+	//
+	// https://json-schema.org/draft/2020-12/json-schema-validation.html#rfc.section.6.4.1
+	MaxItems *uint32 `json:"maxItems"`
+
+	// 6.4.2. minItems
+	//
+	// The value of this keyword MUST be a non-negative integer. An array
+	// instance is valid against "minItems" if its size is greater than, or
+	// equal to, the value of this keyword. Omitting this keyword has the same
+	// behavior as a value of 0.
+	//
+	// https://json-schema.org/draft/2020-12/json-schema-validation.html#rfc.section.6.4.2
+	MinItems *uint32 `json:"minItems"`
+
+	// 6.4.3. uniqueItems
+	//
+	// The value of this keyword MUST be a boolean. If this keyword has boolean
+	// value false, the instance validates successfully. If it has boolean value
+	// true, the instance validates successfully if all of its elements are
+	// unique. Omitting this keyword has the same behavior as a value of false.
+	//
+	// https://json-schema.org/draft/2020-12/json-schema-validation.html#rfc.section.6.4.3
+	UniqueItems bool `json:"uniqueItems"`
+
+	// 6.5. Validation Keywords for Objects
+
+	// 6.5.1. maxProperties
+	//
+	// The value of this keyword MUST be a non-negative integer. An object
+	// instance is valid against "maxProperties" if its number of properties is
+	// less than, or equal to, the value of this keyword.
+	//
+	// https://json-schema.org/draft/2020-12/json-schema-validation.html#rfc.section.6.5.1
+	MaxProperties *uint32 `json:"maxProperties"`
+
+	// 6.5.2. minProperties
+	//
+	// The value of this keyword MUST be a non-negative integer. An object
+	// instance is valid against "minProperties" if its number of properties is
+	// greater than, or equal to, the value of this keyword. Omitting this
+	// keyword has the same behavior as a value of 0.
+	//
+	// https://json-schema.org/draft/2020-12/json-schema-validation.html#rfc.section.6.5.2
+	MinProperties *uint32 `json:"minProperties"`
+
+	// 6.5.3. required
+	//
+	// The value of this keyword MUST be an array. Elements of this array, if
+	// any, MUST be strings, and MUST be unique. An object instance is valid
+	// against this keyword if every item in the array is the name of a property
+	// in the instance. Omitting this keyword has the same behavior as an empty
+	// array.
+	//
+	// https://json-schema.org/draft/2020-12/json-schema-validation.html#rfc.section.6.5.3
+	Required []string `json:"required"`
+
 	// https://json-schema.org/draft/2020-12/json-schema-validation.html#rfc.section.7.3
 	Format StringFormat `json:"format"`
 
@@ -159,6 +289,29 @@ type Schema struct {
 	// https://json-schema.org/draft/2020-12/json-schema-core.html#rfc.section.8.2.3.1
 	Ref string `json:"$ref"`
 
+	// 10.2.1.1. allOf
+	//
+	// This keyword's value MUST be a non-empty array. Each item of the array
+	// MUST be a valid JSON Schema.
+	//
+	// An instance validates successfully against this keyword if it validates
+	// successfully against all schemas defined by this keyword's value.
+	//
+	// https://json-schema.org/draft/2020-12/json-schema-core.html#rfc.section.10.2.1.1
+	AllOf []Schema `json:"allOf"`
+
+	// 10.3.1.2. items
+	//
+	// The value of "items" MUST be a valid JSON Schema. This keyword applies its
+	// subschema to all instance elements at indexes greater than the length of
+	// the "prefixItems" array in the same schema object, as reported by the
+	// annotation result of that "prefixItems" keyword. If no such annotation
+	// result exists, "items" applies its subschema to all instance array
+	// elements.
+	//
+	// https://json-schema.org/draft/2020-12/json-schema-core.html#rfc.section.10.3.1.2
+	Items *Schema `json:"items"`
+
 	// 10.3.2. Keywords for Applying Subschemas to Objects
 	//
 	// 10.3.2.1. properties
@@ -173,16 +326,329 @@ type Schema struct {
 	//
 	// https://json-schema.org/draft/2020-12/json-schema-core.html#rfc.section.10.3.2.1
 	Properties map[string]Schema `json:"properties"`
+
+	// PropertyOrder keeps names of properties in order of the source
+	// document. MarshalJSON writes properties in this order, followed by the
+	// rest of them in alphabetical order.
+	PropertyOrder []string `json:"-"`
+
+	// Vendor extensions.
+
+	// x-go-optional
+	//
+	// Makes the generator wrap a field into a three-state Optional type, which
+	// distinguishes absent, null and set values, e.g. for JSON merge-patch
+	// payloads. Set on an object schema it applies to all its properties, set on
+	// a property it overrides the value inherited from the parent.
+	Optional *bool `json:"x-go-optional"`
+
+	// x-go-tags
+	//
+	// Overrides struct tags of a field generated for the property, e.g.
+	// {"yaml": "name,flow", "db": "-"}. Keys are tag keys, values are tag values.
+	Tags map[string]string `json:"x-go-tags"`
+
+	// x-go-type
+	//
+	// Overrides a Go type of the schema, e.g. "decimal.Decimal". Qualified
+	// types require x-go-package. $defs entries with x-go-type aren't
+	// generated, references to them use the type instead.
+	GoType string `json:"x-go-type"`
+
+	// x-go-package
+	//
+	// An import path of the package with x-go-type, e.g.
+	// "github.com/shopspring/decimal".
+	GoPackage string `json:"x-go-package"`
+
+	// x-go-name
+	//
+	// Overrides a name of the field generated for the property, or a name of
+	// the type generated for the schema.
+	GoName string `json:"x-go-name"`
+
+	// x-go-omitempty
+	//
+	// Overrides whether struct tags of the field get "omitempty" option, which
+	// is set for properties, which aren't required, by default.
+	OmitEmpty *bool `json:"x-go-omitempty"`
+
+	// x-go-pointer
+	//
+	// Overrides whether the field is a pointer. By default only fields of $ref
+	// properties are.
+	Pointer *bool `json:"x-go-pointer"`
+
+	// x-go-embed
+	//
+	// Makes the generator embed a type referenced by the property instead of
+	// adding a named field.
+	Embed bool `json:"x-go-embed"`
+
+	// x-go-skip
+	//
+	// Makes the generator skip the field of the property, or the type of the
+	// schema.
+	Skip bool `json:"x-go-skip"`
+
+	// Extensions keeps all vendor extension keywords, i.e. ones starting with
+	// "x-", as raw JSON, including the ones above.
+	Extensions map[string]json.RawMessage `json:"-"`
+
+	// Unknown keeps keywords, which are neither supported by AST nor vendor
+	// extensions, e.g. "oneOf" or "examples", as raw JSON.
+	Unknown map[string]json.RawMessage `json:"-"`
+
+	// UnknownOrder keeps names of unknown keywords in order of the source
+	// document. MarshalJSON writes unknown keywords in this order, followed by
+	// the rest of them in alphabetical order.
+	UnknownOrder []string `json:"-"`
+
+	// boolean is "true" or "false" for boolean schemas, which are decoded as
+	// equivalent objects, see booleans.
+	boolean string
+}
+
+// booleans map boolean schemas to equivalent objects: true accepts any
+// instance, false accepts none.
+var booleans = map[string]string{
+	"true":  `{}`,
+	"false": `{"not":{}}`,
 }
 
-// Parse parses JSON schema into Abstract Syntax Tree.
-func Parse(r io.Reader) (*Schema, error) {
-	var sch Schema
+// Boolean reports whether s is decoded from a boolean schema and isn't
+// changed since then, and its value, e.g. false for schemas, which accept no
+// instances.
+func (s *Schema) Boolean() (value, ok bool) {
+	if s.boolean == "" {
+		return false, false
+	}
+
+	b, err := s.MarshalJSON()
+	if err != nil || string(b) != s.boolean {
+		return false, false
+	}
+
+	return s.boolean == "true", true
+}
+
+// supported are keywords decoded into Schema fields.
+var supported = func() map[string]bool {
+	m := map[string]bool{}
+
+	t := reflect.TypeOf(Schema{})
+	for i := 0; i < t.NumField(); i++ {
+		if k := t.Field(i).Tag.Get("json"); k != "-" {
+			m[k] = true
+		}
+	}
+
+	return m
+}()
+
+// UnmarshalJSON decodes schema keywords, keeps vendor extensions and unknown
+// keywords as raw JSON and the order of properties, $defs and unknown
+// keywords. Boolean schemas are decoded as {} and {"not": {}}. The array form
+// of items of older drafts is kept in Unknown, Parse rejects it.
+func (s *Schema) UnmarshalJSON(b []byte) error {
+	type schema Schema
+
+	if o, ok := booleans[string(bytes.TrimSpace(b))]; ok {
+		*s = Schema{boolean: string(bytes.TrimSpace(b))}
+		b = []byte(o)
+	}
+
+	keywords := map[string]json.RawMessage{}
+	if err := json.Unmarshal(b, &keywords); err != nil {
+		return err
+	}
+
+	typed := b
+
+	tuple := bytes.HasPrefix(bytes.TrimSpace(keywords["items"]), []byte("["))
+	if tuple {
+		rest := make(map[string]json.RawMessage, len(keywords))
+		for k, v := range keywords {
+			if k != "items" {
+				rest[k] = v
+			}
+		}
+
+		var err error
+		if typed, err = json.Marshal(rest); err != nil {
+			return err
+		}
+	}
+
+	if err := json.Unmarshal(typed, (*schema)(s)); err != nil {
+		return err
+	}
+
+	names, err := keys(b)
+	if err != nil {
+		return err
+	}
+
+	for _, k := range names {
+		v := keywords[k]
+
+		switch {
+		case strings.HasPrefix(k, "x-"):
+			if s.Extensions == nil {
+				s.Extensions = map[string]json.RawMessage{}
+			}
+
+			s.Extensions[k] = v
+		case !supported[k] || k == "items" && tuple:
+			if s.Unknown == nil {
+				s.Unknown = map[string]json.RawMessage{}
+			}
+
+			if _, ok := s.Unknown[k]; !ok {
+				s.UnknownOrder = append(s.UnknownOrder, k)
+			}
+
+			s.Unknown[k] = v
+		}
+	}
+
+	if p, ok := keywords["properties"]; ok {
+		order, err := keys(p)
+		if err != nil {
+			return err
+		}
+
+		s.PropertyOrder = order
+	}
+
+	if d, ok := keywords["$defs"]; ok {
+		order, err := keys(d)
+		if err != nil {
+			return err
+		}
+
+		s.DefOrder = order
+	}
+
+	return nil
+}
+
+// legacy returns an error for keywords of older drafts, which AST can't
+// represent, in schema s located at JSON pointer base and its subschemas,
+// including ones within unknown keywords.
+func legacy(base string, s *Schema) error {
+	return Walk(s, func(ptr string, s *Schema) error {
+		ptr = base + ptr
 
-	// TODO(ekhabarov): Add an order to properties.
-	if err := json.NewDecoder(r).Decode(&sch); err != nil {
+		if _, ok := s.Unknown["items"]; ok {
+			return fmt.Errorf("%s/items: array form of items isn't supported, use prefixItems", ptr)
+		}
+
+		if _, ok := s.Unknown["definitions"]; ok {
+			return fmt.Errorf("%s/definitions: definitions aren't supported, use $defs", ptr)
+		}
+
+		subs, err := s.Subschemas()
+		if err != nil {
+			return fmt.Errorf("%s: %w", ptr, err)
+		}
+
+		for _, sub := range subs {
+			if err := legacy(ptr+sub.Ptr, sub.Schema); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// ParseOption configures Parse.
+type ParseOption func(*parser)
+
+type parser struct {
+	strictFormats bool
+}
+
+// StrictFormats makes Parse fail on formats, which are neither built-in nor
+// registered with RegisterFormat. By default such formats are annotations.
+func StrictFormats() ParseOption {
+	return func(p *parser) {
+		p.strictFormats = true
+	}
+}
+
+// Parse parses JSON schema into Abstract Syntax Tree. The schema is validated
+// against the metaschema of its $schema first, which reports all problems.
+// Draft-04 schemas and ones with unknown $schema are rejected, as well as
+// keywords of older drafts, which AST can't represent, e.g. definitions.
+func Parse(r io.Reader, opts ...ParseOption) (*Schema, error) {
+	var (
+		sch Schema
+		p   parser
+	)
+
+	for _, o := range opts {
+		o(&p)
+	}
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema: %w", err)
+	}
+
+	// Numbers are checked before metaschema, which would parse huge
+	// exponents too.
+	var doc interface{}
+
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+
+	if d.Decode(&doc) == nil {
+		if err := numbers(doc, ""); err != nil {
+			return nil, fmt.Errorf("failed to parse schema: %w", err)
+		}
+	}
+
+	if err := metaschema.Validate(b); err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %w", err)
+	}
+
+	// Draft-04 keywords, e.g. boolean exclusiveMinimum, have different
+	// meaning, which AST doesn't represent.
+	var dialect struct {
+		Schema string `json:"$schema"`
+	}
+
+	if json.Unmarshal(b, &dialect) == nil && strings.TrimSuffix(dialect.Schema, "#") == strings.TrimSuffix(metaschema.Draft4, "#") {
+		return nil, errors.New("failed to parse schema: /$schema: draft-04 isn't supported")
+	}
+
+	d = json.NewDecoder(bytes.NewReader(b))
+	if err := d.Decode(&sch); err != nil {
 		return nil, fmt.Errorf("failed to parse schema: %w", err)
 	}
 
+	if _, err := d.Token(); err != io.EOF {
+		return nil, errors.New("failed to parse schema: unexpected data after the schema")
+	}
+
+	if err := legacy("", &sch); err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %w", err)
+	}
+
+	if p.strictFormats {
+		err := Walk(&sch, func(ptr string, s *Schema) error {
+			if s.Format != "" && !s.Format.Known() {
+				return fmt.Errorf("%s/format: unsupported format: %q", ptr, s.Format.Name())
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse schema: %w", err)
+		}
+	}
+
 	return &sch, nil
 }
@@ -1,6 +1,8 @@
 package ast_test
 
 import (
+	"encoding/json"
+	"math"
 	"strings"
 
 	"github.com/ekhabarov/jsg/ast"
@@ -35,29 +37,48 @@ var _ = Describe("Ast", func() {
 				"exclusiveMinimum": 49
 			}`, Fields{
 				"Type":             Equal(ast.Number),
-				"MultipleOf":       Equal(10.0),
-				"Maximum":          Equal(100.0),
-				"ExclusiveMaximum": Equal(101.0),
-				"Minimum":          Equal(50.0),
-				"ExclusiveMinimum": Equal(49.0),
+				"MultipleOf":       Equal(ast.NewDecimal(10)),
+				"Maximum":          Equal(ast.NewDecimal(100)),
+				"ExclusiveMaximum": Equal(ast.NewDecimal(101)),
+				"Minimum":          Equal(ast.NewDecimal(50)),
+				"ExclusiveMinimum": Equal(ast.NewDecimal(49)),
+			}),
+
+			Entry("Number: exact values", `{"multipleOf": 0.01, "maximum": 1e400}`, Fields{
+				"MultipleOf": PointTo(WithTransform(ast.Decimal.String, Equal("0.01"))),
+				"Maximum":    PointTo(WithTransform(ast.Decimal.String, Equal("1e400"))),
 			}),
 
 			// $id
 
-			Entry("ID", `{"$id": "https://example.com/a/b/c#tail"}`, Fields{
-				"ID": Equal("https://example.com/a/b/c#tail"),
+			Entry("ID", `{"$id": "https://example.com/a/b/c"}`, Fields{
+				"ID": Equal("https://example.com/a/b/c"),
+			}),
+
+			Entry("Unknown keywords", `{"const": 1, "x-a": true, "type": "integer"}`, Fields{
+				"Unknown":    Equal(map[string]json.RawMessage{"const": json.RawMessage(`1`)}),
+				"Extensions": Equal(map[string]json.RawMessage{"x-a": json.RawMessage(`true`)}),
+			}),
+
+			Entry("Number: zero bounds", `{"type": "number", "minimum": 0}`, Fields{
+				"Minimum": Equal(ast.NewDecimal(0)),
+				"Maximum": BeNil(),
 			}),
 
 			Entry("", `{"type": "integer"}`, Fields{"Type": Equal(ast.Integer)}),
 
+			Entry("Enum", `{"enum": ["a", 1, null, true]}`, Fields{
+				"Enum": Equal([]json.RawMessage{json.RawMessage(`"a"`), json.RawMessage(`1`), json.RawMessage(`null`), json.RawMessage(`true`)}),
+			}),
+
 			// String type
 
 			// String length
 
 			Entry("String: length", `{"type": "string", "minLength": 3, "maxLength": 5}`, Fields{
 				"Type":      Equal(ast.String),
-				"MinLength": Equal(uint32(3)),
-				"MaxLength": Equal(uint32(5)),
+				"MinLength": PointTo(Equal(uint32(3))),
+				"MaxLength": PointTo(Equal(uint32(5))),
 			}),
 
 			// String pattern
@@ -164,6 +185,11 @@ var _ = Describe("Ast", func() {
 				"Format": Equal(ast.FormatRegex),
 			}),
 
+			Entry("String: unknown format", `{"type": "string", "format": "iso-country"}`, Fields{
+				"Type":   Equal(ast.String),
+				"Format": WithTransform(ast.StringFormat.Name, Equal("iso-country")),
+			}),
+
 			// Object
 
 			Entry("Object", `{"type": "object"}`, Fields{"Type": Equal(ast.Object)}),
@@ -205,15 +231,502 @@ var _ = Describe("Ast", func() {
 				}),
 			}),
 
+			Entry("Object: required and size", `{
+				"type": "object",
+				"required": ["a", "b"],
+				"minProperties": 1,
+				"maxProperties": 3
+			}`, Fields{
+				"Required":      Equal([]string{"a", "b"}),
+				"MinProperties": PointTo(Equal(uint32(1))),
+				"MaxProperties": PointTo(Equal(uint32(3))),
+			}),
+
 			// Array
 			Entry("", `{"type": "array"}`, Fields{"Type": Equal(ast.Array)}),
+
+			Entry("Array: items", `{
+				"type": "array",
+				"items": {"type": "string"},
+				"minItems": 1,
+				"maxItems": 10,
+				"uniqueItems": true
+			}`, Fields{
+				"Items":       PointTo(MatchFields(IgnoreExtras, Fields{"Type": Equal(ast.String)})),
+				"MinItems":    PointTo(Equal(uint32(1))),
+				"MaxItems":    PointTo(Equal(uint32(10))),
+				"UniqueItems": BeTrue(),
+			}),
+
 			Entry("", `{"type": "boolean"}`, Fields{"Type": Equal(ast.Boolean)}),
 			Entry("", `{"type": "null"}`, Fields{"Type": Equal(ast.Null)}),
 
+			Entry("$defs", `{"$defs": {"name": {"type": "string"}}}`, Fields{
+				"Defs": MatchAllKeys(Keys{
+					"name": MatchFields(IgnoreExtras, Fields{"Type": Equal(ast.String)}),
+				}),
+			}),
+
+			// Vendor extensions
+
+			Entry("x-go-optional", `{"x-go-optional": true}`, Fields{"Optional": PointTo(BeTrue())}),
+			Entry("x-go-tags", `{"x-go-tags": {"db": "-", "yaml": "name,flow"}}`, Fields{
+				"Tags": Equal(map[string]string{"db": "-", "yaml": "name,flow"}),
+			}),
+			Entry("x-go-*", `{
+				"x-go-type": "decimal.Decimal",
+				"x-go-package": "github.com/shopspring/decimal",
+				"x-go-name": "Amount",
+				"x-go-omitempty": false,
+				"x-go-pointer": true,
+				"x-go-embed": true,
+				"x-go-skip": true
+			}`, Fields{
+				"GoType":    Equal("decimal.Decimal"),
+				"GoPackage": Equal("github.com/shopspring/decimal"),
+				"GoName":    Equal("Amount"),
+				"OmitEmpty": PointTo(BeFalse()),
+				"Pointer":   PointTo(BeTrue()),
+				"Embed":     BeTrue(),
+				"Skip":      BeTrue(),
+			}),
+			Entry("Extensions", `{
+				"type": "string",
+				"x-go-name": "Code",
+				"x-vendor": {"a": [1, 2]}
+			}`, Fields{
+				"Extensions": Equal(map[string]json.RawMessage{
+					"x-go-name": json.RawMessage(`"Code"`),
+					"x-vendor":  json.RawMessage(`{"a": [1, 2]}`),
+				}),
+			}),
+			Entry("Extensions of subschemas", `{
+				"properties": {"a": {"x-kind": 1}}
+			}`, Fields{
+				"Properties": MatchAllKeys(Keys{
+					"a": MatchFields(IgnoreExtras, Fields{
+						"Extensions": Equal(map[string]json.RawMessage{"x-kind": json.RawMessage(`1`)}),
+					}),
+				}),
+			}),
+
 			Entry("", `{"type": ["string", "number", "boolean"]}`, Fields{
 				"Type": Equal(ast.String | ast.Number | ast.Boolean),
 			}),
 		)
 	})
 
+	Context("Decimal", func() {
+
+		DescribeTable("Values",
+			func(s, rat string, f float64, isInt bool) {
+				d, err := ast.ParseDecimal(s)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(d.String()).To(Equal(s))
+				Expect(d.Rat().RatString()).To(Equal(rat))
+				Expect(d.Float64()).To(Equal(f))
+				Expect(d.IsInt()).To(Equal(isInt))
+			},
+
+			Entry("", "0.01", "1/100", 0.01, false),
+			Entry("", "-2.50", "-5/2", -2.5, false),
+			Entry("", "1E2", "100", 100.0, true),
+			Entry("", "9007199254740993", "9007199254740993", 9007199254740992.0, true),
+			Entry("", "1e400", "1"+strings.Repeat("0", 400), math.Inf(1), true),
+		)
+
+		DescribeTable("Errors",
+			func(s string) {
+				_, err := ast.ParseDecimal(s)
+				Expect(err).To(MatchError(ContainSubstring("invalid number")))
+			},
+
+			Entry("", ""),
+			Entry("", "01"),
+			Entry("", "1."),
+			Entry("", "NaN"),
+			Entry("", "0x10"),
+		)
+
+		It("rejects numbers out of exact range", func() {
+			_, err := ast.ParseDecimal("1e-10000000")
+			Expect(err).To(MatchError("number 1e-10000000 is out of range"))
+
+			err = json.Unmarshal([]byte(`{"multipleOf": 1e10000000}`), &ast.Schema{})
+			Expect(err).To(MatchError(ContainSubstring("number 1e10000000 is out of range")))
+		})
+
+		It("rejects exponents beyond 10000 at their keywords", func() {
+			_, err := ast.ParseDecimal("1e10000")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = ast.ParseDecimal("1E+10001")
+			Expect(err).To(MatchError("number 1E+10001 is out of range"))
+
+			_, err = ast.Parse(strings.NewReader(`{"minimum": 1e9999999}`))
+			Expect(err).To(MatchError("failed to parse schema: /minimum: number 1e9999999 is out of range"))
+
+			_, err = ast.Parse(strings.NewReader(`{"properties": {"a/b": {"enum": [1, 1e-20000]}}}`))
+			Expect(err).To(MatchError("failed to parse schema: /properties/a~1b/enum/1: number 1e-20000 is out of range"))
+		})
+
+		It("returns copies of parsed values", func() {
+			d, err := ast.ParseDecimal("0.5")
+			Expect(err).NotTo(HaveOccurred())
+
+			d.Rat().SetInt64(7)
+			Expect(d.Rat().RatString()).To(Equal("1/2"))
+			Expect(ast.Decimal{}.Rat().RatString()).To(Equal("0"))
+		})
+
+		It("rejects non-numeric keywords", func() {
+			err := json.Unmarshal([]byte(`{"minimum": "1"}`), &ast.Schema{})
+			Expect(err).To(MatchError(ContainSubstring(`invalid number: "\"1\""`)))
+		})
+
+		It("marshals values as written", func() {
+			b, err := json.Marshal(struct{ M *ast.Decimal }{M: ast.NewDecimal(0.1)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(Equal(`{"M":0.1}`))
+		})
+	})
+
+	Context("MarshalJSON", func() {
+
+		DescribeTable("Schemas",
+			func(data, exp string) {
+				schema, err := ast.Parse(strings.NewReader(data))
+				Expect(err).NotTo(HaveOccurred())
+
+				b, err := json.Marshal(schema)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(b)).To(Equal(exp))
+			},
+
+			Entry("empty", `{}`, `{}`),
+			Entry("absent keywords are omitted", `{"type": "string", "minLength": 0, "pattern": "", "uniqueItems": false}`,
+				`{"type":"string","minLength":0}`),
+			Entry("canonical order", `{"required": ["b"], "type": "object", "$id": "https://example.com/a.json", "title": "A"}`,
+				`{"$id":"https://example.com/a.json","title":"A","type":"object","required":["b"]}`),
+			Entry("multiple types", `{"type": ["null", "string"]}`, `{"type":["string","null"]}`),
+			Entry("numbers as written", `{"multipleOf": 0.01, "maximum": 1e400}`, `{"multipleOf":0.01,"maximum":1e400}`),
+			Entry("enum values as written", `{"enum": [9007199254740993, 1.0, {"b": 1, "a": null}]}`,
+				`{"enum":[9007199254740993,1.0,{"b":1,"a":null}]}`),
+			Entry("formats", `{"format": "date-time", "items": {"format": "marshal-unknown"}}`,
+				`{"format":"date-time","items":{"format":"marshal-unknown"}}`),
+			Entry("property order", `{"properties": {"b": {}, "a": {"properties": {"y": {}, "x": {}}}}}`,
+				`{"properties":{"b":{},"a":{"properties":{"y":{},"x":{}}}}}`),
+			Entry("$ref after type", `{"type": "object", "$ref": "#/$defs/a", "title": "A", "$id": "https://example.com/a.json"}`,
+				`{"$id":"https://example.com/a.json","title":"A","type":"object","$ref":"#/$defs/a"}`),
+			Entry("$defs order", `{"$defs": {"zeta": {}, "alpha": {}}}`, `{"$defs":{"zeta":{},"alpha":{}}}`),
+			Entry("unknown keywords order", `{"zeta": 1, "type": "string", "alpha": 2, "$comment": "c"}`,
+				`{"type":"string","zeta":1,"alpha":2,"$comment":"c"}`),
+			Entry("vendor extensions", `{"x-b": [1], "x-go-type": "T", "x-a": {"k": "v"}, "x-go-embed": false}`,
+				`{"x-go-type":"T","x-a":{"k":"v"},"x-b":[1]}`),
+			Entry("boolean schemas", `{"items": false, "properties": {"a": true, "b": false}, "allOf": [true]}`,
+				`{"items":false,"allOf":[true],"properties":{"a":true,"b":false}}`),
+			Entry("boolean root", `true`, `true`),
+		)
+
+		It("writes changed boolean schemas as objects", func() {
+			schema, err := ast.Parse(strings.NewReader(`{"properties": {"a": true}}`))
+			Expect(err).NotTo(HaveOccurred())
+
+			a := schema.Properties["a"]
+			a.Type = ast.String
+			schema.Properties["a"] = a
+
+			b, err := json.Marshal(schema)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(Equal(`{"properties":{"a":{"type":"string"}}}`))
+		})
+
+		It("writes properties, which aren't ordered, in alphabetical order", func() {
+			b, err := json.Marshal(ast.Schema{
+				Properties:    map[string]ast.Schema{"c": {}, "b": {}, "a": {Type: ast.Integer}},
+				PropertyOrder: []string{"b", "none"},
+				GoType:        "T",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(Equal(`{"properties":{"b":{},"a":{"type":"integer"},"c":{}},"x-go-type":"T"}`))
+		})
+
+		It("writes $defs and unknown keywords, which aren't ordered, in alphabetical order", func() {
+			b, err := json.Marshal(ast.Schema{
+				Defs:         map[string]ast.Schema{"c": {}, "b": {}, "a": {}},
+				DefOrder:     []string{"b"},
+				Unknown:      map[string]json.RawMessage{"zeta": []byte("1"), "alpha": []byte("2")},
+				UnknownOrder: []string{"none"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(Equal(`{"alpha":2,"zeta":1,"$defs":{"b":{},"a":{},"c":{}}}`))
+		})
+
+		It("fails on invalid types", func() {
+			_, err := json.Marshal(ast.Schema{Items: &ast.Schema{Type: ast.SchemaType(128)}})
+			Expect(err).To(MatchError(ContainSubstring("invalid schema type: 128")))
+		})
+	})
+
+	Context("Format", func() {
+
+		It("writes schema in canonical layout", func() {
+			b, err := ast.Format([]byte(`{"properties": {"b": {"pattern": "<[a-z]+>", "type": "string"},
+				"a": {"oneOf": [{"type": "string"}, {"maximum": 1}], "x-go-name": "A"}},
+				"type": "object", "$comment": "c", "title": "T", "$schema": "https://json-schema.org/draft/2020-12/schema"}`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(Equal(`{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "title": "T",
+  "type": "object",
+  "properties": {
+    "b": {
+      "type": "string",
+      "pattern": "<[a-z]+>"
+    },
+    "a": {
+      "oneOf": [
+        {
+          "type": "string"
+        },
+        {
+          "maximum": 1
+        }
+      ],
+      "x-go-name": "A"
+    }
+  },
+  "$comment": "c"
+}
+`))
+		})
+
+		It("fails on invalid schemas", func() {
+			_, err := ast.Format([]byte(`{"type": "string"} {}`))
+			Expect(err).To(MatchError(ContainSubstring("failed to parse schema")))
+		})
+
+		It("fails on schemas violating the metaschema", func() {
+			_, err := ast.Format([]byte(`{"type": "string", "minLength": -1}`))
+			Expect(err).To(MatchError(ContainSubstring("failed to parse schema: /minLength")))
+		})
+	})
+
+	Context("Walk", func() {
+
+		It("visits subschemas in order", func() {
+			schema, err := ast.Parse(strings.NewReader(`{
+				"items": {"properties": {"x": {}}},
+				"properties": {"b": {}, "a/c": {}},
+				"allOf": [{}, {"items": {}}],
+				"$defs": {"d": {}}
+			}`))
+			Expect(err).NotTo(HaveOccurred())
+
+			ptrs := []string{}
+			err = ast.Walk(schema, func(ptr string, s *ast.Schema) error {
+				ptrs = append(ptrs, ptr)
+
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ptrs).To(Equal([]string{"", "/$defs/d", "/allOf/0", "/allOf/1", "/allOf/1/items", "/items", "/items/properties/x", "/properties/a~1c", "/properties/b"}))
+		})
+	})
+
+	Context("Subschemas", func() {
+
+		It("returns schemas within unknown keywords", func() {
+			schema, err := ast.Parse(strings.NewReader(`{
+				"oneOf": [{"type": "string"}, {"$ref": "#/$defs/a"}],
+				"not": {"const": 1},
+				"additionalProperties": false,
+				"dependencies": {"a": ["b"], "c": {"required": ["d"]}},
+				"patternProperties": {"^z": {}, "^y": {}},
+				"examples": [{}]
+			}`))
+			Expect(err).NotTo(HaveOccurred())
+
+			subs, err := schema.Subschemas()
+			Expect(err).NotTo(HaveOccurred())
+
+			ptrs := []string{}
+			for _, sub := range subs {
+				ptrs = append(ptrs, sub.Ptr)
+			}
+
+			Expect(ptrs).To(Equal([]string{"/dependencies/c", "/not", "/oneOf/0", "/oneOf/1", "/patternProperties/^z", "/patternProperties/^y"}))
+			Expect(subs[3].Schema.Ref).To(Equal("#/$defs/a"))
+		})
+
+		It("writes changed schemas back", func() {
+			schema, err := ast.Parse(strings.NewReader(`{
+				"anyOf": [{"$ref": "a.json"}, {"type": "string"}],
+				"patternProperties": {"^z": {"$ref": "a.json"}, "^y": {}}
+			}`))
+			Expect(err).NotTo(HaveOccurred())
+
+			subs, err := schema.Subschemas()
+			Expect(err).NotTo(HaveOccurred())
+
+			for _, sub := range subs {
+				if sub.Schema.Ref != "" {
+					sub.Schema.Ref = "b.json"
+				}
+			}
+
+			Expect(schema.SetSubschemas(subs)).To(Succeed())
+			Expect(string(schema.Unknown["anyOf"])).To(Equal(`[{"$ref":"b.json"},{"type":"string"}]`))
+			Expect(string(schema.Unknown["patternProperties"])).To(Equal(`{"^z":{"$ref":"b.json"},"^y":{}}`))
+		})
+	})
+
+	Context("StringFormat", func() {
+
+		DescribeTable("Name",
+			func(f ast.StringFormat, name string) {
+				Expect(f.Name()).To(Equal(name))
+			},
+
+			Entry("", ast.FormatDateTime, "date-time"),
+			Entry("", ast.FormatRelativeJSONPointer, "relative-json-pointer"),
+			Entry("", ast.StringFormat(""), ""),
+		)
+
+		It("keeps unknown formats as names", func() {
+			schema, err := ast.Parse(strings.NewReader(`{"format": "name-unknown"}`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(schema.Format).To(Equal(ast.StringFormat("name-unknown")))
+			Expect(schema.Format.Known()).To(BeFalse())
+
+			_, ok := schema.Format.Custom()
+			Expect(ok).To(BeFalse())
+
+			b, err := json.Marshal(schema)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(b).To(MatchJSON(`{"format": "name-unknown"}`))
+		})
+	})
+
+	Context("Metaschema", func() {
+
+		DescribeTable("rejects invalid schemas",
+			func(schema, msg string) {
+				_, err := ast.Parse(strings.NewReader(schema))
+				Expect(err).To(MatchError("failed to parse schema: " + msg))
+			},
+
+			Entry("wrong type", `{"type": "string", "maxLength": "abc"}`,
+				"/maxLength: expected integer, got string"),
+			Entry("negative multipleOf", `{"properties": {"a": {"multipleOf": -1}}}`,
+				"/properties/a/multipleOf: -1 is less than or equal to 0"),
+			Entry("$id with fragment", `{"$id": "https://example.com/a#tail"}`,
+				`/$id: "https://example.com/a#tail" does not match pattern "^[^#]*#?$"`),
+			Entry("all problems", `{"type": "strin", "items": 1, "required": ["a", "a"]}`,
+				`/items: expected object or boolean, got integer; `+
+					`/required: items at 0 and 1 are equal; `+
+					`/type: "strin" is not one of "array", "boolean", "integer", "null", "number", "object" or "string"`),
+			Entry("draft-07", `{"$schema": "http://json-schema.org/draft-07/schema#", "items": [{}, 1]}`,
+				"/items/1: expected object or boolean, got integer"),
+			Entry("draft-04", `{"$schema": "http://json-schema.org/draft-04/schema#", "minimum": 1, "exclusiveMinimum": true}`,
+				"/$schema: draft-04 isn't supported"),
+			Entry("unknown dialect", `{"$schema": "https://example.com/schema"}`,
+				`/$schema: unknown dialect "https://example.com/schema"`),
+			Entry("draft-07: array form of items", `{
+				"$schema": "http://json-schema.org/draft-07/schema#",
+				"properties": {"a": {"items": [{"type": "string"}, {}]}}
+			}`, "/properties/a/items: array form of items isn't supported, use prefixItems"),
+			Entry("2019-09: definitions", `{
+				"$schema": "https://json-schema.org/draft/2019-09/schema",
+				"$ref": "#/definitions/a",
+				"definitions": {"a": {}}
+			}`, "/definitions: definitions aren't supported, use $defs"),
+			Entry("definitions within unknown keyword", `{"oneOf": [{"definitions": {"a": {}}}]}`,
+				"/oneOf/0/definitions: definitions aren't supported, use $defs"),
+		)
+
+		It("decodes boolean schemas as equivalent objects", func() {
+			schema, err := ast.Parse(strings.NewReader(`{
+				"items": false,
+				"properties": {"a": true},
+				"$defs": {"b": false}
+			}`))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(schema.Items.Unknown).To(Equal(map[string]json.RawMessage{"not": json.RawMessage(`{}`)}))
+			Expect(schema.Properties["a"].Unknown).To(BeNil())
+			Expect(schema.Defs["b"].Unknown).To(HaveKey("not"))
+		})
+
+		It("keeps boolean schemas marked until they're changed", func() {
+			schema, err := ast.Parse(strings.NewReader(`{"properties": {"a": true, "b": false, "c": {"not": {}}}}`))
+			Expect(err).NotTo(HaveOccurred())
+
+			for n, exp := range map[string][2]bool{"a": {true, true}, "b": {false, true}, "c": {false, false}} {
+				p := schema.Properties[n]
+				v, ok := p.Boolean()
+				Expect([2]bool{v, ok}).To(Equal(exp), n)
+			}
+
+			b := schema.Properties["b"]
+			b.Type = ast.String
+			_, ok := b.Boolean()
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Context("StrictFormats", func() {
+
+		It("rejects unknown formats", func() {
+			_, err := ast.Parse(strings.NewReader(`{
+				"properties": {"a": {"type": "string", "format": "strict-unknown"}}
+			}`), ast.StrictFormats())
+
+			Expect(err).To(MatchError(`failed to parse schema: /properties/a/format: unsupported format: "strict-unknown"`))
+		})
+
+		It("accepts registered formats", func() {
+			_, err := ast.RegisterFormat(ast.FormatDef{Name: "strict-known"})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = ast.Parse(strings.NewReader(`{"format": "strict-known"}`), ast.StrictFormats())
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("RegisterFormat", func() {
+
+		It("keeps the value of a format found before registration", func() {
+			schema, err := ast.Parse(strings.NewReader(`{"format": "semver"}`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(schema.Format.Known()).To(BeFalse())
+
+			f, err := ast.RegisterFormat(ast.FormatDef{
+				Name:   "semver",
+				GoType: "semver.Version",
+				Import: "github.com/Masterminds/semver",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(f).To(Equal(schema.Format))
+			Expect(f.Known()).To(BeTrue())
+			Expect(f.Name()).To(Equal("semver"))
+
+			def, ok := f.Custom()
+			Expect(ok).To(BeTrue())
+			Expect(def.GoType).To(Equal("semver.Version"))
+		})
+
+		DescribeTable("Errors",
+			func(def ast.FormatDef, msg string) {
+				_, err := ast.RegisterFormat(def)
+				Expect(err).To(MatchError(msg))
+			},
+
+			Entry("", ast.FormatDef{}, "format name is required"),
+			Entry("", ast.FormatDef{Name: "email"}, `format "email" is built-in`),
+			Entry("", ast.FormatDef{Name: "x", Import: "x"}, `format "x": import path without Go type`),
+		)
+	})
+
 })
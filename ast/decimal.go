@@ -0,0 +1,140 @@
+package ast
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ekhabarov/jsg/lib"
+)
+
+// number matches JSON numbers.
+var number = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// maxExponent limits exponents of numbers, e.g. 1e9999999 would make
+// big.Rat allocate megabytes.
+const maxExponent = 10000
+
+// Decimal is an exact value of a numeric keyword, e.g. "multipleOf": 0.01,
+// which float64 can't represent. It keeps the number as written in the
+// schema. Zero value is 0.
+type Decimal struct {
+	lit string
+	// rat is the parsed lit, nil for zero value.
+	rat *big.Rat
+}
+
+// NewDecimal returns decimal of finite number f in the shortest
+// representation, e.g. 0.1.
+func NewDecimal(f float64) *Decimal {
+	lit := strconv.FormatFloat(f, 'g', -1, 64)
+	r, _ := new(big.Rat).SetString(lit)
+
+	return &Decimal{lit: lit, rat: r}
+}
+
+// ParseDecimal parses JSON number s. Numbers with exponents beyond ±10000,
+// e.g. 1e-10000000, are rejected.
+func ParseDecimal(s string) (*Decimal, error) {
+	if !number.MatchString(s) {
+		return nil, fmt.Errorf("invalid number: %q", s)
+	}
+
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		if e, err := strconv.Atoi(s[i+1:]); err != nil || e > maxExponent || e < -maxExponent {
+			return nil, fmt.Errorf("number %s is out of range", s)
+		}
+	}
+
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, fmt.Errorf("number %s is out of range", s)
+	}
+
+	return &Decimal{lit: s, rat: r}, nil
+}
+
+// numbers checks, that all numbers of decoded JSON value v at pointer ptr
+// are parsed by ParseDecimal, so the error is located at the keyword.
+func numbers(v interface{}, ptr string) error {
+	switch v := v.(type) {
+	case json.Number:
+		if _, err := ParseDecimal(v.String()); err != nil {
+			return fmt.Errorf("%s: %w", ptr, err)
+		}
+	case []interface{}:
+		for i, e := range v {
+			if err := numbers(e, ptr+"/"+strconv.Itoa(i)); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		names := make([]string, 0, len(v))
+		for n := range v {
+			names = append(names, n)
+		}
+
+		sort.Strings(names)
+
+		for _, n := range names {
+			if err := numbers(v[n], ptr+"/"+lib.EscapePointer(n)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Rat returns the exact value of d. The value is parsed once by ParseDecimal
+// or NewDecimal, Rat returns its copy, which callers may change.
+func (d Decimal) Rat() *big.Rat {
+	if d.rat == nil {
+		return new(big.Rat)
+	}
+
+	return new(big.Rat).Set(d.rat)
+}
+
+// Float64 returns the nearest float64 value of d, or ±Inf if d is out of
+// float64 range.
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+
+	return f
+}
+
+// IsInt reports whether d is an integer.
+func (d Decimal) IsInt() bool {
+	return d.Rat().IsInt()
+}
+
+// String returns d as written in the schema.
+func (d Decimal) String() string {
+	if d.lit == "" {
+		return "0"
+	}
+
+	return d.lit
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Decimal) UnmarshalJSON(b []byte) error {
+	v, err := ParseDecimal(string(b))
+	if err != nil {
+		return err
+	}
+
+	*d = *v
+
+	return nil
+}
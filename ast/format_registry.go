@@ -0,0 +1,78 @@
+package ast
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// FormatDef defines a custom format.
+type FormatDef struct {
+	// Name is a format name used in schemas, e.g. "semver".
+	Name string
+	// Check checks whether a string conforms to the format. Nil Check accepts
+	// any string.
+	Check func(string) error
+	// GoType is a Go type generated for the format, e.g. "semver.Version".
+	// Empty GoType means string.
+	GoType string
+	// Import is an import path of a package with GoType, if necessary.
+	Import string
+}
+
+// registry keeps registered custom formats by their names.
+var registry = struct {
+	sync.RWMutex
+
+	defs map[StringFormat]FormatDef
+}{
+	defs: map[StringFormat]FormatDef{},
+}
+
+// RegisterFormat registers custom format def, so schemas with the format are
+// checked with def.Check during validation and use def.GoType in generated
+// code. Registering the same name again replaces its definition, built-in
+// formats can't be redefined.
+func RegisterFormat(def FormatDef) (StringFormat, error) {
+	if def.Name == "" {
+		return "", errors.New("format name is required")
+	}
+
+	f := StringFormat(def.Name)
+	if f.builtin() {
+		return "", fmt.Errorf("format %q is built-in", def.Name)
+	}
+
+	if def.Import != "" && def.GoType == "" {
+		return "", fmt.Errorf("format %q: import path without Go type", def.Name)
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	registry.defs[f] = def
+
+	return f, nil
+}
+
+// Custom returns a definition of registered custom format. It returns false
+// for built-in and unknown formats.
+func (sf StringFormat) Custom() (FormatDef, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	def, ok := registry.defs[sf]
+
+	return def, ok
+}
+
+// Known reports whether sf is a built-in or registered custom format.
+func (sf StringFormat) Known() bool {
+	if sf.builtin() {
+		return true
+	}
+
+	_, ok := sf.Custom()
+
+	return ok
+}
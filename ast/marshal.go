@@ -0,0 +1,267 @@
+package ast
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MarshalJSON implements json.Marshaler. It writes keywords, which are set,
+// in canonical order: identifiers, annotations and $ref first, then
+// assertions, subschemas, unknown keywords, $defs and vendor extensions.
+// Properties, $defs and unknown keywords keep PropertyOrder, DefOrder and
+// UnknownOrder. Decoded boolean schemas are written as booleans.
+func (s Schema) MarshalJSON() ([]byte, error) {
+	o := &object{}
+
+	o.add("$schema", s.Schema, s.Schema != "")
+	o.add("$id", s.ID, s.ID != "")
+	o.add("title", s.Title, s.Title != "")
+	o.add("description", s.Description, s.Description != "")
+	o.add("type", s.Type, s.Type != 0)
+	o.add("$ref", s.Ref, s.Ref != "")
+	o.add("enum", s.Enum, s.Enum != nil)
+	o.add("format", s.Format, s.Format != "")
+
+	o.add("multipleOf", s.MultipleOf, s.MultipleOf != nil)
+	o.add("minimum", s.Minimum, s.Minimum != nil)
+	o.add("exclusiveMinimum", s.ExclusiveMinimum, s.ExclusiveMinimum != nil)
+	o.add("maximum", s.Maximum, s.Maximum != nil)
+	o.add("exclusiveMaximum", s.ExclusiveMaximum, s.ExclusiveMaximum != nil)
+
+	o.add("minLength", s.MinLength, s.MinLength != nil)
+	o.add("maxLength", s.MaxLength, s.MaxLength != nil)
+	o.add("pattern", s.Pattern, s.Pattern != "")
+
+	o.add("items", s.Items, s.Items != nil)
+	o.add("minItems", s.MinItems, s.MinItems != nil)
+	o.add("maxItems", s.MaxItems, s.MaxItems != nil)
+	o.add("uniqueItems", s.UniqueItems, s.UniqueItems)
+
+	o.add("allOf", s.AllOf, s.AllOf != nil)
+	o.add("properties", ordered{s.Properties, s.PropertyOrder}, s.Properties != nil)
+	o.add("required", s.Required, s.Required != nil)
+	o.add("minProperties", s.MinProperties, s.MinProperties != nil)
+	o.add("maxProperties", s.MaxProperties, s.MaxProperties != nil)
+
+	o.raw(s.Unknown, s.UnknownOrder, nil)
+	o.add("$defs", ordered{s.Defs, s.DefOrder}, s.Defs != nil)
+
+	o.add("x-go-optional", s.Optional, s.Optional != nil)
+	o.add("x-go-tags", s.Tags, s.Tags != nil)
+	o.add("x-go-type", s.GoType, s.GoType != "")
+	o.add("x-go-package", s.GoPackage, s.GoPackage != "")
+	o.add("x-go-name", s.GoName, s.GoName != "")
+	o.add("x-go-omitempty", s.OmitEmpty, s.OmitEmpty != nil)
+	o.add("x-go-pointer", s.Pointer, s.Pointer != nil)
+	o.add("x-go-embed", s.Embed, s.Embed)
+	o.add("x-go-skip", s.Skip, s.Skip)
+
+	o.raw(s.Extensions, nil, typed)
+
+	b, err := o.bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	// Boolean schemas, which aren't changed since decoding, are written back
+	// as booleans.
+	if s.boolean != "" && string(b) == booleans[s.boolean] {
+		return []byte(s.boolean), nil
+	}
+
+	return b, nil
+}
+
+// typed are vendor extensions, which are kept in Schema fields.
+var typed = map[string]bool{
+	"x-go-optional":  true,
+	"x-go-tags":      true,
+	"x-go-type":      true,
+	"x-go-package":   true,
+	"x-go-name":      true,
+	"x-go-omitempty": true,
+	"x-go-pointer":   true,
+	"x-go-embed":     true,
+	"x-go-skip":      true,
+}
+
+// object writes JSON object members in order of addition. It keeps the first
+// error.
+type object struct {
+	buf bytes.Buffer
+	err error
+}
+
+// add adds member k with value v, if ok.
+func (o *object) add(k string, v interface{}, ok bool) {
+	if !ok || o.err != nil {
+		return
+	}
+
+	b, err := marshal(v, "")
+	if err != nil {
+		o.err = fmt.Errorf("%s: %w", k, err)
+
+		return
+	}
+
+	if o.buf.Len() == 0 {
+		o.buf.WriteByte('{')
+	} else {
+		o.buf.WriteByte(',')
+	}
+
+	name, _ := marshal(k, "")
+	o.buf.Write(name)
+	o.buf.WriteByte(':')
+	o.buf.Write(b)
+}
+
+// raw adds members of m in order of names, followed by the rest of them in
+// alphabetical order, except for skipped ones.
+func (o *object) raw(m map[string]json.RawMessage, names []string, skip map[string]bool) {
+	seen := map[string]bool{}
+
+	for _, k := range names {
+		if v, ok := m[k]; ok && !seen[k] && !skip[k] {
+			seen[k] = true
+			o.add(k, v, true)
+		}
+	}
+
+	rest := make([]string, 0, len(m))
+	for k := range m {
+		if !seen[k] && !skip[k] {
+			rest = append(rest, k)
+		}
+	}
+
+	sort.Strings(rest)
+
+	for _, k := range rest {
+		o.add(k, m[k], true)
+	}
+}
+
+func (o *object) bytes() ([]byte, error) {
+	if o.err != nil {
+		return nil, o.err
+	}
+
+	if o.buf.Len() == 0 {
+		return []byte("{}"), nil
+	}
+
+	o.buf.WriteByte('}')
+
+	return o.buf.Bytes(), nil
+}
+
+// ordered is a map of schemas, which is written in order of names, followed
+// by the rest of names of m in alphabetical order.
+type ordered struct {
+	m     map[string]Schema
+	names []string
+}
+
+// MarshalJSON implements json.Marshaler.
+func (m ordered) MarshalJSON() ([]byte, error) {
+	o := &object{}
+	seen := map[string]bool{}
+
+	for _, n := range m.names {
+		if s, ok := m.m[n]; ok && !seen[n] {
+			seen[n] = true
+			o.add(n, s, true)
+		}
+	}
+
+	rest := make([]string, 0, len(m.m))
+	for n := range m.m {
+		if !seen[n] {
+			rest = append(rest, n)
+		}
+	}
+
+	sort.Strings(rest)
+
+	for _, n := range rest {
+		o.add(n, m.m[n], true)
+	}
+
+	return o.bytes()
+}
+
+// marshal returns JSON of v, indented if indent isn't empty, without HTML
+// escaping, so that patterns like "<[a-z]+>" are written as is.
+func marshal(v interface{}, indent string) ([]byte, error) {
+	b := &bytes.Buffer{}
+
+	e := json.NewEncoder(b)
+	e.SetEscapeHTML(false)
+	e.SetIndent("", indent)
+
+	if err := e.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n")), nil
+}
+
+// Format returns schema src in canonical layout, see Marshal. Schemas, which
+// Parse rejects, e.g. ones violating their metaschema, aren't formatted.
+func Format(src []byte, opts ...ParseOption) ([]byte, error) {
+	s, err := Parse(bytes.NewReader(src), opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return Marshal(s)
+}
+
+// Marshal returns schema s in canonical layout, which is written by
+// MarshalJSON, indented with two spaces and ends with a newline.
+func Marshal(s *Schema) ([]byte, error) {
+	b, err := marshal(s, "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(b, '\n'), nil
+}
+
+// keys returns member names of JSON object b in order, or nil for null.
+func keys(b []byte) ([]string, error) {
+	d := json.NewDecoder(bytes.NewReader(b))
+
+	t, err := d.Token()
+	switch {
+	case err != nil:
+		return nil, err
+	case t == nil:
+		return nil, nil
+	case t != json.Delim('{'):
+		return nil, fmt.Errorf("invalid object: %s", strings.TrimSpace(string(b)))
+	}
+
+	names := []string{}
+
+	for d.More() {
+		t, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		var v json.RawMessage
+		if err := d.Decode(&v); err != nil {
+			return nil, err
+		}
+
+		names = append(names, t.(string))
+	}
+
+	return names, nil
+}
@@ -1,6 +1,7 @@
 package ast
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -54,6 +55,41 @@ func (st *SchemaType) UnmarshalJSON(b []byte) error {
 	}
 }
 
+// MarshalJSON implements json.Marshaler. Multiple types are written as an
+// array, e.g. ["string", "null"].
+func (st SchemaType) MarshalJSON() ([]byte, error) {
+	names := []string{}
+
+	for _, t := range types {
+		if st&t.t != 0 {
+			names = append(names, t.name)
+		}
+	}
+
+	switch {
+	case len(names) == 0:
+		return nil, fmt.Errorf("invalid schema type: %d", st)
+	case len(names) == 1:
+		return json.Marshal(names[0])
+	}
+
+	return json.Marshal(names)
+}
+
+// types are names of schema types.
+var types = []struct {
+	t    SchemaType
+	name string
+}{
+	{String, "string"},
+	{Number, "number"},
+	{Integer, "integer"},
+	{Object, "object"},
+	{Array, "array"},
+	{Boolean, "boolean"},
+	{Null, "null"},
+}
+
 func typ(t string) (SchemaType, error) {
 	switch t {
 	case `"string"`:
@@ -75,25 +111,41 @@ func typ(t string) (SchemaType, error) {
 	return SchemaType(0), fmt.Errorf("unsupported type: %s", t)
 }
 
+// rtPackage is an import path of runtime package with types of formats, which
+// have no suitable standard types.
+const rtPackage = "github.com/ekhabarov/jsg/rt"
+
 // GoType returns a Go type mapped to schema type, and imported package name, if
 // necessary.
 func GoType(st SchemaType, format StringFormat, ref string) (string, string, error) {
 	switch st {
 	case String:
 		switch format {
-		case FormatDateTime, FormatDate, FormatTime:
+		case FormatDateTime:
 			return "time.Time", "time", nil
+		case FormatDate:
+			return "rt.Date", rtPackage, nil
+		case FormatTime:
+			return "rt.TimeOfDay", rtPackage, nil
 		case FormatDuration:
-			return "time.Duration", "time", nil
-		case FormatIPv4, FormatIPv6:
-			return "net.IP", "net", nil
+			return "rt.ISODuration", rtPackage, nil
+		case FormatIPv4:
+			return "rt.IPv4", rtPackage, nil
+		case FormatIPv6:
+			return "rt.IPv6", rtPackage, nil
 		case FormatRegex:
-			return "regexp.Regexp", "regexp", nil
+			return "rt.Regexp", rtPackage, nil
+		case FormatURI:
+			return "rt.URL", rtPackage, nil
 		case FormatUUID:
 			return "uuid.UUID", "github.com/gofrs/uuid", nil
-		default:
-			return "string", "", nil
 		}
+
+		if def, ok := format.Custom(); ok && def.GoType != "" {
+			return def.GoType, def.Import, nil
+		}
+
+		return "string", "", nil
 	case Integer:
 		return "int", "", nil
 	case Number:
@@ -107,7 +159,7 @@ func GoType(st SchemaType, format StringFormat, ref string) (string, string, err
 	}
 
 	if ref != "" {
-		r, err := lib.URLName(ref)
+		r, err := lib.RefName(ref)
 		if err != nil {
 			return "", "", fmt.Errorf("malformed $ref: %w", err)
 		}
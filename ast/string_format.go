@@ -1,30 +1,37 @@
 package ast
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+)
 
+// StringFormat is a name of one of built-in formats or a custom one, see
+// RegisterFormat. Formats, which are neither built-in nor registered, keep
+// their names too, they're annotations only.
+//
 // https://json-schema.org/understanding-json-schema/reference/string.html
-type StringFormat uint8
+type StringFormat string
 
 const (
-	FormatDateTime StringFormat = iota + 1
-	FormatTime
-	FormatDate
-	FormatDuration
-	FormatEmail
-	FormatIdnEmail
-	FormatHostname
-	FormatIdnHostname
-	FormatIPv4
-	FormatIPv6
-	FormatUUID
-	FormatURI
-	FormatURIReference
-	FormatIRI
-	FormatIRIReference
-	FormatURITemplate
-	FormatJSONPointer
-	FormatRelativeJSONPointer
-	FormatRegex
+	FormatDateTime            StringFormat = "date-time"
+	FormatTime                StringFormat = "time"
+	FormatDate                StringFormat = "date"
+	FormatDuration            StringFormat = "duration"
+	FormatEmail               StringFormat = "email"
+	FormatIdnEmail            StringFormat = "idn-email"
+	FormatHostname            StringFormat = "hostname"
+	FormatIdnHostname         StringFormat = "idn-hostname"
+	FormatIPv4                StringFormat = "ipv4"
+	FormatIPv6                StringFormat = "ipv6"
+	FormatUUID                StringFormat = "uuid"
+	FormatURI                 StringFormat = "uri"
+	FormatURIReference        StringFormat = "uri-reference"
+	FormatIRI                 StringFormat = "iri"
+	FormatIRIReference        StringFormat = "iri-reference"
+	FormatURITemplate         StringFormat = "uri-template"
+	FormatJSONPointer         StringFormat = "json-pointer"
+	FormatRelativeJSONPointer StringFormat = "relative-json-pointer"
+	FormatRegex               StringFormat = "regex"
 )
 
 func (sf *StringFormat) UnmarshalJSON(b []byte) error {
@@ -38,47 +45,50 @@ func (sf *StringFormat) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// MarshalJSON implements json.Marshaler.
+func (sf StringFormat) MarshalJSON() ([]byte, error) {
+	return json.Marshal(string(sf))
+}
+
+// Name returns the name of the format used in JSON schema, e.g. "date-time".
+func (sf StringFormat) Name() string {
+	return string(sf)
+}
+
+// builtin reports whether sf is a built-in format.
+func (sf StringFormat) builtin() bool {
+	return formats[string(sf)]
+}
+
+// formats are names of built-in formats.
+var formats = map[string]bool{
+	"date-time":             true,
+	"time":                  true,
+	"date":                  true,
+	"duration":              true,
+	"email":                 true,
+	"idn-email":             true,
+	"hostname":              true,
+	"idn-hostname":          true,
+	"ipv4":                  true,
+	"ipv6":                  true,
+	"uuid":                  true,
+	"uri":                   true,
+	"uri-reference":         true,
+	"iri":                   true,
+	"iri-reference":         true,
+	"uri-template":          true,
+	"json-pointer":          true,
+	"relative-json-pointer": true,
+	"regex":                 true,
+}
+
 func format(t string) (StringFormat, error) {
-	switch t {
-	case `"date-time"`:
-		return FormatDateTime, nil
-	case `"time"`:
-		return FormatTime, nil
-	case `"date"`:
-		return FormatDate, nil
-	case `"duration"`:
-		return FormatDuration, nil
-	case `"email"`:
-		return FormatEmail, nil
-	case `"idn-email"`:
-		return FormatIdnEmail, nil
-	case `"hostname"`:
-		return FormatHostname, nil
-	case `"idn-hostname"`:
-		return FormatIdnHostname, nil
-	case `"ipv4"`:
-		return FormatIPv4, nil
-	case `"ipv6"`:
-		return FormatIPv6, nil
-	case `"uuid"`:
-		return FormatUUID, nil
-	case `"uri"`:
-		return FormatURI, nil
-	case `"uri-reference"`:
-		return FormatURIReference, nil
-	case `"iri"`:
-		return FormatIRI, nil
-	case `"iri-reference"`:
-		return FormatIRIReference, nil
-	case `"uri-template"`:
-		return FormatURITemplate, nil
-	case `"json-pointer"`:
-		return FormatJSONPointer, nil
-	case `"relative-json-pointer"`:
-		return FormatRelativeJSONPointer, nil
-	case `"regex"`:
-		return FormatRegex, nil
+	var n string
+	if err := json.Unmarshal([]byte(t), &n); err != nil {
+		return "", fmt.Errorf("invalid format: %s", t)
 	}
 
-	return StringFormat(0), fmt.Errorf("unsupported format: %s", t)
+	// Unknown formats are annotations, unless StrictFormats option is used.
+	return StringFormat(n), nil
 }
@@ -0,0 +1,233 @@
+package ast
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/ekhabarov/jsg/lib"
+)
+
+// shape is a shape of a keyword value with subschemas.
+type shape uint8
+
+const (
+	// single is a schema, e.g. "not".
+	single shape = iota
+	// list is an array of schemas, e.g. "oneOf".
+	list
+	// members is an object with schemas, e.g. "patternProperties".
+	members
+)
+
+// applicators are keywords with subschemas, which are kept in Unknown.
+var applicators = map[string]shape{
+	"additionalItems":       single,
+	"additionalProperties":  single,
+	"contains":              single,
+	"contentSchema":         single,
+	"else":                  single,
+	"if":                    single,
+	"not":                   single,
+	"propertyNames":         single,
+	"then":                  single,
+	"unevaluatedItems":      single,
+	"unevaluatedProperties": single,
+	"anyOf":                 list,
+	"oneOf":                 list,
+	"prefixItems":           list,
+	"definitions":           members,
+	"dependencies":          members,
+	"dependentSchemas":      members,
+	"patternProperties":     members,
+}
+
+// Subschema is a schema within an Unknown keyword, e.g. an item of oneOf.
+type Subschema struct {
+	// Ptr is a JSON pointer to the schema relative to its parent, e.g.
+	// "/oneOf/0".
+	Ptr    string
+	Schema *Schema
+
+	keyword string
+	// member is an index or a name of the schema within the keyword value,
+	// it's empty for keywords with a single schema.
+	member string
+	// orig is JSON of the schema as it's decoded.
+	orig []byte
+}
+
+// Subschemas returns schemas within Unknown keywords of s, e.g. items of
+// oneOf or additionalProperties, in order of keywords and their members.
+// Boolean schemas and other values, e.g. property names listed in
+// dependencies, are skipped.
+func (s *Schema) Subschemas() ([]Subschema, error) {
+	kk := make([]string, 0, len(s.Unknown))
+	for k := range s.Unknown {
+		if _, ok := applicators[k]; ok {
+			kk = append(kk, k)
+		}
+	}
+
+	sort.Strings(kk)
+
+	subs := []Subschema{}
+
+	for _, k := range kk {
+		raw := s.Unknown[k]
+		ptr := "/" + lib.EscapePointer(k)
+
+		switch applicators[k] {
+		case single:
+			if !isObject(raw) {
+				continue
+			}
+
+			sub, err := decode(ptr, raw)
+			if err != nil {
+				return nil, err
+			}
+
+			subs = append(subs, sub.at(k, ""))
+		case list:
+			var items []json.RawMessage
+			if err := json.Unmarshal(raw, &items); err != nil {
+				return nil, fmt.Errorf("%s: %w", ptr, err)
+			}
+
+			for i, item := range items {
+				if !isObject(item) {
+					continue
+				}
+
+				sub, err := decode(ptr+"/"+strconv.Itoa(i), item)
+				if err != nil {
+					return nil, err
+				}
+
+				subs = append(subs, sub.at(k, strconv.Itoa(i)))
+			}
+		case members:
+			names, err := keys(raw)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", ptr, err)
+			}
+
+			m := map[string]json.RawMessage{}
+			if err := json.Unmarshal(raw, &m); err != nil {
+				return nil, fmt.Errorf("%s: %w", ptr, err)
+			}
+
+			for _, n := range names {
+				if !isObject(m[n]) {
+					continue
+				}
+
+				sub, err := decode(ptr+"/"+lib.EscapePointer(n), m[n])
+				if err != nil {
+					return nil, err
+				}
+
+				subs = append(subs, sub.at(k, n))
+			}
+		}
+	}
+
+	return subs, nil
+}
+
+// SetSubschemas writes schemas returned by Subschemas back into Unknown
+// keywords of s, e.g. after their references are rewritten. Unchanged
+// schemas keep their raw JSON.
+func (s *Schema) SetSubschemas(subs []Subschema) error {
+	for _, sub := range subs {
+		b, err := marshal(sub.Schema, "")
+		if err != nil {
+			return fmt.Errorf("%s: %w", sub.Ptr, err)
+		}
+
+		if bytes.Equal(b, sub.orig) {
+			continue
+		}
+
+		raw := s.Unknown[sub.keyword]
+
+		switch applicators[sub.keyword] {
+		case single:
+			raw = b
+		case list:
+			var items []json.RawMessage
+			if err := json.Unmarshal(raw, &items); err != nil {
+				return fmt.Errorf("%s: %w", sub.Ptr, err)
+			}
+
+			i, err := strconv.Atoi(sub.member)
+			if err != nil || i >= len(items) {
+				return fmt.Errorf("%s: no such item", sub.Ptr)
+			}
+
+			items[i] = b
+
+			if raw, err = marshal(items, ""); err != nil {
+				return fmt.Errorf("%s: %w", sub.Ptr, err)
+			}
+		case members:
+			names, err := keys(raw)
+			if err != nil {
+				return fmt.Errorf("%s: %w", sub.Ptr, err)
+			}
+
+			m := map[string]json.RawMessage{}
+			if err := json.Unmarshal(raw, &m); err != nil {
+				return fmt.Errorf("%s: %w", sub.Ptr, err)
+			}
+
+			m[sub.member] = b
+
+			// Members keep their order.
+			o := &object{}
+			for _, n := range names {
+				o.add(n, m[n], true)
+			}
+
+			if raw, err = o.bytes(); err != nil {
+				return fmt.Errorf("%s: %w", sub.Ptr, err)
+			}
+		default:
+			return fmt.Errorf("%s: unknown keyword", sub.Ptr)
+		}
+
+		s.Unknown[sub.keyword] = raw
+	}
+
+	return nil
+}
+
+// decode decodes subschema raw located at ptr.
+func decode(ptr string, raw json.RawMessage) (*Subschema, error) {
+	s := &Schema{}
+	if err := json.Unmarshal(raw, s); err != nil {
+		return nil, fmt.Errorf("%s: %w", ptr, err)
+	}
+
+	b, err := marshal(s, "")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ptr, err)
+	}
+
+	return &Subschema{Ptr: ptr, Schema: s, orig: b}, nil
+}
+
+// at returns sub located at member of keyword k.
+func (sub *Subschema) at(k, member string) Subschema {
+	sub.keyword, sub.member = k, member
+
+	return *sub
+}
+
+// isObject reports whether JSON value raw is an object.
+func isObject(raw json.RawMessage) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(raw), []byte("{"))
+}
@@ -0,0 +1,63 @@
+package ast
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/ekhabarov/jsg/lib"
+)
+
+// WalkFunc is called by Walk for every schema, where ptr is a JSON pointer to
+// the schema within the root one.
+type WalkFunc func(ptr string, s *Schema) error
+
+// Walk calls f for schema s and all its subschemas, depth-first, in a stable
+// order. It stops on the first error returned by f. Subschemas passed to f
+// may be copies, so changes made by f aren't guaranteed to persist.
+func Walk(s *Schema, f WalkFunc) error {
+	return walk(s, "", f)
+}
+
+func walk(s *Schema, ptr string, f WalkFunc) error {
+	if err := f(ptr, s); err != nil {
+		return err
+	}
+
+	if err := walkMap(s.Defs, ptr+"/$defs", f); err != nil {
+		return err
+	}
+
+	for i := range s.AllOf {
+		if err := walk(&s.AllOf[i], ptr+"/allOf/"+strconv.Itoa(i), f); err != nil {
+			return err
+		}
+	}
+
+	if s.Items != nil {
+		if err := walk(s.Items, ptr+"/items", f); err != nil {
+			return err
+		}
+	}
+
+	return walkMap(s.Properties, ptr+"/properties", f)
+}
+
+// walkMap walks schemas of m in order of their names, where ptr is a JSON
+// pointer to m.
+func walkMap(m map[string]Schema, ptr string, f WalkFunc) error {
+	names := make([]string, 0, len(m))
+	for n := range m {
+		names = append(names, n)
+	}
+
+	sort.Strings(names)
+
+	for _, n := range names {
+		s := m[n]
+		if err := walk(&s, ptr+"/"+lib.EscapePointer(n), f); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
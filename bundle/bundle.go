@@ -0,0 +1,456 @@
+// Package bundle combines schema resources, which reference each other, into
+// a single compound schema document and splits such documents back.
+//
+// https://json-schema.org/draft/2020-12/json-schema-core.html#rfc.section.9.3
+package bundle
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ekhabarov/jsg/ast"
+	"github.com/ekhabarov/jsg/lib"
+)
+
+// Loader returns a schema resource by its absolute URI without a fragment.
+type Loader func(uri string) (*ast.Schema, error)
+
+// DirLoader returns Loader, which reads schemas from directory dir by the
+// last segment of URI path, e.g. dir/address.json for
+// https://example.com/schemas/address.json.
+func DirLoader(dir string) Loader {
+	return func(uri string) (*ast.Schema, error) {
+		u, err := url.Parse(uri)
+		if err != nil {
+			return nil, err
+		}
+
+		f, err := os.Open(filepath.Join(dir, path.Base(u.Path)))
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		return ast.Parse(f)
+	}
+}
+
+// Bundle returns a copy of schema root with every external resource it
+// references, directly or via other resources, embedded into $defs. Embedded
+// resources keep their $id, so references to them stay valid. References by
+// URIs, which differ from $id of loaded resources, are rewritten to $id.
+func Bundle(root *ast.Schema, load Loader) (*ast.Schema, error) {
+	b, err := clone(root)
+	if err != nil {
+		return nil, err
+	}
+
+	base, err := resolve("", b.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid $id %q: %w", b.ID, err)
+	}
+
+	// known are URIs of resources within the document.
+	known := map[string]bool{base: true}
+	if err := resources(b, "", known); err != nil {
+		return nil, err
+	}
+
+	pending, err := external(b, "", known)
+	if err != nil {
+		return nil, err
+	}
+
+	// aliases maps URIs of loaded resources to their $id.
+	aliases := map[string]string{}
+	// failed are errors of resources, which can be loaded later by $id.
+	failed := map[string]error{}
+
+	for len(pending) > 0 {
+		uri := pending[0]
+		pending = pending[1:]
+
+		if known[uri] || failed[uri] != nil {
+			continue
+		}
+
+		s, err := load(uri)
+		if err != nil {
+			failed[uri] = fmt.Errorf("failed to load %s: %w", uri, err)
+
+			continue
+		}
+
+		id, err := resolve(uri, s.ID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid $id %q: %w", uri, s.ID, err)
+		}
+
+		aliases[uri] = id
+
+		if known[id] {
+			known[uri] = true
+
+			continue
+		}
+
+		known[uri] = true
+
+		s.ID = id
+
+		if err := resources(s, "", known); err != nil {
+			return nil, fmt.Errorf("%s: %w", uri, err)
+		}
+
+		refs, err := external(s, "", known)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", uri, err)
+		}
+
+		pending = append(pending, refs...)
+
+		if b.Defs == nil {
+			b.Defs = map[string]ast.Schema{}
+		}
+
+		n := defName(b.Defs, id)
+		b.Defs[n] = *s
+		b.DefOrder = append(b.DefOrder, n)
+	}
+
+	uris := make([]string, 0, len(failed))
+	for uri := range failed {
+		if !known[uri] {
+			uris = append(uris, uri)
+		}
+	}
+
+	if len(uris) > 0 {
+		sort.Strings(uris)
+
+		return nil, failed[uris[0]]
+	}
+
+	err = visit(b, "", func(s *ast.Schema, base string) error {
+		if s.Ref == "" {
+			return nil
+		}
+
+		uri, fragment, err := split(base, s.Ref)
+		if err != nil {
+			return err
+		}
+
+		if id, ok := aliases[uri]; ok && id != uri {
+			s.Ref = join(id, fragment)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// Unbundle splits compound schema document s into the root resource and
+// resources embedded into its $defs, recursively. References into embedded
+// resources by JSON pointers, e.g. "#/$defs/address/properties/city", are
+// rewritten to their $id. The root resource comes first.
+func Unbundle(s *ast.Schema) ([]*ast.Schema, error) {
+	root, err := clone(s)
+	if err != nil {
+		return nil, err
+	}
+
+	ss := []*ast.Schema{root}
+	// moved maps absolute locations of embedded resources, e.g.
+	// "https://example.com/root.json#/$defs/a", to their $id.
+	moved := map[string]string{}
+
+	for i := 0; i < len(ss); i++ {
+		base, err := resolve("", ss[i].ID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid $id %q: %w", ss[i].ID, err)
+		}
+
+		names := make([]string, 0, len(ss[i].Defs))
+		for n := range ss[i].Defs {
+			names = append(names, n)
+		}
+
+		sort.Strings(names)
+
+		for _, n := range names {
+			d := ss[i].Defs[n]
+			if d.ID == "" {
+				continue
+			}
+
+			id, err := resolve(base, d.ID)
+			if err != nil {
+				return nil, fmt.Errorf("$defs/%s: invalid $id %q: %w", n, d.ID, err)
+			}
+
+			loc, fragment, err := split(base, "#/$defs/"+url.PathEscape(lib.EscapePointer(n)))
+			if err != nil {
+				return nil, err
+			}
+
+			d.ID = id
+			moved[join(loc, fragment)] = id
+			ss = append(ss, &d)
+
+			delete(ss[i].Defs, n)
+		}
+
+		var order []string
+		for _, n := range ss[i].DefOrder {
+			if _, ok := ss[i].Defs[n]; ok {
+				order = append(order, n)
+			}
+		}
+
+		ss[i].DefOrder = order
+
+		if len(ss[i].Defs) == 0 {
+			ss[i].Defs = nil
+			ss[i].DefOrder = nil
+		}
+	}
+
+	for _, r := range ss {
+		err := visit(r, "", func(s *ast.Schema, base string) error {
+			if s.Ref == "" {
+				return nil
+			}
+
+			uri, fragment, err := split(base, s.Ref)
+			if err != nil {
+				return err
+			}
+
+			if ref, ok := relocate(join(uri, fragment), moved); ok {
+				s.Ref = ref
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ss, nil
+}
+
+// relocate returns reference ref to a location within moved resources,
+// which are found by their locations in the document, e.g.
+// "root.json#/$defs/a/$defs/b" is moved into "b.json" nested into "a.json".
+func relocate(ref string, moved map[string]string) (string, bool) {
+	found := false
+
+	for {
+		next := ref
+
+		for loc, id := range moved {
+			if p := strings.TrimPrefix(ref, loc); p != ref && (p == "" || p[0] == '/') {
+				next = join(id, p)
+			}
+		}
+
+		if next == ref {
+			return ref, found
+		}
+
+		ref, found = next, true
+	}
+}
+
+// clone returns a deep copy of schema s.
+func clone(s *ast.Schema) (*ast.Schema, error) {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &ast.Schema{}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// resources adds URIs of schema s and its subschemas with $id to known.
+func resources(s *ast.Schema, base string, known map[string]bool) error {
+	return visit(s, base, func(s *ast.Schema, base string) error {
+		if s.ID != "" {
+			known[base] = true
+		}
+
+		return nil
+	})
+}
+
+// external returns URIs of resources referenced by schema s, which aren't
+// known, in alphabetical order.
+func external(s *ast.Schema, base string, known map[string]bool) ([]string, error) {
+	set := map[string]bool{}
+
+	err := visit(s, base, func(s *ast.Schema, base string) error {
+		if s.Ref == "" {
+			return nil
+		}
+
+		uri, _, err := split(base, s.Ref)
+		if err != nil {
+			return err
+		}
+
+		if known[uri] {
+			return nil
+		}
+
+		if u, _ := url.Parse(uri); !u.IsAbs() {
+			return fmt.Errorf("can't resolve $ref %q without absolute $id", s.Ref)
+		}
+
+		set[uri] = true
+
+		return nil
+	})
+
+	uris := make([]string, 0, len(set))
+	for uri := range set {
+		uris = append(uris, uri)
+	}
+
+	sort.Strings(uris)
+
+	return uris, err
+}
+
+// visit calls f for schema s and its subschemas, including ones within
+// unknown keywords, with their base URIs, which are resolved against base.
+// Changes made by f are kept.
+func visit(s *ast.Schema, base string, f func(s *ast.Schema, base string) error) error {
+	if s.ID != "" {
+		id, err := resolve(base, s.ID)
+		if err != nil {
+			return fmt.Errorf("invalid $id %q: %w", s.ID, err)
+		}
+
+		base = id
+	}
+
+	if err := f(s, base); err != nil {
+		return err
+	}
+
+	for n, d := range s.Defs {
+		if err := visit(&d, base, f); err != nil {
+			return err
+		}
+
+		s.Defs[n] = d
+	}
+
+	for i := range s.AllOf {
+		if err := visit(&s.AllOf[i], base, f); err != nil {
+			return err
+		}
+	}
+
+	if s.Items != nil {
+		if err := visit(s.Items, base, f); err != nil {
+			return err
+		}
+	}
+
+	for n, p := range s.Properties {
+		if err := visit(&p, base, f); err != nil {
+			return err
+		}
+
+		s.Properties[n] = p
+	}
+
+	// Keywords, which AST doesn't decode, e.g. oneOf, have subschemas too.
+	subs, err := s.Subschemas()
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range subs {
+		if err := visit(sub.Schema, base, f); err != nil {
+			return err
+		}
+	}
+
+	return s.SetSubschemas(subs)
+}
+
+// resolve resolves reference ref against base URI and drops a fragment.
+func resolve(base, ref string) (string, error) {
+	uri, _, err := split(base, ref)
+
+	return uri, err
+}
+
+// split resolves reference ref against base URI and returns it without a
+// fragment, and the fragment.
+func split(base, ref string) (string, string, error) {
+	b, err := url.Parse(base)
+	if err != nil {
+		return "", "", err
+	}
+
+	r, err := url.Parse(ref)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid reference %q: %w", ref, err)
+	}
+
+	u := b.ResolveReference(r)
+	fragment := u.EscapedFragment()
+	u.Fragment, u.RawFragment = "", ""
+
+	return u.String(), fragment, nil
+}
+
+// join returns reference to fragment of resource uri.
+func join(uri, fragment string) string {
+	if fragment == "" {
+		return uri
+	}
+
+	return uri + "#" + fragment
+}
+
+// defName returns a name of $defs entry for resource id, which is the last
+// segment of its path without extension, e.g. "address", unique within defs.
+func defName(defs map[string]ast.Schema, id string) string {
+	n := "resource"
+	if u, err := url.Parse(id); err == nil {
+		if b := strings.TrimSuffix(path.Base(u.Path), path.Ext(u.Path)); b != "" && b != "." && b != "/" {
+			n = b
+		}
+	}
+
+	name := n
+	for i := 2; ; i++ {
+		if _, ok := defs[name]; !ok {
+			return name
+		}
+
+		name = n + "_" + strconv.Itoa(i)
+	}
+}
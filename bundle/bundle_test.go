@@ -0,0 +1,219 @@
+package bundle_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/ekhabarov/jsg/ast"
+	"github.com/ekhabarov/jsg/bundle"
+	"github.com/ekhabarov/jsg/validate"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+func parse(schema string) *ast.Schema {
+	s, err := ast.Parse(strings.NewReader(schema))
+	Expect(err).NotTo(HaveOccurred())
+
+	return s
+}
+
+func read(file string) *ast.Schema {
+	b, err := os.ReadFile(file)
+	Expect(err).NotTo(HaveOccurred())
+
+	return parse(string(b))
+}
+
+// loader loads schemas from map m.
+func loader(m map[string]string) bundle.Loader {
+	return func(uri string) (*ast.Schema, error) {
+		s, ok := m[uri]
+		if !ok {
+			return nil, errors.New("not found")
+		}
+
+		return parse(s), nil
+	}
+}
+
+const bundled = `{
+	"$id": "https://example.com/schemas/user.json",
+	"type": "object",
+	"properties": {
+		"name": {"type": "string"},
+		"address": {"$ref": "address.json"},
+		"home": {"$ref": "address.json#/properties/country"}
+	},
+	"$defs": {
+		"address": {
+			"$id": "https://example.com/schemas/address.json",
+			"type": "object",
+			"properties": {
+				"city": {"type": "string"},
+				"country": {"$ref": "country.json"}
+			}
+		},
+		"country": {
+			"$id": "https://example.com/schemas/country.json",
+			"type": "object",
+			"properties": {
+				"code": {"type": "string", "maxLength": 2},
+				"neighbours": {"type": "array", "items": {"$ref": "#"}}
+			}
+		}
+	}
+}`
+
+var _ = Describe("Bundle", func() {
+
+	Context("Bundle", func() {
+
+		It("embeds referenced resources into $defs", func() {
+			b, err := bundle.Bundle(read("testdata/user.json"), bundle.DirLoader("testdata"))
+			Expect(err).NotTo(HaveOccurred())
+
+			out, err := json.Marshal(b)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out).To(MatchJSON(bundled))
+		})
+
+		It("makes self-contained document", func() {
+			b, err := bundle.Bundle(read("testdata/user.json"), bundle.DirLoader("testdata"))
+			Expect(err).NotTo(HaveOccurred())
+
+			v, err := validate.Compile(b)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = v.Validate([]byte(`{"address": {"country": {"neighbours": [{"code": "ABC"}]}}}`))
+			Expect(err).To(MatchError(ContainSubstring("/address/country/neighbours/0/code")))
+		})
+
+		It("doesn't change the root schema", func() {
+			root := read("testdata/user.json")
+
+			_, err := bundle.Bundle(root, bundle.DirLoader("testdata"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(root.Defs).To(BeNil())
+		})
+
+		It("rewrites references to $id of loaded resources", func() {
+			b, err := bundle.Bundle(parse(`{
+				"$id": "https://example.com/root.json",
+				"properties": {
+					"a": {"$ref": "https://mirror.example.com/a.json#/properties/x"},
+					"b": {"$ref": "a.json"}
+				}
+			}`), loader(map[string]string{
+				"https://mirror.example.com/a.json": `{"$id": "https://example.com/a.json", "properties": {"x": {}}}`,
+			}))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(b.Properties["a"].Ref).To(Equal("https://example.com/a.json#/properties/x"))
+			Expect(b.Properties["b"].Ref).To(Equal("a.json"))
+			Expect(b.Defs).To(HaveLen(1))
+		})
+
+		It("follows references within unknown keywords", func() {
+			b, err := bundle.Bundle(parse(`{
+				"$id": "https://example.com/root.json",
+				"oneOf": [{"$ref": "https://mirror.example.com/a.json"}, {"type": "null"}],
+				"additionalProperties": {"$ref": "b.json"}
+			}`), loader(map[string]string{
+				"https://mirror.example.com/a.json": `{"$id": "https://example.com/a.json", "not": {"$ref": "c.json"}}`,
+				"https://example.com/b.json":        `{}`,
+				"https://example.com/c.json":        `{}`,
+			}))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(b.Defs).To(HaveLen(3))
+			Expect(b.Unknown["oneOf"]).To(MatchJSON(`[{"$ref": "https://example.com/a.json"}, {"type": "null"}]`))
+		})
+
+		It("names $defs entries uniquely", func() {
+			b, err := bundle.Bundle(parse(`{
+				"$id": "https://example.com/root.json",
+				"$defs": {"a": {}},
+				"properties": {"a": {"$ref": "a.json"}, "b": {"$ref": "v2/a.json"}}
+			}`), loader(map[string]string{
+				"https://example.com/a.json":    `{}`,
+				"https://example.com/v2/a.json": `{}`,
+			}))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(b.Defs).To(HaveKeyWithValue("a_2", ast.Schema{ID: "https://example.com/a.json"}))
+			Expect(b.Defs).To(HaveKeyWithValue("a_3", ast.Schema{ID: "https://example.com/v2/a.json"}))
+		})
+
+		DescribeTable("Errors",
+			func(schema, msg string) {
+				_, err := bundle.Bundle(parse(schema), loader(map[string]string{}))
+				Expect(err).To(MatchError(msg))
+			},
+
+			Entry("unknown resource", `{"$id": "https://example.com/root.json", "$ref": "none.json"}`,
+				"failed to load https://example.com/none.json: not found"),
+			Entry("relative reference", `{"$ref": "none.json"}`,
+				`can't resolve $ref "none.json" without absolute $id`),
+		)
+	})
+
+	Context("Unbundle", func() {
+
+		It("splits document into resources", func() {
+			ss, err := bundle.Unbundle(parse(bundled))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ss).To(HaveLen(3))
+
+			for i, f := range []string{"user.json", "address.json", "country.json"} {
+				exp := read("testdata/" + f)
+				exp.ID = "https://example.com/schemas/" + f
+
+				Expect(ss[i]).To(Equal(exp))
+			}
+		})
+
+		It("rewrites JSON pointers into embedded resources", func() {
+			ss, err := bundle.Unbundle(parse(`{
+				"$id": "https://example.com/root.json",
+				"properties": {
+					"a": {"$ref": "#/$defs/a/properties/x"},
+					"b": {"$ref": "#/$defs/a/$defs/b"},
+					"c": {"$ref": "#/$defs/c"}
+				},
+				"$defs": {
+					"a": {
+						"$id": "a.json",
+						"properties": {"x": {"$ref": "#/$defs/b"}},
+						"$defs": {"b": {"$id": "b.json"}}
+					},
+					"c": {}
+				}
+			}`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ss).To(HaveLen(3))
+
+			Expect(ss[0].Properties["a"].Ref).To(Equal("https://example.com/a.json#/properties/x"))
+			Expect(ss[0].Properties["b"].Ref).To(Equal("https://example.com/b.json"))
+			Expect(ss[0].Properties["c"].Ref).To(Equal("#/$defs/c"))
+			Expect(ss[1].Properties["x"].Ref).To(Equal("https://example.com/b.json"))
+			Expect(ss[1].Defs).To(BeNil())
+			Expect(ss[2].ID).To(Equal("https://example.com/b.json"))
+		})
+
+		It("rewrites JSON pointers within unknown keywords", func() {
+			ss, err := bundle.Unbundle(parse(`{
+				"$id": "https://example.com/root.json",
+				"anyOf": [{"$ref": "#/$defs/a"}],
+				"$defs": {"a": {"$id": "a.json"}}
+			}`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ss).To(HaveLen(2))
+
+			Expect(ss[0].Unknown["anyOf"]).To(MatchJSON(`[{"$ref": "https://example.com/a.json"}]`))
+		})
+	})
+})
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/ekhabarov/jsg/ast"
+	"github.com/ekhabarov/jsg/bundle"
+)
+
+// bundleSchema writes a schema file with all resources it references, which
+// are read from the same directory, embedded into $defs.
+func bundleSchema(args []string) int {
+	flags := flag.NewFlagSet("bundle", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: jsg bundle [flags] schema.json")
+		flags.PrintDefaults()
+	}
+
+	out := flags.String("o", "", "output file, the bundle is written to stdout if empty")
+
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+
+		return 2
+	}
+
+	root, err := parseFile(flags.Arg(0))
+	if err != nil {
+		return fail("bundle", err)
+	}
+
+	b, err := bundle.Bundle(root, bundle.DirLoader(filepath.Dir(flags.Arg(0))))
+	if err != nil {
+		return fail("bundle", err)
+	}
+
+	if err := writeSchema(*out, b); err != nil {
+		return fail("bundle", err)
+	}
+
+	return 0
+}
+
+// unbundleSchema splits a bundled schema file into files named after $id of
+// embedded resources.
+func unbundleSchema(args []string) int {
+	flags := flag.NewFlagSet("unbundle", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: jsg unbundle [flags] bundle.json")
+		flags.PrintDefaults()
+	}
+
+	dir := flags.String("o", ".", "output directory")
+
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+
+		return 2
+	}
+
+	s, err := parseFile(flags.Arg(0))
+	if err != nil {
+		return fail("unbundle", err)
+	}
+
+	ss, err := bundle.Unbundle(s)
+	if err != nil {
+		return fail("unbundle", err)
+	}
+
+	for i, s := range ss {
+		name := path.Base(s.ID)
+		if s.ID == "" && i == 0 {
+			name = filepath.Base(flags.Arg(0))
+		}
+
+		if err := writeSchema(filepath.Join(*dir, name), s); err != nil {
+			return fail("unbundle", err)
+		}
+	}
+
+	return 0
+}
+
+func parseFile(name string) (*ast.Schema, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ast.Parse(f)
+}
+
+// writeSchema writes schema s into file name, or to stdout if name is empty.
+func writeSchema(name string, s *ast.Schema) error {
+	b, err := ast.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	if name == "" {
+		_, err = os.Stdout.Write(b)
+
+		return err
+	}
+
+	return os.WriteFile(name, b, 0o644)
+}
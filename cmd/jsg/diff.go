@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ekhabarov/jsg/diff"
+)
+
+// allowed are compatibilities of all changes together, which each -compat
+// mode accepts.
+var allowed = map[string][]diff.Compatibility{
+	"none":     {diff.Compatible, diff.Backward, diff.Forward},
+	"backward": {diff.Compatible, diff.Backward},
+	"forward":  {diff.Compatible, diff.Forward},
+	"full":     {diff.Compatible},
+}
+
+// diffSchemas prints changes between two schema files and fails if they
+// aren't compatible.
+func diffSchemas(args []string) int {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: jsg diff [flags] old.json new.json")
+		flags.PrintDefaults()
+	}
+
+	output := flags.String("format", "text", "output format: text, markdown or json")
+	compat := flags.String("compat", "none", "required compatibility: none, backward, forward or full; breaking changes always fail")
+
+	_ = flags.Parse(args)
+
+	write, ok := map[string]func(io.Writer, diff.Changes) error{
+		"text":     writeText,
+		"markdown": writeMarkdown,
+		"json":     writeJSON,
+	}[*output]
+
+	accepted, known := allowed[*compat]
+
+	if flags.NArg() != 2 || !ok || !known {
+		flags.Usage()
+
+		return 2
+	}
+
+	from, err := parseFile(flags.Arg(0))
+	if err != nil {
+		return fail("diff", err)
+	}
+
+	to, err := parseFile(flags.Arg(1))
+	if err != nil {
+		return fail("diff", err)
+	}
+
+	cs := diff.Compare(from, to)
+
+	if err := write(os.Stdout, cs); err != nil {
+		return fail("diff", err)
+	}
+
+	c := cs.Compatibility()
+	for _, a := range accepted {
+		if c == a {
+			return 0
+		}
+	}
+
+	return 1
+}
+
+func writeText(w io.Writer, cs diff.Changes) error {
+	for _, c := range cs {
+		if _, err := fmt.Fprintln(w, c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeMarkdown writes changes as a table, e.g. for pull request comments.
+func writeMarkdown(w io.Writer, cs diff.Changes) error {
+	if len(cs) == 0 {
+		_, err := fmt.Fprintln(w, "No schema changes.")
+
+		return err
+	}
+
+	b := &strings.Builder{}
+
+	fmt.Fprintf(b, "Schema changes are **%s**.\n\n", cs.Compatibility())
+	fmt.Fprintln(b, "| Path | Change | Compatibility |")
+	fmt.Fprintln(b, "| --- | --- | --- |")
+
+	for _, c := range cs {
+		fmt.Fprintf(b, "| `%s` | %s | %s |\n", c.Path, cell(c.Message), c.Compatibility)
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+// cell escapes s for a markdown table cell.
+func cell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
+
+func writeJSON(w io.Writer, cs diff.Changes) error {
+	if cs == nil {
+		cs = diff.Changes{}
+	}
+
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+
+	return e.Encode(struct {
+		Compatibility diff.Compatibility `json:"compatibility"`
+		Changes       diff.Changes       `json:"changes"`
+	}{cs.Compatibility(), cs})
+}
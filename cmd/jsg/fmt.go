@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/ekhabarov/jsg/ast"
+	"github.com/ekhabarov/jsg/internal/udiff"
+)
+
+// formatter rewrites schema files in canonical layout like gofmt.
+type formatter struct {
+	list, diff, write bool
+	// changed is set, if any file isn't formatted.
+	changed bool
+}
+
+// format formats schema files and directories, or stdin. Exit code is 1 if
+// files aren't formatted with -l or -d flags.
+func format(args []string) int {
+	f := &formatter{}
+
+	flags := flag.NewFlagSet("fmt", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: jsg fmt [flags] [path ...]")
+		flags.PrintDefaults()
+	}
+
+	flags.BoolVar(&f.list, "l", false, "list files, whose formatting differs from canonical one")
+	flags.BoolVar(&f.diff, "d", false, "display diffs instead of rewriting files")
+	flags.BoolVar(&f.write, "w", false, "write result to source files instead of stdout")
+
+	_ = flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		if f.write {
+			return fail("fmt", errors.New("can't use -w on stdin"))
+		}
+
+		if err := f.file("<stdin>", os.Stdin); err != nil {
+			return fail("fmt", err)
+		}
+
+		return f.code()
+	}
+
+	for _, p := range flags.Args() {
+		err := walkSchemas(p, func(path string) error {
+			r, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer r.Close()
+
+			return f.file(path, r)
+		})
+		if err != nil {
+			return fail("fmt", err)
+		}
+	}
+
+	return f.code()
+}
+
+// walkSchemas calls f for file p, or for every *.json file within directory
+// p.
+func walkSchemas(p string, f func(path string) error) error {
+	return filepath.WalkDir(p, func(path string, d fs.DirEntry, err error) error {
+		switch {
+		case err != nil:
+			return err
+		case d.IsDir() || path != p && filepath.Ext(path) != ".json":
+			return nil
+		}
+
+		return f(path)
+	})
+}
+
+// file formats schema read from r, which is file path.
+func (f *formatter) file(path string, r io.Reader) error {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	res, err := ast.Format(src)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	if bytes.Equal(src, res) {
+		if !f.list && !f.diff && !f.write {
+			os.Stdout.Write(res)
+		}
+
+		return nil
+	}
+
+	f.changed = true
+
+	if f.list {
+		fmt.Println(path)
+	}
+
+	if f.diff {
+		name := filepath.ToSlash(path)
+		os.Stdout.Write(udiff.Unified(name+".orig", name, src, res))
+	}
+
+	if f.write {
+		return os.WriteFile(path, res, 0o644)
+	}
+
+	if !f.list && !f.diff {
+		os.Stdout.Write(res)
+	}
+
+	return nil
+}
+
+func (f *formatter) code() int {
+	if f.changed && (f.list || f.diff) {
+		return 1
+	}
+
+	return 0
+}
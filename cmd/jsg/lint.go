@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ekhabarov/jsg/ast"
+	"github.com/ekhabarov/jsg/lint"
+)
+
+// fileProblem is a problem found in a file.
+type fileProblem struct {
+	File string `json:"file"`
+	lint.Problem
+}
+
+// lintSchemas checks schema files and directories, or stdin. Exit code is 1
+// if any problem is as severe as -fail flag.
+func lintSchemas(args []string) int {
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: jsg lint [flags] [path ...]")
+		flags.PrintDefaults()
+	}
+
+	output := flags.String("format", "text", "output format: text or json")
+	disable := flags.String("disable", "", "comma-separated IDs of disabled rules")
+	severity := flags.String("severity", "", "comma-separated severities of rules, e.g. missing-title=warning,unused-defs=error")
+	threshold := flags.String("fail", "error", "the lowest severity of problems, which fail the command")
+	list := flags.Bool("rules", false, "list rules and exit")
+
+	_ = flags.Parse(args)
+
+	if *list {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, r := range lint.Rules() {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", r.ID, r.Severity, r.Description)
+		}
+
+		w.Flush()
+
+		return 0
+	}
+
+	opts, err := lintOptions(*disable, *severity)
+	if err != nil {
+		return fail("lint", err)
+	}
+
+	failAt, err := lint.ParseSeverity(*threshold)
+	if err != nil {
+		return fail("lint", err)
+	}
+
+	if *output != "text" && *output != "json" {
+		flags.Usage()
+
+		return 2
+	}
+
+	problems := []fileProblem{}
+
+	check := func(path string, r io.Reader) error {
+		s, err := ast.Parse(r)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		ps, err := lint.Lint(s, opts...)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		for _, p := range ps {
+			problems = append(problems, fileProblem{File: path, Problem: p})
+		}
+
+		return nil
+	}
+
+	if flags.NArg() == 0 {
+		if err := check("<stdin>", os.Stdin); err != nil {
+			return fail("lint", err)
+		}
+	}
+
+	for _, p := range flags.Args() {
+		err := walkSchemas(p, func(path string) error {
+			r, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer r.Close()
+
+			return check(path, r)
+		})
+		if err != nil {
+			return fail("lint", err)
+		}
+	}
+
+	if *output == "json" {
+		e := json.NewEncoder(os.Stdout)
+		e.SetIndent("", "  ")
+
+		if err := e.Encode(problems); err != nil {
+			return fail("lint", err)
+		}
+	} else {
+		for _, p := range problems {
+			fmt.Printf("%s:%s\n", p.File, p.Problem)
+		}
+	}
+
+	for _, p := range problems {
+		if failAt != lint.Off && p.Severity >= failAt {
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// lintOptions returns lint options for -disable and -severity flags.
+func lintOptions(disable, severity string) ([]lint.Option, error) {
+	opts := []lint.Option{}
+
+	if disable != "" {
+		opts = append(opts, lint.Disable(strings.Split(disable, ",")...))
+	}
+
+	if severity == "" {
+		return opts, nil
+	}
+
+	for _, rs := range strings.Split(severity, ",") {
+		i := strings.Index(rs, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid rule severity %q, rule=severity expected", rs)
+		}
+
+		s, err := lint.ParseSeverity(rs[i+1:])
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, lint.SetSeverity(rs[:i], s))
+	}
+
+	return opts, nil
+}
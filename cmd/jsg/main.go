@@ -0,0 +1,75 @@
+// Command jsg works with JSON schemas.
+//
+// Usage:
+//
+//	jsg <command> [flags] [arguments]
+//
+// Commands:
+//
+//	bundle   embed referenced schemas into one file
+//	diff     compare two versions of a schema
+//	fmt      format schema files
+//	lint     check schemas for likely mistakes
+//	schema   generate JSON schemas from Go types
+//	unbundle split a bundled schema into files
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// command is a jsg subcommand, which gets arguments after its name and
+// returns an exit code.
+type command struct {
+	usage string
+	run   func(args []string) int
+}
+
+var commands = map[string]command{
+	"bundle":   {"embed referenced schemas into one file", bundleSchema},
+	"diff":     {"compare two versions of a schema", diffSchemas},
+	"fmt":      {"format schema files", format},
+	"lint":     {"check schemas for likely mistakes", lintSchemas},
+	"schema":   {"generate JSON schemas from Go types", schema},
+	"unbundle": {"split a bundled schema into files", unbundleSchema},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	c, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "jsg: unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	os.Exit(c.run(os.Args[2:]))
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: jsg <command> [flags] [arguments]\n\nCommands:")
+
+	names := make([]string, 0, len(commands))
+	for n := range commands {
+		names = append(names, n)
+	}
+
+	sort.Strings(names)
+
+	for _, n := range names {
+		fmt.Fprintf(os.Stderr, "  %-9s %s\n", n, commands[n].usage)
+	}
+}
+
+// fail prints error of command cmd and returns exit code 1.
+func fail(cmd string, err error) int {
+	fmt.Fprintf(os.Stderr, "jsg %s: %v\n", cmd, err)
+
+	return 1
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path"
+	"path/filepath"
+
+	"github.com/ekhabarov/jsg/reverse"
+)
+
+// schema generates JSON schemas of exported structs of a Go package and
+// writes them into files named after $id, or to stdout.
+func schema(args []string) int {
+	fs := flag.NewFlagSet("schema", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: jsg schema [flags] package")
+		fs.PrintDefaults()
+	}
+
+	base := fs.String("base", "", "base URI of $id of schemas, e.g. https://example.com/schemas/")
+	out := fs.String("o", "", "output directory, schemas are written to stdout if empty")
+	flatten := fs.Bool("flatten", false, "copy properties of embedded structs instead of allOf")
+
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+
+		return 2
+	}
+
+	opts := []reverse.Option{reverse.BaseURI(*base)}
+	if *flatten {
+		opts = append(opts, reverse.Flatten())
+	}
+
+	ss, err := reverse.Schemas(fs.Arg(0), opts...)
+	if err != nil {
+		return fail("schema", err)
+	}
+
+	for _, s := range ss {
+		name := ""
+		if *out != "" {
+			name = filepath.Join(*out, path.Base(s.ID))
+		}
+
+		if err := writeSchema(name, s); err != nil {
+			return fail("schema", err)
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,620 @@
+// Package diff compares two versions of a schema and classifies changes by
+// their effect on valid instances.
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ekhabarov/jsg/ast"
+	"github.com/ekhabarov/jsg/internal/jsonvalue"
+	"github.com/ekhabarov/jsg/lib"
+)
+
+// Compatibility of a change between old and new schema.
+type Compatibility uint8
+
+const (
+	// Compatible changes don't change valid instances, e.g. descriptions.
+	Compatible Compatibility = iota
+	// Backward changes widen the schema: instances valid against the old
+	// schema stay valid against the new one, e.g. a removed bound.
+	Backward
+	// Forward changes narrow the schema: instances valid against the new
+	// schema are valid against the old one, e.g. a new required property.
+	Forward
+	// Breaking changes are neither backward nor forward compatible.
+	Breaking
+)
+
+var names = map[Compatibility]string{
+	Compatible: "compatible",
+	Backward:   "backward-compatible",
+	Forward:    "forward-compatible",
+	Breaking:   "breaking",
+}
+
+func (c Compatibility) String() string {
+	return names[c]
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (c Compatibility) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// combine returns compatibility of both changes c and o.
+func (c Compatibility) combine(o Compatibility) Compatibility {
+	switch {
+	case c == o || o == Compatible:
+		return c
+	case c == Compatible:
+		return o
+	}
+
+	return Breaking
+}
+
+// Change is a difference between old and new schema.
+type Change struct {
+	// Path is a JSON pointer to the changed keyword.
+	Path          string        `json:"path"`
+	Message       string        `json:"message"`
+	Compatibility Compatibility `json:"compatibility"`
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s (%s)", c.Path, c.Message, c.Compatibility)
+}
+
+// Changes are differences between schemas in order of traversal: keywords
+// of a schema in canonical order, each followed by changes of its subschemas.
+type Changes []Change
+
+// Compatibility returns compatibility of all changes together, e.g. a
+// backward and a forward compatible changes are breaking.
+func (cs Changes) Compatibility() Compatibility {
+	r := Compatible
+	for _, c := range cs {
+		r = r.combine(c.Compatibility)
+	}
+
+	return r
+}
+
+// Compare returns changes made in schema to compared to schema from.
+func Compare(from, to *ast.Schema) Changes {
+	d := &differ{}
+	d.schema("", from, to)
+
+	return d.changes
+}
+
+type differ struct {
+	changes Changes
+}
+
+func (d *differ) add(path string, c Compatibility, msg string, args ...interface{}) {
+	d.changes = append(d.changes, Change{Path: path, Message: fmt.Sprintf(msg, args...), Compatibility: c})
+}
+
+// schema compares subschemas located at JSON pointer ptr.
+func (d *differ) schema(ptr string, from, to *ast.Schema) {
+	d.annotation(ptr+"/title", from.Title, to.Title)
+	d.annotation(ptr+"/description", from.Description, to.Description)
+
+	// Types of generated Go code are kept, if both schemas have the same
+	// x-go-type.
+	pinned := from.GoType != "" && from.GoType == to.GoType
+
+	switch {
+	case from.Ref == to.Ref:
+	case !pinned:
+		d.retyped(ptr+"/$ref", from.Ref, to.Ref)
+	default:
+		d.optional(ptr+"/$ref", from.Ref, to.Ref, func() {
+			d.add(ptr+"/$ref", Breaking, "changed from %q to %q", from.Ref, to.Ref)
+		})
+	}
+
+	d.types(ptr+"/type", from.Type, to.Type, pinned)
+	d.enum(ptr+"/enum", from.Enum, to.Enum)
+
+	switch {
+	case from.Format == to.Format:
+	case !pinned && formatType(from.Format) != formatType(to.Format):
+		d.retyped(ptr+"/format", from.Format.Name(), to.Format.Name())
+	default:
+		d.optional(ptr+"/format", from.Format.Name(), to.Format.Name(), func() {
+			d.add(ptr+"/format", Breaking, "changed from %q to %q", from.Format.Name(), to.Format.Name())
+		})
+	}
+
+	d.multipleOf(ptr+"/multipleOf", from.MultipleOf, to.MultipleOf)
+	d.lower(ptr, from, to)
+	d.upper(ptr, from, to)
+
+	d.length(ptr+"/minLength", from.MinLength, to.MinLength, Forward)
+	d.length(ptr+"/maxLength", from.MaxLength, to.MaxLength, Backward)
+
+	if from.Pattern != to.Pattern {
+		d.optional(ptr+"/pattern", from.Pattern, to.Pattern, func() {
+			d.add(ptr+"/pattern", Breaking, "changed from %q to %q", from.Pattern, to.Pattern)
+		})
+	}
+
+	d.length(ptr+"/minItems", from.MinItems, to.MinItems, Forward)
+	d.length(ptr+"/maxItems", from.MaxItems, to.MaxItems, Backward)
+
+	switch {
+	case !from.UniqueItems && to.UniqueItems:
+		d.add(ptr+"/uniqueItems", Forward, "added")
+	case from.UniqueItems && !to.UniqueItems:
+		d.add(ptr+"/uniqueItems", Backward, "removed")
+	}
+
+	d.length(ptr+"/minProperties", from.MinProperties, to.MinProperties, Forward)
+	d.length(ptr+"/maxProperties", from.MaxProperties, to.MaxProperties, Backward)
+	d.required(ptr+"/required", from.Required, to.Required)
+
+	d.subschema(ptr+"/items", from.Items, to.Items, Breaking)
+
+	for i := 0; i < len(from.AllOf) || i < len(to.AllOf); i++ {
+		var o, n *ast.Schema
+		if i < len(from.AllOf) {
+			o = &from.AllOf[i]
+		}
+
+		if i < len(to.AllOf) {
+			n = &to.AllOf[i]
+		}
+
+		d.subschema(fmt.Sprintf("%s/allOf/%d", ptr, i), o, n, Forward)
+	}
+
+	d.properties(ptr+"/properties", from.Properties, to.Properties, Forward, ", generated Go field is removed")
+	d.properties(ptr+"/$defs", from.Defs, to.Defs, Compatible, ", generated Go type is removed")
+
+	d.raw(ptr, from.Unknown, to.Unknown, func(k string) (Compatibility, string) {
+		if annotations[k] {
+			return Compatible, ""
+		}
+
+		return Breaking, ""
+	})
+
+	d.raw(ptr, from.Extensions, to.Extensions, func(k string) (Compatibility, string) {
+		switch {
+		case goAPI[k]:
+			return Breaking, retyped
+		case strings.HasPrefix(k, "x-go-"):
+			return Compatible, ", generated Go code changes"
+		}
+
+		return Compatible, ""
+	})
+}
+
+// annotations are unknown keywords, which don't change valid instances.
+var annotations = map[string]bool{
+	"$comment":   true,
+	"default":    true,
+	"deprecated": true,
+	"examples":   true,
+	"readOnly":   true,
+	"writeOnly":  true,
+}
+
+// retyped is a note of changes, which change types of generated Go code.
+const retyped = ", generated Go types change"
+
+// goAPI are vendor extensions, which change names or types of generated Go
+// code, so their changes break Go callers even if valid instances stay the
+// same.
+var goAPI = map[string]bool{
+	"x-go-type":     true,
+	"x-go-package":  true,
+	"x-go-name":     true,
+	"x-go-optional": true,
+	"x-go-pointer":  true,
+	"x-go-embed":    true,
+	"x-go-skip":     true,
+}
+
+// raw compares keywords kept as raw JSON, e.g. unknown ones or vendor
+// extensions, where kind returns compatibility of keyword changes and a note
+// added to their messages. Effect of unknown keywords, e.g. oneOf, isn't
+// known, so their changes are breaking.
+func (d *differ) raw(ptr string, from, to map[string]json.RawMessage, kind func(k string) (Compatibility, string)) {
+	keywords := map[string]bool{}
+	for k := range from {
+		keywords[k] = true
+	}
+
+	for k := range to {
+		keywords[k] = true
+	}
+
+	sorted := make([]string, 0, len(keywords))
+	for k := range keywords {
+		sorted = append(sorted, k)
+	}
+
+	sort.Strings(sorted)
+
+	for _, k := range sorted {
+		o, inOld := from[k]
+		n, inNew := to[k]
+		path := ptr + "/" + lib.EscapePointer(k)
+		c, note := kind(k)
+
+		switch {
+		case !inOld:
+			d.add(path, c, "added%s", note)
+		case !inNew:
+			d.add(path, c, "removed%s", note)
+		case jsonvalue.Canonical(o) != jsonvalue.Canonical(n):
+			d.add(path, c, "changed%s", note)
+		}
+	}
+}
+
+// optional reports a keyword, which is either added or removed, or calls
+// changed. Added keywords narrow the schema.
+func (d *differ) optional(path string, from, to string, changed func()) {
+	switch {
+	case from == "":
+		d.add(path, Forward, "added %q", to)
+	case to == "":
+		d.add(path, Backward, "removed %q", from)
+	default:
+		changed()
+	}
+}
+
+// retyped reports a keyword, which is added, removed or changed, and changes
+// types of generated Go code, e.g. $ref.
+func (d *differ) retyped(path string, from, to string) {
+	switch {
+	case from == "":
+		d.add(path, Breaking, "added %q%s", to, retyped)
+	case to == "":
+		d.add(path, Breaking, "removed %q%s", from, retyped)
+	default:
+		d.add(path, Breaking, "changed from %q to %q%s", from, to, retyped)
+	}
+}
+
+// formatType returns Go type of strings with format f.
+func formatType(f ast.StringFormat) string {
+	t, _, _ := ast.GoType(ast.String, f, "")
+
+	return t
+}
+
+func (d *differ) annotation(path, from, to string) {
+	if from != to {
+		d.add(path, Compatible, "changed")
+	}
+}
+
+// subschema compares subschemas, which may be absent. An added subschema
+// has compatibility added, e.g. it narrows the schema, or it's breaking, if
+// it changes types of generated Go code like items do. A removed subschema
+// changes generated Go code, so it's breaking.
+func (d *differ) subschema(ptr string, from, to *ast.Schema, added Compatibility) {
+	switch {
+	case from == nil && to == nil:
+	case from == nil && added == Breaking:
+		d.add(ptr, added, "added%s", retyped)
+	case from == nil:
+		d.add(ptr, added, "added")
+	case to == nil:
+		d.add(ptr, Breaking, "removed%s", retyped)
+	default:
+		d.schema(ptr, from, to)
+	}
+}
+
+// properties compares maps of subschemas. Added entries have compatibility
+// added. Removed ones remove fields or types of generated Go code, so they're
+// breaking with note removed.
+func (d *differ) properties(ptr string, from, to map[string]ast.Schema, added Compatibility, removed string) {
+	names := map[string]bool{}
+	for n := range from {
+		names[n] = true
+	}
+
+	for n := range to {
+		names[n] = true
+	}
+
+	sorted := make([]string, 0, len(names))
+	for n := range names {
+		sorted = append(sorted, n)
+	}
+
+	sort.Strings(sorted)
+
+	for _, n := range sorted {
+		o, inOld := from[n]
+		s, inNew := to[n]
+		path := ptr + "/" + lib.EscapePointer(n)
+
+		switch {
+		case !inOld:
+			d.add(path, added, "added")
+		case !inNew:
+			d.add(path, Breaking, "removed%s", removed)
+		default:
+			d.schema(path, &o, &s)
+		}
+	}
+}
+
+func opposite(c Compatibility) Compatibility {
+	switch c {
+	case Backward:
+		return Forward
+	case Forward:
+		return Backward
+	}
+
+	return c
+}
+
+// allTypes are all types, which is the case if "type" is absent.
+const allTypes = ast.String | ast.Number | ast.Integer | ast.Object | ast.Array | ast.Boolean | ast.Null
+
+// typeSet returns types accepted by "type" keyword value t.
+func typeSet(t ast.SchemaType) ast.SchemaType {
+	if t == 0 {
+		return allTypes
+	}
+
+	// Numbers include integers.
+	if t&ast.Number != 0 {
+		t |= ast.Integer
+	}
+
+	return t
+}
+
+func typeName(t ast.SchemaType) string {
+	if t == 0 {
+		return "any"
+	}
+
+	b, _ := json.Marshal(t)
+
+	return strings.Trim(string(b), `"`)
+}
+
+// types compares "type" keywords. Types change Go types of generated code,
+// e.g. int for integer and float64 for number, so their changes are
+// breaking, unless Go types are pinned with x-go-type.
+func (d *differ) types(path string, from, to ast.SchemaType, pinned bool) {
+	o, n := typeSet(from), typeSet(to)
+	if o == n {
+		return
+	}
+
+	if !pinned {
+		d.add(path, Breaking, "changed from %s to %s%s", typeName(from), typeName(to), retyped)
+
+		return
+	}
+
+	c := Breaking
+
+	switch {
+	case o&n == o:
+		c = Backward
+	case o&n == n:
+		c = Forward
+	}
+
+	d.add(path, c, "changed from %s to %s", typeName(from), typeName(to))
+}
+
+func (d *differ) enum(path string, from, to []json.RawMessage) {
+	if from == nil && to == nil {
+		return
+	}
+
+	if from == nil {
+		d.add(path, Forward, "added")
+
+		return
+	}
+
+	if to == nil {
+		d.add(path, Backward, "removed")
+
+		return
+	}
+
+	o, n := values(from), values(to)
+	added, removed := []string{}, []string{}
+
+	for v := range n {
+		if !o[v] {
+			added = append(added, v)
+		}
+	}
+
+	for v := range o {
+		if !n[v] {
+			removed = append(removed, v)
+		}
+	}
+
+	sort.Strings(added)
+	sort.Strings(removed)
+
+	switch {
+	case len(added) > 0 && len(removed) > 0:
+		d.add(path, Breaking, "added %s, removed %s", strings.Join(added, ", "), strings.Join(removed, ", "))
+	case len(added) > 0:
+		d.add(path, Backward, "added %s", strings.Join(added, ", "))
+	case len(removed) > 0:
+		d.add(path, Forward, "removed %s", strings.Join(removed, ", "))
+	}
+}
+
+// values returns canonical JSON representations of enum values, where
+// objects have sorted keys and equal numbers are written the same way, e.g.
+// 1.0 as 1.
+func values(vv []json.RawMessage) map[string]bool {
+	m := map[string]bool{}
+
+	for _, raw := range vv {
+		m[jsonvalue.Canonical(raw)] = true
+	}
+
+	return m
+}
+
+func (d *differ) required(path string, from, to []string) {
+	o, n := map[string]bool{}, map[string]bool{}
+	for _, r := range from {
+		o[r] = true
+	}
+
+	for _, r := range to {
+		n[r] = true
+	}
+
+	// Existing Go callers don't set new required properties, so their values
+	// become invalid.
+	for _, r := range to {
+		if !o[r] {
+			d.add(path, Breaking, "added %q, existing Go callers don't set it", r)
+		}
+	}
+
+	for _, r := range from {
+		if !n[r] {
+			d.add(path, Backward, "removed %q", r)
+		}
+	}
+}
+
+// length compares non-negative integer keywords, e.g. minLength, where
+// raising the value has compatibility raised.
+func (d *differ) length(path string, from, to *uint32, raised Compatibility) {
+	switch {
+	case from == nil && to == nil:
+	case from == nil:
+		d.add(path, Forward, "added %d", *to)
+	case to == nil:
+		d.add(path, Backward, "removed %d", *from)
+	case *to > *from:
+		d.add(path, raised, "raised from %d to %d", *from, *to)
+	case *to < *from:
+		d.add(path, opposite(raised), "lowered from %d to %d", *from, *to)
+	}
+}
+
+func (d *differ) multipleOf(path string, from, to *ast.Decimal) {
+	switch {
+	case from == nil && to == nil:
+	case from == nil:
+		d.add(path, Forward, "added %s", to)
+	case to == nil:
+		d.add(path, Backward, "removed %s", from)
+	case from.Rat().Cmp(to.Rat()) == 0:
+	case multiple(to.Rat(), from.Rat()):
+		d.add(path, Forward, "changed from %s to %s", from, to)
+	case multiple(from.Rat(), to.Rat()):
+		d.add(path, Backward, "changed from %s to %s", from, to)
+	default:
+		d.add(path, Breaking, "changed from %s to %s", from, to)
+	}
+}
+
+// multiple reports whether x is a multiple of y.
+func multiple(x, y *big.Rat) bool {
+	return new(big.Rat).Quo(x, y).IsInt()
+}
+
+// bound is an effective numeric bound set by inclusive or exclusive keyword.
+type bound struct {
+	keyword   string
+	value     *ast.Decimal
+	exclusive bool
+}
+
+func (b *bound) String() string {
+	if b.exclusive {
+		return "exclusive " + b.value.String()
+	}
+
+	return b.value.String()
+}
+
+// effective returns the tighter bound of inclusive and exclusive ones, where
+// sign is 1 for lower bounds and -1 for upper ones.
+func effective(sign int, incl, excl *ast.Decimal, inclKw, exclKw string) *bound {
+	switch {
+	case incl == nil && excl == nil:
+		return nil
+	case incl == nil:
+		return &bound{exclKw, excl, true}
+	case excl == nil:
+		return &bound{inclKw, incl, false}
+	}
+
+	if incl.Rat().Cmp(excl.Rat())*sign > 0 {
+		return &bound{inclKw, incl, false}
+	}
+
+	return &bound{exclKw, excl, true}
+}
+
+// tighter returns 1 if bound b is tighter than o, -1 if it's looser, or 0.
+func (b *bound) tighter(sign int, o *bound) int {
+	if c := b.value.Rat().Cmp(o.value.Rat()) * sign; c != 0 {
+		return c
+	}
+
+	switch {
+	case b.exclusive == o.exclusive:
+		return 0
+	case b.exclusive:
+		return 1
+	}
+
+	return -1
+}
+
+func (d *differ) lower(ptr string, from, to *ast.Schema) {
+	d.bounds(ptr, 1,
+		effective(1, from.Minimum, from.ExclusiveMinimum, "minimum", "exclusiveMinimum"),
+		effective(1, to.Minimum, to.ExclusiveMinimum, "minimum", "exclusiveMinimum"),
+	)
+}
+
+func (d *differ) upper(ptr string, from, to *ast.Schema) {
+	d.bounds(ptr, -1,
+		effective(-1, from.Maximum, from.ExclusiveMaximum, "maximum", "exclusiveMaximum"),
+		effective(-1, to.Maximum, to.ExclusiveMaximum, "maximum", "exclusiveMaximum"),
+	)
+}
+
+func (d *differ) bounds(ptr string, sign int, from, to *bound) {
+	switch {
+	case from == nil && to == nil:
+	case from == nil:
+		d.add(ptr+"/"+to.keyword, Forward, "added %s", to)
+	case to == nil:
+		d.add(ptr+"/"+from.keyword, Backward, "removed %s", from)
+	case to.tighter(sign, from) > 0:
+		d.add(ptr+"/"+to.keyword, Forward, "tightened from %s to %s", from, to)
+	case to.tighter(sign, from) < 0:
+		d.add(ptr+"/"+to.keyword, Backward, "loosened from %s to %s", from, to)
+	}
+}
@@ -0,0 +1,166 @@
+package diff_test
+
+import (
+	"strings"
+
+	"github.com/ekhabarov/jsg/ast"
+	"github.com/ekhabarov/jsg/diff"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+func parse(schema string) *ast.Schema {
+	s, err := ast.Parse(strings.NewReader(schema))
+	Expect(err).NotTo(HaveOccurred())
+
+	return s
+}
+
+// changes returns changes as strings.
+func changes(cs diff.Changes) []string {
+	ss := []string{}
+	for _, c := range cs {
+		ss = append(ss, c.String())
+	}
+
+	return ss
+}
+
+var _ = Describe("Diff", func() {
+
+	Context("Compare", func() {
+
+		DescribeTable("Changes",
+			func(from, to string, exp ...string) {
+				Expect(changes(diff.Compare(parse(from), parse(to)))).To(Equal(exp))
+			},
+
+			Entry("no changes", `{"type": "string", "properties": {"a": {}}}`, `{"properties": {"a": {}}, "type": "string"}`),
+			Entry("description", `{"description": "a"}`, `{"description": "b"}`, "/description: changed (compatible)"),
+
+			// type
+
+			Entry("type: integer to number", `{"type": "integer"}`, `{"type": "number"}`,
+				"/type: changed from integer to number, generated Go types change (breaking)"),
+			Entry("type: widened", `{"type": "integer", "x-go-type": "T"}`, `{"type": "number", "x-go-type": "T"}`,
+				"/type: changed from integer to number (backward-compatible)"),
+			Entry("type: narrowed", `{"type": ["string", "null"], "x-go-type": "T"}`, `{"type": "string", "x-go-type": "T"}`,
+				`/type: changed from ["string","null"] to string (forward-compatible)`),
+			Entry("type: changed", `{"type": "string", "x-go-type": "T"}`, `{"type": "integer", "x-go-type": "T"}`,
+				"/type: changed from string to integer (breaking)"),
+			Entry("type: removed", `{"type": "string", "x-go-type": "T"}`, `{"x-go-type": "T"}`,
+				"/type: changed from string to any (backward-compatible)"),
+			Entry("type: another x-go-type", `{"type": "string", "x-go-type": "T"}`, `{"type": ["string", "null"], "x-go-type": "U"}`,
+				`/type: changed from string to ["string","null"], generated Go types change (breaking)`,
+				"/x-go-type: changed, generated Go types change (breaking)"),
+
+			// enum
+
+			Entry("enum: added values", `{"enum": ["a"]}`, `{"enum": ["a", "b", 1]}`,
+				`/enum: added "b", 1 (backward-compatible)`),
+			Entry("enum: removed values", `{"enum": ["a", "b"]}`, `{"enum": ["a"]}`,
+				`/enum: removed "b" (forward-compatible)`),
+			Entry("enum: replaced values", `{"enum": ["a"]}`, `{"enum": ["b"]}`,
+				`/enum: added "b", removed "a" (breaking)`),
+			Entry("unknown keyword: changed", `{"oneOf": [{"type": "string"}]}`, `{"oneOf": [{"type": "integer"}]}`,
+				"/oneOf: changed (breaking)"),
+			Entry("unknown keyword: added", `{"properties": {"a": {}}}`, `{"properties": {"a": {"const": 1}}}`,
+				"/properties/a/const: added (breaking)"),
+			Entry("unknown keyword: the same value", `{"not": {"const": 1.0, "type": "integer"}}`, `{"not": {"type": "integer", "const": 1}}`),
+			Entry("unknown keyword: annotation", `{"default": 1, "examples": [1]}`, `{"default": 2}`,
+				"/default: changed (compatible)", "/examples: removed (compatible)"),
+			Entry("extension: x-go-*", `{"x-go-name": "A"}`, `{"x-go-name": "B", "x-order": 1}`,
+				"/x-go-name: changed, generated Go types change (breaking)", "/x-order: added (compatible)"),
+			Entry("extension: x-go-type", `{"properties": {"a": {}}}`, `{"properties": {"a": {"x-go-type": "int64"}}}`,
+				"/properties/a/x-go-type: added, generated Go types change (breaking)"),
+			Entry("extension: x-go-tags", `{"x-go-tags": {"db": "a"}, "x-go-omitempty": true}`, `{"x-go-tags": {"db": "b"}}`,
+				"/x-go-omitempty: removed, generated Go code changes (compatible)",
+				"/x-go-tags: changed, generated Go code changes (compatible)"),
+
+			Entry("enum: equal values", `{"enum": [1, 0.50, {"a": 1, "b": 2}]}`, `{"enum": [1.0, 0.5, {"b": 2, "a": 1}]}`),
+			Entry("enum: large integers", `{"enum": [9007199254740993]}`, `{"enum": [9007199254740992]}`,
+				`/enum: added 9007199254740992, removed 9007199254740993 (breaking)`),
+			Entry("enum: added", `{}`, `{"enum": ["a"]}`, `/enum: added (forward-compatible)`),
+
+			// bounds
+
+			Entry("minimum: tightened", `{"minimum": 1}`, `{"minimum": 1.5}`,
+				"/minimum: tightened from 1 to 1.5 (forward-compatible)"),
+			Entry("minimum: exclusive", `{"minimum": 1}`, `{"exclusiveMinimum": 1}`,
+				"/exclusiveMinimum: tightened from 1 to exclusive 1 (forward-compatible)"),
+			Entry("minimum: the same bound", `{"minimum": 1, "exclusiveMinimum": 0}`, `{"minimum": 1}`),
+			Entry("maximum: loosened", `{"exclusiveMaximum": 10}`, `{"maximum": 10}`,
+				"/maximum: loosened from exclusive 10 to 10 (backward-compatible)"),
+			Entry("maximum: removed", `{"maximum": 10}`, `{}`, "/maximum: removed 10 (backward-compatible)"),
+			Entry("multipleOf: multiple", `{"multipleOf": 0.5}`, `{"multipleOf": 1.5}`,
+				"/multipleOf: changed from 0.5 to 1.5 (forward-compatible)"),
+			Entry("multipleOf: divisor", `{"multipleOf": 1.5}`, `{"multipleOf": 0.5}`,
+				"/multipleOf: changed from 1.5 to 0.5 (backward-compatible)"),
+			Entry("multipleOf: changed", `{"multipleOf": 2}`, `{"multipleOf": 3}`,
+				"/multipleOf: changed from 2 to 3 (breaking)"),
+			Entry("minLength: raised", `{"minLength": 1}`, `{"minLength": 2}`,
+				"/minLength: raised from 1 to 2 (forward-compatible)"),
+			Entry("maxItems: raised", `{"maxItems": 1}`, `{"maxItems": 2}`,
+				"/maxItems: raised from 1 to 2 (backward-compatible)"),
+			Entry("maxItems: added", `{}`, `{"maxItems": 2}`, "/maxItems: added 2 (forward-compatible)"),
+
+			// strings
+
+			Entry("pattern: changed", `{"pattern": "^a"}`, `{"pattern": "^b"}`,
+				`/pattern: changed from "^a" to "^b" (breaking)`),
+			Entry("format: added", `{}`, `{"format": "email"}`, `/format: added "email" (forward-compatible)`),
+			Entry("format: retyped", `{"format": "email"}`, `{"format": "date"}`,
+				`/format: changed from "email" to "date", generated Go types change (breaking)`),
+			Entry("$ref: added", `{}`, `{"$ref": "a.json"}`, `/$ref: added "a.json", generated Go types change (breaking)`),
+
+			// objects
+
+			Entry("properties", `{
+				"properties": {"a": {"type": "string"}, "b": {}},
+				"required": ["b"]
+			}`, `{
+				"properties": {"a": {"type": ["string", "null"]}, "c": {}},
+				"required": ["c"]
+			}`,
+				`/required: added "c", existing Go callers don't set it (breaking)`,
+				`/required: removed "b" (backward-compatible)`,
+				`/properties/a/type: changed from string to ["string","null"], generated Go types change (breaking)`,
+				`/properties/b: removed, generated Go field is removed (breaking)`,
+				`/properties/c: added (forward-compatible)`,
+			),
+			Entry("removed property", `{"properties": {"a": {}, "b": {}}}`, `{"properties": {"a": {}}}`,
+				"/properties/b: removed, generated Go field is removed (breaking)"),
+			Entry("removed $defs entry", `{"$defs": {"a": {}, "b": {}}}`, `{"$defs": {"b": {}}}`,
+				"/$defs/a: removed, generated Go type is removed (breaking)"),
+			Entry("items: added", `{"type": "array"}`, `{"type": "array", "items": {}}`,
+				"/items: added, generated Go types change (breaking)"),
+
+			Entry("nested", `{"items": {"allOf": [{"$ref": "a.json"}]}, "$defs": {"a": {}}}`, `{"items": {"allOf": [{"$ref": "b.json"}, {}]}}`,
+				`/items/allOf/0/$ref: changed from "a.json" to "b.json", generated Go types change (breaking)`,
+				`/items/allOf/1: added (forward-compatible)`,
+				`/$defs/a: removed, generated Go type is removed (breaking)`,
+			),
+		)
+	})
+
+	Context("Changes", func() {
+
+		DescribeTable("Compatibility",
+			func(exp diff.Compatibility, cc ...diff.Compatibility) {
+				cs := diff.Changes{}
+				for _, c := range cc {
+					cs = append(cs, diff.Change{Compatibility: c})
+				}
+
+				Expect(cs.Compatibility()).To(Equal(exp))
+			},
+
+			Entry("none", diff.Compatible),
+			Entry("compatible", diff.Backward, diff.Compatible, diff.Backward),
+			Entry("forward", diff.Forward, diff.Forward, diff.Compatible),
+			Entry("both directions", diff.Breaking, diff.Backward, diff.Forward),
+			Entry("breaking", diff.Breaking, diff.Breaking, diff.Backward),
+		)
+	})
+})
@@ -0,0 +1,159 @@
+package formats
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DateTime checks RFC 3339 date-time, e.g. "1985-04-12T23:20:50.52Z".
+func DateTime(s string) error {
+	i := strings.IndexAny(s, "Tt")
+	if i < 0 {
+		return invalid("date-time", "'T' separator expected")
+	}
+
+	if err := date("date-time", s[:i]); err != nil {
+		return err
+	}
+
+	return fullTime("date-time", s[i+1:])
+}
+
+// Date checks RFC 3339 full-date, e.g. "1985-04-12".
+func Date(s string) error {
+	return date("date", s)
+}
+
+// Time checks RFC 3339 full-time, e.g. "23:20:50.52Z".
+func Time(s string) error {
+	return fullTime("time", s)
+}
+
+var duration = regexp.MustCompile(`^P(?:` +
+	`(?:\d+Y(?:\d+M(?:\d+D)?)?|\d+M(?:\d+D)?|\d+D)(?:T(?:\d+H(?:\d+M(?:\d+S)?)?|\d+M(?:\d+S)?|\d+S))?` +
+	`|T(?:\d+H(?:\d+M(?:\d+S)?)?|\d+M(?:\d+S)?|\d+S)` +
+	`|\d+W)$`)
+
+// Duration checks ISO 8601 duration as defined in RFC 3339 appendix A, e.g.
+// "P1DT2H" or "P4W".
+func Duration(s string) error {
+	if !duration.MatchString(s) {
+		return invalid("duration", "%q doesn't match ISO 8601 duration", s)
+	}
+
+	return nil
+}
+
+// date checks full-date = date-fullyear "-" date-month "-" date-mday.
+func date(format, s string) error {
+	if len(s) != 10 || s[4] != '-' || s[7] != '-' {
+		return invalid(format, "YYYY-MM-DD expected")
+	}
+
+	y, ok1 := number(s[0:4])
+	m, ok2 := number(s[5:7])
+	d, ok3 := number(s[8:10])
+
+	if !ok1 || !ok2 || !ok3 {
+		return invalid(format, "YYYY-MM-DD expected")
+	}
+
+	if m < 1 || m > 12 {
+		return invalid(format, "month %02d is out of range", m)
+	}
+
+	if d < 1 || d > daysIn(m, y) {
+		return invalid(format, "day %02d is out of range", d)
+	}
+
+	return nil
+}
+
+// fullTime checks full-time = partial-time time-offset, where partial-time is
+// time-hour ":" time-minute ":" time-second [time-secfrac].
+func fullTime(format, s string) error {
+	if len(s) < 8 || s[2] != ':' || s[5] != ':' {
+		return invalid(format, "HH:MM:SS expected")
+	}
+
+	h, ok1 := number(s[0:2])
+	m, ok2 := number(s[3:5])
+	sec, ok3 := number(s[6:8])
+
+	if !ok1 || !ok2 || !ok3 {
+		return invalid(format, "HH:MM:SS expected")
+	}
+
+	if h > 23 || m > 59 || sec > 60 {
+		return invalid(format, "time %s is out of range", s[:8])
+	}
+
+	s = s[8:]
+
+	if s != "" && s[0] == '.' {
+		n := digits(s[1:])
+		if n == 0 {
+			return invalid(format, "digits expected after '.'")
+		}
+
+		s = s[n+1:]
+	}
+
+	// offset in minutes
+	var off int
+
+	switch {
+	case s == "Z" || s == "z":
+	case len(s) == 6 && (s[0] == '+' || s[0] == '-') && s[3] == ':':
+		oh, ok1 := number(s[1:3])
+		om, ok2 := number(s[4:6])
+
+		if !ok1 || !ok2 || oh > 23 || om > 59 {
+			return invalid(format, "invalid time offset %q", s)
+		}
+
+		off = oh*60 + om
+		if s[0] == '-' {
+			off = -off
+		}
+	default:
+		return invalid(format, "time offset expected")
+	}
+
+	// Leap second is allowed only at the end of a day in UTC.
+	if sec == 60 {
+		utc := ((h*60+m-off)%(24*60) + 24*60) % (24 * 60)
+		if utc != 23*60+59 {
+			return invalid(format, "leap second is allowed at 23:59 UTC only")
+		}
+	}
+
+	return nil
+}
+
+// number parses a fixed-width unsigned number.
+func number(s string) (int, bool) {
+	if digits(s) != len(s) {
+		return 0, false
+	}
+
+	n, err := strconv.Atoi(s)
+
+	return n, err == nil
+}
+
+func daysIn(m, y int) int {
+	switch m {
+	case 2:
+		if y%4 == 0 && (y%100 != 0 || y%400 == 0) {
+			return 29
+		}
+
+		return 28
+	case 4, 6, 9, 11:
+		return 30
+	}
+
+	return 31
+}
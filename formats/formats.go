@@ -0,0 +1,193 @@
+// Package formats checks strings against formats defined by JSON schema.
+//
+// https://json-schema.org/draft/2020-12/json-schema-validation.html#rfc.section.7.3
+package formats
+
+import (
+	"fmt"
+
+	"github.com/ekhabarov/jsg/ast"
+	"github.com/ekhabarov/jsg/regex"
+)
+
+// Checker checks whether s conforms to a format.
+type Checker func(s string) error
+
+var checkers = map[ast.StringFormat]Checker{
+	ast.FormatDateTime:            DateTime,
+	ast.FormatTime:                Time,
+	ast.FormatDate:                Date,
+	ast.FormatDuration:            Duration,
+	ast.FormatEmail:               Email,
+	ast.FormatIdnEmail:            IdnEmail,
+	ast.FormatHostname:            Hostname,
+	ast.FormatIdnHostname:         IdnHostname,
+	ast.FormatIPv4:                IPv4,
+	ast.FormatIPv6:                IPv6,
+	ast.FormatUUID:                UUID,
+	ast.FormatURI:                 URI,
+	ast.FormatURIReference:        URIReference,
+	ast.FormatIRI:                 IRI,
+	ast.FormatIRIReference:        IRIReference,
+	ast.FormatURITemplate:         URITemplate,
+	ast.FormatJSONPointer:         JSONPointer,
+	ast.FormatRelativeJSONPointer: RelativeJSONPointer,
+	ast.FormatRegex:               Regex,
+}
+
+// Check checks s against format f, which is either built-in or registered
+// with ast.RegisterFormat. Unknown formats and custom ones without checker
+// accept any string.
+func Check(f ast.StringFormat, s string) error {
+	if c, ok := checkers[f]; ok {
+		return c(s)
+	}
+
+	def, ok := f.Custom()
+	if !ok || def.Check == nil {
+		return nil
+	}
+
+	if err := def.Check(s); err != nil {
+		return invalid(def.Name, "%s", err)
+	}
+
+	return nil
+}
+
+// Error is returned by checkers for strings, which don't conform to the
+// format.
+type Error struct {
+	// Format is a format name, e.g. "date-time".
+	Format string
+	Reason string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Format, e.Reason)
+}
+
+func invalid(format, msg string, args ...interface{}) error {
+	return &Error{Format: format, Reason: fmt.Sprintf(msg, args...)}
+}
+
+// UUID checks RFC 4122 UUID in its string representation, e.g.
+// "f81d4fae-7dec-11d0-a765-00a0c91e6bf6".
+func UUID(s string) error {
+	if len(s) != 36 {
+		return invalid("uuid", "expected 36 characters, got %d", len(s))
+	}
+
+	for i, c := range s {
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return invalid("uuid", "expected '-' at %d", i)
+			}
+		default:
+			if !isHex(c) {
+				return invalid("uuid", "expected hex digit at %d", i)
+			}
+		}
+	}
+
+	return nil
+}
+
+// JSONPointer checks RFC 6901 JSON pointer.
+func JSONPointer(s string) error {
+	if s == "" {
+		return nil
+	}
+
+	if s[0] != '/' {
+		return invalid("json-pointer", "must start with '/'")
+	}
+
+	return pointerTokens("json-pointer", s)
+}
+
+// RelativeJSONPointer checks relative JSON pointer, e.g. "0/foo", "1#" or
+// "0-1/bar".
+//
+// https://datatracker.ietf.org/doc/html/draft-bhutton-relative-json-pointer-00
+func RelativeJSONPointer(s string) error {
+	i := digits(s)
+
+	if i == 0 {
+		return invalid("relative-json-pointer", "must start with non-negative integer")
+	}
+
+	if s[0] == '0' && i > 1 {
+		return invalid("relative-json-pointer", "leading zeros are not allowed")
+	}
+
+	rest := s[i:]
+
+	if rest != "" && (rest[0] == '+' || rest[0] == '-') {
+		j := digits(rest[1:])
+		if j == 0 || rest[1] == '0' {
+			return invalid("relative-json-pointer", "index manipulation must be a positive integer")
+		}
+
+		rest = rest[j+1:]
+	}
+
+	if rest == "#" {
+		return nil
+	}
+
+	if rest != "" && rest[0] != '/' {
+		return invalid("relative-json-pointer", "'#' or JSON pointer expected after prefix")
+	}
+
+	return pointerTokens("relative-json-pointer", rest)
+}
+
+// pointerTokens checks escape sequences in JSON pointer.
+func pointerTokens(format, s string) error {
+	for i := 0; i < len(s); i++ {
+		if s[i] == '~' && (i+1 == len(s) || (s[i+1] != '0' && s[i+1] != '1')) {
+			return invalid(format, "'~' must be followed by '0' or '1' at %d", i)
+		}
+	}
+
+	return nil
+}
+
+// Regex checks ECMA-262 regular expression. Valid constructs, which Go can't
+// handle, like lookarounds, are accepted.
+func Regex(s string) error {
+	if _, err := regex.Compile(s); err != nil {
+		if e, ok := err.(*regex.Error); ok && e.Unsupported {
+			return nil
+		}
+
+		return invalid("regex", "%s", err)
+	}
+
+	return nil
+}
+
+// digits returns the number of leading ASCII digits in s.
+func digits(s string) int {
+	i := 0
+
+	for i < len(s) && isDigit(rune(s[i])) {
+		i++
+	}
+
+	return i
+}
+
+func isDigit(c rune) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isAlpha(c rune) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isHex(c rune) bool {
+	return isDigit(c) || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
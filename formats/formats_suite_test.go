@@ -0,0 +1,13 @@
+package formats_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestFormats(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Formats Suite")
+}
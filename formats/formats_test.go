@@ -0,0 +1,127 @@
+package formats_test
+
+import (
+	"errors"
+
+	"github.com/ekhabarov/jsg/ast"
+	"github.com/ekhabarov/jsg/formats"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Formats", func() {
+
+	Context("Custom formats", func() {
+
+		It("uses registered checker", func() {
+			f, err := ast.RegisterFormat(ast.FormatDef{
+				Name: "even-length",
+				Check: func(s string) error {
+					if len(s)%2 != 0 {
+						return errors.New("odd length")
+					}
+
+					return nil
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(formats.Check(f, "ab")).To(Succeed())
+			Expect(formats.Check(f, "abc")).To(MatchError("invalid even-length: odd length"))
+		})
+	})
+
+	Context("Check", func() {
+
+		DescribeTable("Valid",
+			func(f ast.StringFormat, s string) {
+				Expect(formats.Check(f, s)).To(Succeed())
+			},
+
+			Entry("date-time", ast.FormatDateTime, "1985-04-12T23:20:50.52Z"),
+			Entry("date-time: offset", ast.FormatDateTime, "1996-12-19T16:39:57-08:00"),
+			Entry("date-time: lower case", ast.FormatDateTime, "1996-12-19t16:39:57z"),
+			Entry("date-time: leap second", ast.FormatDateTime, "1998-12-31T23:59:60Z"),
+			Entry("date-time: leap second with offset", ast.FormatDateTime, "1998-12-31T15:59:60.123-08:00"),
+			Entry("date: leap year", ast.FormatDate, "2020-02-29"),
+			Entry("time", ast.FormatTime, "08:30:06.283185Z"),
+			Entry("duration", ast.FormatDuration, "P4DT12H30M5S"),
+			Entry("duration: weeks", ast.FormatDuration, "P4W"),
+			Entry("duration: time only", ast.FormatDuration, "PT36H"),
+			Entry("email", ast.FormatEmail, "joe.bloggs@example.com"),
+			Entry("email: quoted", ast.FormatEmail, `"joe bloggs"@example.com`),
+			Entry("email: IPv6 literal", ast.FormatEmail, "joe@[IPv6:::1]"),
+			Entry("idn-email", ast.FormatIdnEmail, "실례@실례.테스트"),
+			Entry("hostname", ast.FormatHostname, "www.example.com"),
+			Entry("hostname: A-label", ast.FormatHostname, "xn--4gbwdl.xn--wgbh1c"),
+			Entry("idn-hostname", ast.FormatIdnHostname, "실례.테스트"),
+			Entry("ipv4", ast.FormatIPv4, "192.168.0.1"),
+			Entry("ipv6", ast.FormatIPv6, "::ffff:192.168.0.1"),
+			Entry("uuid", ast.FormatUUID, "2EB8AA08-AA98-11EA-B4AA-73B441D16380"),
+			Entry("uri", ast.FormatURI, "http://user@[2001:db8::7]:80/c=GB?objectClass?one#f"),
+			Entry("uri: urn", ast.FormatURI, "urn:oasis:names:specification:docbook:dtd:xml:4.1.2"),
+			Entry("uri-reference", ast.FormatURIReference, "../a/b?c#d"),
+			Entry("uri-reference: fragment", ast.FormatURIReference, "#/$defs/a"),
+			Entry("iri", ast.FormatIRI, "http://ƒøø.ßår/?∂éœ=πîx#πîüx"),
+			Entry("iri-reference", ast.FormatIRIReference, "//ƒøø.ßår/?∂éœ=πîx#πîüx"),
+			Entry("uri-template", ast.FormatURITemplate, "http://example.com/dictionary/{term:1}/{term}{?q*,lang}"),
+			Entry("json-pointer", ast.FormatJSONPointer, "/foo/bar~0/~1baz"),
+			Entry("json-pointer: empty", ast.FormatJSONPointer, ""),
+			Entry("relative-json-pointer", ast.FormatRelativeJSONPointer, "1/foo"),
+			Entry("relative-json-pointer: index", ast.FormatRelativeJSONPointer, "0#"),
+			Entry("relative-json-pointer: manipulation", ast.FormatRelativeJSONPointer, "0-1/foo"),
+			Entry("regex", ast.FormatRegex, "^[a-z]+$"),
+			Entry("regex: lookahead", ast.FormatRegex, "^(?=a)\\w+$"),
+			Entry("unknown format", ast.StringFormat(""), "anything"),
+		)
+
+		DescribeTable("Invalid",
+			func(f ast.StringFormat, s, msg string) {
+				err := formats.Check(f, s)
+				Expect(err).To(MatchError(ContainSubstring(msg)))
+
+				var fe *formats.Error
+				Expect(err).To(BeAssignableToTypeOf(fe))
+			},
+
+			Entry("date-time: no separator", ast.FormatDateTime, "1985-04-12 23:20:50Z", "'T' separator expected"),
+			Entry("date-time: no offset", ast.FormatDateTime, "1985-04-12T23:20:50", "time offset expected"),
+			Entry("date-time: wrong leap second", ast.FormatDateTime, "1998-12-31T23:58:60Z", "leap second"),
+			Entry("date: not a leap year", ast.FormatDate, "2021-02-29", "day 29 is out of range"),
+			Entry("date: month", ast.FormatDate, "2021-13-01", "month 13 is out of range"),
+			Entry("time: hour", ast.FormatTime, "24:00:00Z", "out of range"),
+			Entry("duration: empty", ast.FormatDuration, "P", "doesn't match"),
+			Entry("duration: mixed weeks", ast.FormatDuration, "P1Y2W", "doesn't match"),
+			Entry("duration: empty time", ast.FormatDuration, "P1DT", "doesn't match"),
+			Entry("email: no @", ast.FormatEmail, "joe.example.com", "'@' expected"),
+			Entry("email: double dot", ast.FormatEmail, "joe..bloggs@example.com", "empty atom"),
+			Entry("email: non-ASCII", ast.FormatEmail, "실례@example.com", "invalid character"),
+			Entry("hostname: underscore", ast.FormatHostname, "not_valid.com", "contains '_'"),
+			Entry("hostname: hyphen", ast.FormatHostname, "-a.com", "starts or ends with '-'"),
+			Entry("hostname: long label", ast.FormatHostname, "a123456789012345678901234567890123456789012345678901234567890123.com", "label length"),
+			Entry("hostname: bad A-label", ast.FormatHostname, "xn--X", "not a valid A-label"),
+			Entry("idn-hostname: invalid", ast.FormatIdnHostname, "〮실례.테스트", "idn-hostname"),
+			Entry("ipv4: leading zero", ast.FormatIPv4, "087.10.0.1", "leading zeros"),
+			Entry("ipv4: range", ast.FormatIPv4, "256.0.0.1", "out of range"),
+			Entry("ipv6: ipv4", ast.FormatIPv6, "127.0.0.1", "not an IPv6 address"),
+			Entry("ipv6: zone", ast.FormatIPv6, "fe80::1%eth0", "not an IPv6 address"),
+			Entry("uuid: short", ast.FormatUUID, "2eb8aa08-aa98-11ea-b4aa-73b441d1638", "36 characters"),
+			Entry("uuid: not hex", ast.FormatUUID, "2eb8aa08-aa98-11ea-b4aa-73b441d1638x", "hex digit"),
+			Entry("uri: relative", ast.FormatURI, "/abc", "scheme expected"),
+			Entry("uri: space", ast.FormatURI, "http://example.com/a b", "invalid character ' '"),
+			Entry("uri: percent", ast.FormatURI, "http://example.com/%zz", "percent-encoding"),
+			Entry("uri: non-ASCII", ast.FormatURI, "http://ƒøø.com", "invalid character"),
+			Entry("uri-reference: backslash", ast.FormatURIReference, `\\WINDOWS\fileshare`, "invalid character"),
+			Entry("iri: relative", ast.FormatIRI, "/ƒøø", "scheme expected"),
+			Entry("uri-template: unclosed", ast.FormatURITemplate, "http://example.com/{term", "unclosed expression"),
+			Entry("uri-template: prefix", ast.FormatURITemplate, "{term:10000}", "prefix modifier"),
+			Entry("json-pointer: not absolute", ast.FormatJSONPointer, "foo", "must start with '/'"),
+			Entry("json-pointer: escape", ast.FormatJSONPointer, "/foo~2", "'~' must be followed"),
+			Entry("relative-json-pointer: leading zero", ast.FormatRelativeJSONPointer, "01/a", "leading zeros"),
+			Entry("relative-json-pointer: absolute", ast.FormatRelativeJSONPointer, "/a", "non-negative integer"),
+			Entry("regex", ast.FormatRegex, "^(abc", "missing closing )"),
+		)
+	})
+
+})
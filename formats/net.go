@@ -0,0 +1,186 @@
+package formats
+
+import (
+	"net"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+)
+
+// IPv4 checks dotted-quad IPv4 address as defined in RFC 2673 section 3.2,
+// e.g. "192.168.0.1". Leading zeros are not allowed.
+func IPv4(s string) error {
+	parts := strings.Split(s, ".")
+	if len(parts) != 4 {
+		return invalid("ipv4", "four octets expected")
+	}
+
+	for _, p := range parts {
+		n, ok := number(p)
+
+		switch {
+		case !ok || len(p) == 0 || len(p) > 3:
+			return invalid("ipv4", "invalid octet %q", p)
+		case len(p) > 1 && p[0] == '0':
+			return invalid("ipv4", "leading zeros are not allowed in %q", p)
+		case n > 255:
+			return invalid("ipv4", "octet %s is out of range", p)
+		}
+	}
+
+	return nil
+}
+
+// IPv6 checks RFC 4291 IPv6 address, e.g. "2001:db8::1".
+func IPv6(s string) error {
+	if !strings.Contains(s, ":") || net.ParseIP(s) == nil {
+		return invalid("ipv6", "%q is not an IPv6 address", s)
+	}
+
+	return nil
+}
+
+// Hostname checks RFC 1123 hostname, e.g. "www.example.com". Labels starting
+// with "xn--" must be valid Punycode.
+func Hostname(s string) error {
+	return hostname("hostname", s)
+}
+
+var idnaProfile = idna.New(
+	idna.MapForLookup(),
+	idna.BidiRule(),
+	idna.ValidateLabels(true),
+	idna.StrictDomainName(true),
+	idna.VerifyDNSLength(true),
+)
+
+// IdnHostname checks RFC 5890 internationalized hostname, e.g.
+// "例え.テスト".
+func IdnHostname(s string) error {
+	a, err := idnaProfile.ToASCII(s)
+	if err != nil {
+		return invalid("idn-hostname", "%s", strings.TrimPrefix(err.Error(), "idna: "))
+	}
+
+	return hostname("idn-hostname", a)
+}
+
+func hostname(format, s string) error {
+	if s == "" || len(s) > 253 {
+		return invalid(format, "length must be from 1 to 253 characters")
+	}
+
+	for _, l := range strings.Split(s, ".") {
+		if l == "" || len(l) > 63 {
+			return invalid(format, "label length must be from 1 to 63 characters")
+		}
+
+		if l[0] == '-' || l[len(l)-1] == '-' {
+			return invalid(format, "label %q starts or ends with '-'", l)
+		}
+
+		for _, c := range l {
+			if !isAlpha(c) && !isDigit(c) && c != '-' {
+				return invalid(format, "label %q contains %q", l, c)
+			}
+		}
+
+		if strings.HasPrefix(strings.ToLower(l), "xn--") {
+			if _, err := idnaProfile.ToUnicode(l); err != nil {
+				return invalid(format, "label %q is not a valid A-label", l)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Email checks RFC 5321 mailbox, e.g. "joe@example.com".
+func Email(s string) error {
+	return email("email", s, false)
+}
+
+// IdnEmail checks RFC 6531 internationalized mailbox, e.g. "실례@실례.테스트".
+func IdnEmail(s string) error {
+	return email("idn-email", s, true)
+}
+
+func email(format, s string, idn bool) error {
+	i := strings.LastIndexByte(s, '@')
+	if i < 0 {
+		return invalid(format, "'@' expected")
+	}
+
+	local, domain := s[:i], s[i+1:]
+
+	if err := localPart(format, local, idn); err != nil {
+		return err
+	}
+
+	if strings.HasPrefix(domain, "[") && strings.HasSuffix(domain, "]") {
+		addr := domain[1 : len(domain)-1]
+
+		check := IPv4
+		if strings.HasPrefix(addr, "IPv6:") {
+			check, addr = IPv6, addr[len("IPv6:"):]
+		}
+
+		if check(addr) != nil {
+			return invalid(format, "invalid address literal %q", domain)
+		}
+
+		return nil
+	}
+
+	if idn {
+		return IdnHostname(domain)
+	}
+
+	return hostname(format, domain)
+}
+
+// localPart checks Dot-string or Quoted-string.
+func localPart(format, s string, idn bool) error {
+	if s == "" || len(s) > 64 {
+		return invalid(format, "local part length must be from 1 to 64 octets")
+	}
+
+	if !utf8.ValidString(s) {
+		return invalid(format, "invalid UTF-8 in local part")
+	}
+
+	if len(s) > 1 && s[0] == '"' && s[len(s)-1] == '"' {
+		q := s[1 : len(s)-1]
+
+		for i := 0; i < len(q); i++ {
+			c := q[i]
+
+			switch {
+			case c == '\\':
+				i++
+				if i == len(q) || q[i] < 32 || q[i] > 126 {
+					return invalid(format, "invalid quoted pair in local part")
+				}
+			case c == '"' || c < 32 || c == 127 || c > 127 && !idn:
+				return invalid(format, "invalid character %q in quoted local part", c)
+			}
+		}
+
+		return nil
+	}
+
+	for _, atom := range strings.Split(s, ".") {
+		if atom == "" {
+			return invalid(format, "empty atom in local part")
+		}
+
+		for _, c := range atom {
+			if !isAlpha(c) && !isDigit(c) && !strings.ContainsRune("!#$%&'*+-/=?^_`{|}~", c) && !(idn && c > 127) {
+				return invalid(format, "invalid character %q in local part", c)
+			}
+		}
+	}
+
+	return nil
+}
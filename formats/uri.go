@@ -0,0 +1,283 @@
+package formats
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// URI checks RFC 3986 URI with scheme, e.g. "https://example.com/a?b#c".
+func URI(s string) error {
+	return uriReference("uri", s, false, true)
+}
+
+// URIReference checks RFC 3986 URI or relative reference, e.g. "../a#b".
+func URIReference(s string) error {
+	return uriReference("uri-reference", s, false, false)
+}
+
+// IRI checks RFC 3987 IRI with scheme, e.g. "https://例え.テスト/パス".
+func IRI(s string) error {
+	return uriReference("iri", s, true, true)
+}
+
+// IRIReference checks RFC 3987 IRI or relative reference.
+func IRIReference(s string) error {
+	return uriReference("iri-reference", s, true, false)
+}
+
+const (
+	unreserved = "-._~"
+	subDelims  = "!$&'()*+,;="
+	pchar      = unreserved + subDelims + ":@"
+)
+
+// uriReference checks URI-reference, or IRI-reference if iri is set. Scheme
+// is required if absolute is set.
+func uriReference(format, s string, iri, absolute bool) error {
+	if !utf8.ValidString(s) {
+		return invalid(format, "invalid UTF-8")
+	}
+
+	rest := s
+
+	if i := strings.IndexAny(s, ":/?#"); i >= 0 && s[i] == ':' {
+		if !scheme(s[:i]) {
+			return invalid(format, "invalid scheme %q", s[:i])
+		}
+
+		rest = s[i+1:]
+	} else if absolute {
+		return invalid(format, "scheme expected")
+	}
+
+	if i := strings.IndexByte(rest, '#'); i >= 0 {
+		if err := chars(format, rest[i+1:], pchar+"/?", iri, false); err != nil {
+			return err
+		}
+
+		rest = rest[:i]
+	}
+
+	if i := strings.IndexByte(rest, '?'); i >= 0 {
+		if err := chars(format, rest[i+1:], pchar+"/?", iri, iri); err != nil {
+			return err
+		}
+
+		rest = rest[:i]
+	}
+
+	if strings.HasPrefix(rest, "//") {
+		rest = rest[2:]
+
+		i := strings.IndexByte(rest, '/')
+		if i < 0 {
+			i = len(rest)
+		}
+
+		if err := authority(format, rest[:i], iri); err != nil {
+			return err
+		}
+
+		rest = rest[i:]
+	}
+
+	return chars(format, rest, pchar+"/", iri, false)
+}
+
+// scheme checks scheme = ALPHA *( ALPHA / DIGIT / "+" / "-" / "." ).
+func scheme(s string) bool {
+	if s == "" || !isAlpha(rune(s[0])) {
+		return false
+	}
+
+	for _, c := range s {
+		if !isAlpha(c) && !isDigit(c) && c != '+' && c != '-' && c != '.' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// authority checks authority = [ userinfo "@" ] host [ ":" port ].
+func authority(format, s string, iri bool) error {
+	if i := strings.LastIndexByte(s, '@'); i >= 0 {
+		if err := chars(format, s[:i], unreserved+subDelims+":", iri, false); err != nil {
+			return err
+		}
+
+		s = s[i+1:]
+	}
+
+	host, port := s, ""
+
+	if strings.HasPrefix(s, "[") {
+		i := strings.IndexByte(s, ']')
+		if i < 0 {
+			return invalid(format, "']' expected in IP literal")
+		}
+
+		host, port = s[:i+1], s[i+1:]
+
+		if port != "" && port[0] != ':' {
+			return invalid(format, "':' expected after IP literal")
+		}
+
+		if err := ipLiteral(format, host[1:len(host)-1]); err != nil {
+			return err
+		}
+	} else {
+		if i := strings.LastIndexByte(s, ':'); i >= 0 {
+			host, port = s[:i], s[i:]
+		}
+
+		if err := chars(format, host, unreserved+subDelims, iri, false); err != nil {
+			return err
+		}
+	}
+
+	if port != "" && digits(port[1:]) != len(port)-1 {
+		return invalid(format, "invalid port %q", port[1:])
+	}
+
+	return nil
+}
+
+// ipLiteral checks IPv6address or IPvFuture.
+func ipLiteral(format, s string) error {
+	if s != "" && (s[0] == 'v' || s[0] == 'V') {
+		i := strings.IndexByte(s, '.')
+		if i < 2 || i == len(s)-1 {
+			return invalid(format, "invalid IPvFuture %q", s)
+		}
+
+		for _, c := range s[1:i] {
+			if !isHex(c) {
+				return invalid(format, "invalid IPvFuture %q", s)
+			}
+		}
+
+		return chars(format, s[i+1:], unreserved+subDelims+":", false, false)
+	}
+
+	if err := IPv6(s); err != nil {
+		return invalid(format, "invalid IP literal %q", s)
+	}
+
+	return nil
+}
+
+// chars checks that s contains only ASCII letters, digits, allowed characters
+// and percent-encoded octets. Non-ASCII characters are allowed for IRIs.
+func chars(format, s, allowed string, iri, private bool) error {
+	for i, c := range s {
+		switch {
+		case isAlpha(c) || isDigit(c) || c < utf8.RuneSelf && strings.ContainsRune(allowed, c):
+		case c == '%':
+			if i+2 >= len(s) || !isHex(rune(s[i+1])) || !isHex(rune(s[i+2])) {
+				return invalid(format, "invalid percent-encoding at %d", i)
+			}
+		case iri && ucschar(c), private && iprivate(c):
+		default:
+			return invalid(format, "invalid character %q at %d", c, i)
+		}
+	}
+
+	return nil
+}
+
+// ucschar reports whether c is allowed in IRI as defined in RFC 3987 section
+// 2.2.
+func ucschar(c rune) bool {
+	switch {
+	case c >= 0xA0 && c <= 0xD7FF, c >= 0xF900 && c <= 0xFDCF, c >= 0xFDF0 && c <= 0xFFEF:
+		return true
+	case c >= 0x10000 && c <= 0xEFFFD:
+		// Each plane excludes its last two code points.
+		return c&0xFFFF <= 0xFFFD
+	}
+
+	return false
+}
+
+// iprivate reports whether c is a private use character allowed in IRI query.
+func iprivate(c rune) bool {
+	return c >= 0xE000 && c <= 0xF8FF || c >= 0xF0000 && c <= 0xFFFFD || c >= 0x100000 && c <= 0x10FFFD
+}
+
+// URITemplate checks RFC 6570 URI template, e.g. "/users/{id}{?fields*}".
+func URITemplate(s string) error {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		switch {
+		case c == '{':
+			j := strings.IndexByte(s[i:], '}')
+			if j < 0 {
+				return invalid("uri-template", "unclosed expression at %d", i)
+			}
+
+			if err := expression(s[i+1 : i+j]); err != nil {
+				return err
+			}
+
+			i += j
+		case c == '%':
+			if i+2 >= len(s) || !isHex(rune(s[i+1])) || !isHex(rune(s[i+2])) {
+				return invalid("uri-template", "invalid percent-encoding at %d", i)
+			}
+		case c <= ' ' || c == 127 || strings.IndexByte(`"'<>\^`+"`|}", c) >= 0:
+			return invalid("uri-template", "invalid literal %q at %d", c, i)
+		}
+	}
+
+	return nil
+}
+
+// expression checks template expression without braces, i.e.
+// [ operator ] variable-list.
+func expression(s string) error {
+	if s != "" && strings.IndexByte("+#./;?&", s[0]) >= 0 {
+		s = s[1:]
+	}
+
+	for _, v := range strings.Split(s, ",") {
+		if i := strings.IndexByte(v, ':'); i >= 0 {
+			l := v[i+1:]
+			if l == "" || len(l) > 4 || l[0] == '0' || digits(l) != len(l) {
+				return invalid("uri-template", "invalid prefix modifier in %q", v)
+			}
+
+			v = v[:i]
+		} else {
+			v = strings.TrimSuffix(v, "*")
+		}
+
+		if !varname(v) {
+			return invalid("uri-template", "invalid variable name %q", v)
+		}
+	}
+
+	return nil
+}
+
+// varname checks varname = varchar *( ["."] varchar ).
+func varname(s string) bool {
+	if s == "" || s[0] == '.' || s[len(s)-1] == '.' || strings.Contains(s, "..") {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := rune(s[i])
+
+		switch {
+		case isAlpha(c) || isDigit(c) || c == '_' || c == '.':
+		case c == '%' && i+2 < len(s) && isHex(rune(s[i+1])) && isHex(rune(s[i+2])):
+			i += 2
+		default:
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,232 @@
+package gen
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/ekhabarov/jsg/ast"
+	"github.com/iancoleman/strcase"
+)
+
+// Config configures code generation.
+type Config struct {
+	// PackageName is a name of generated package, "schema" by default.
+	PackageName string
+	// OutputDir is a directory GenerateAll writes files into, current
+	// directory by default.
+	OutputDir string
+	// Layout defines how GenerateAll splits types into files.
+	Layout FileLayout
+	// ValidatorTags makes generator produce github.com/go-playground/validator
+	// tags instead of Validate methods.
+	ValidatorTags bool
+	// Tags are struct tags added to every field.
+	Tags []Tag
+	// Warn is called for every schema constraint, which can't be expressed in
+	// generated code.
+	Warn func(Warning)
+	// Mappings override Go types of matching schemas.
+	Mappings []Mapping
+	// Integers defines how Go types of integer schemas are chosen.
+	Integers IntegerWidth
+	// Numbers defines Go types of number and integer schemas.
+	Numbers NumberType
+
+	// schemas maps $id to generated schemas.
+	schemas map[string]*ast.Schema
+	// vars are names of package level variables already generated.
+	vars map[string]bool
+}
+
+// Option configures Generate.
+type Option func(*Config)
+
+// newConfig returns configuration with opts applied to defaults.
+func newConfig(opts []Option) (*Config, error) {
+	c := &Config{PackageName: "schema", schemas: map[string]*ast.Schema{}, vars: map[string]bool{}}
+
+	for _, o := range opts {
+		o(c)
+	}
+
+	for i, m := range c.Mappings {
+		if err := m.validate(); err != nil {
+			return nil, fmt.Errorf("mapping %d: %w", i, err)
+		}
+	}
+
+	return c, nil
+}
+
+// FileLayout defines how generated types are split into files.
+type FileLayout uint8
+
+const (
+	// FilePerSchema puts types of every schema and its $defs into one file
+	// named after the schema $id, e.g. "user_profile.go".
+	FilePerSchema FileLayout = iota
+	// FilePerDef puts every type into its own file, e.g. "address.go".
+	FilePerDef
+	// SingleFile puts all types into one file named after the package.
+	SingleFile
+)
+
+// IntegerWidth defines how Go types of integer schemas are chosen.
+type IntegerWidth uint8
+
+const (
+	// PlatformInt makes every integer an int.
+	PlatformInt IntegerWidth = iota
+	// BoundedInt picks the narrowest type, which holds values allowed by
+	// minimum and maximum, e.g. uint8 for 0..255 or int32 for -1..1<<31-1.
+	// Integers without a lower bound are signed, ones without an upper bound
+	// are 64-bit.
+	BoundedInt
+	// Int64 makes every integer an int64, which has the same size on all
+	// platforms.
+	Int64
+)
+
+// NumberType defines Go types of number and integer schemas.
+type NumberType uint8
+
+const (
+	// Float makes numbers float64 and integers int, or types chosen by
+	// Integers.
+	Float NumberType = iota
+	// JSONNumber makes numbers and integers json.Number, which keeps them as
+	// written.
+	JSONNumber
+	// BigNumber makes numbers rt.BigFloat, i.e. *big.Float marshaled as JSON
+	// number, and integers *big.Int.
+	BigNumber
+)
+
+// PackageName sets a name of generated package.
+func PackageName(n string) Option {
+	return func(c *Config) {
+		c.PackageName = n
+	}
+}
+
+// OutputDir sets a directory GenerateAll writes files into.
+func OutputDir(d string) Option {
+	return func(c *Config) {
+		c.OutputDir = d
+	}
+}
+
+// Layout sets how GenerateAll splits types into files.
+func Layout(l FileLayout) Option {
+	return func(c *Config) {
+		c.Layout = l
+	}
+}
+
+// Integers sets how Go types of integer schemas are chosen.
+func Integers(w IntegerWidth) Option {
+	return func(c *Config) {
+		c.Integers = w
+	}
+}
+
+// Numbers sets Go types of number and integer schemas. Decimal mapping takes
+// precedence for numbers it matches.
+func Numbers(t NumberType) Option {
+	return func(c *Config) {
+		c.Numbers = t
+	}
+}
+
+// ValidatorTags makes generator translate schema constraints into `validate`
+// tags of github.com/go-playground/validator instead of Validate methods.
+func ValidatorTags() Option {
+	return func(c *Config) {
+		c.ValidatorTags = true
+	}
+}
+
+// Tags adds struct tags to every field, in addition to `json` tag of optional
+// fields.
+func Tags(tags ...Tag) Option {
+	return func(c *Config) {
+		c.Tags = append(c.Tags, tags...)
+	}
+}
+
+// Warnings sets a function, which is called for every schema constraint,
+// which generated code doesn't check.
+func Warnings(f func(Warning)) Option {
+	return func(c *Config) {
+		c.Warn = f
+	}
+}
+
+// Warning describes a schema constraint, which is ignored by generator.
+type Warning struct {
+	// Path is a JSON pointer to the schema with the keyword.
+	Path    string
+	Keyword string
+	Message string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%s/%s: %s", w.Path, w.Keyword, w.Message)
+}
+
+// warn reports a warning if Warn is set.
+func (c *Config) warn(path, keyword, msg string, args ...interface{}) {
+	if c.Warn == nil {
+		return
+	}
+
+	c.Warn(Warning{Path: path, Keyword: keyword, Message: fmt.Sprintf(msg, args...)})
+}
+
+// variable returns a unique name of package level variable based on n. Names
+// of different types and properties may be the same, e.g. "ab" + "c" and
+// "a" + "bc", so the ones already used get a numeric suffix.
+func (c *Config) variable(n string) string {
+	v := n
+	for i := 2; c.vars[v]; i++ {
+		v = n + strconv.Itoa(i)
+	}
+
+	c.vars[v] = true
+
+	return v
+}
+
+// Tag defines a struct tag, e.g. `yaml:"name,omitempty"`.
+type Tag struct {
+	// Key is a tag key, e.g. "yaml".
+	Key    string
+	Naming Naming
+	// OmitEmpty adds ",omitempty" to tags of properties, which aren't
+	// required.
+	OmitEmpty bool
+}
+
+// Naming is a naming convention of tag values.
+type Naming uint8
+
+const (
+	// Original keeps property name as is.
+	Original Naming = iota
+	// Snake converts property name to snake_case.
+	Snake
+	// Camel converts property name to lowerCamelCase.
+	Camel
+)
+
+// apply returns property name n converted according to the convention.
+func (nc Naming) apply(n string) string {
+	switch nc {
+	case Snake:
+		return strcase.ToSnake(n)
+	case Camel:
+		return strcase.ToLowerCamel(n)
+	}
+
+	return n
+}
@@ -0,0 +1,105 @@
+package gen
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"strings"
+
+	"github.com/ekhabarov/jsg/ast"
+)
+
+// goExtensions are x-go-* keywords supported by generator.
+var goExtensions = map[string]bool{
+	"x-go-optional":  true,
+	"x-go-tags":      true,
+	"x-go-type":      true,
+	"x-go-package":   true,
+	"x-go-name":      true,
+	"x-go-omitempty": true,
+	"x-go-pointer":   true,
+	"x-go-embed":     true,
+	"x-go-skip":      true,
+}
+
+// namedType matches Go types, which can be embedded, e.g. *pkg.Name.
+var namedType = regexp.MustCompile(`^\*?([A-Za-z_][0-9A-Za-z_]*\.)?[A-Za-z_][0-9A-Za-z_]*$`)
+
+// checkExtensions returns an error if x-go-* keywords of schema s located at
+// ptr are malformed.
+func checkExtensions(ptr string, s *ast.Schema) error {
+	for k := range s.Extensions {
+		if strings.HasPrefix(k, "x-go-") && !goExtensions[k] {
+			return fmt.Errorf("%s: unsupported keyword %q", ptr, k)
+		}
+	}
+
+	if s.GoName != "" && (!token.IsIdentifier(s.GoName) || !token.IsExported(s.GoName)) {
+		return fmt.Errorf("%s: x-go-name: %q isn't an exported Go identifier", ptr, s.GoName)
+	}
+
+	if s.GoPackage != "" && s.GoType == "" {
+		return fmt.Errorf("%s: x-go-package: x-go-type is required", ptr)
+	}
+
+	if s.GoType != "" {
+		if _, err := parser.ParseExpr(s.GoType); err != nil {
+			return fmt.Errorf("%s: x-go-type: invalid Go type %q", ptr, s.GoType)
+		}
+
+		if qualified := strings.Contains(s.GoType, "."); qualified != (s.GoPackage != "") {
+			return fmt.Errorf("%s: x-go-type: %q requires x-go-package, if and only if it's qualified", ptr, s.GoType)
+		}
+	}
+
+	if s.Embed && s.Optional != nil && *s.Optional {
+		return fmt.Errorf("%s: x-go-embed: optional fields can't be embedded", ptr)
+	}
+
+	return nil
+}
+
+// fieldName returns a name of the field generated for property n.
+func fieldName(n string, p *ast.Schema) string {
+	if p.GoName != "" {
+		return p.GoName
+	}
+
+	return n
+}
+
+// fieldType returns a Go type of the field generated for property p located
+// at ptr within schema id.
+func fieldType(c *Config, id, ptr string, p *ast.Schema, im imports) (string, error) {
+	t, err := goType(c, id, ptr, p, im)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case p.Pointer == nil:
+	case *p.Pointer && !strings.HasPrefix(t, "*"):
+		t = "*" + t
+	case !*p.Pointer:
+		t = strings.TrimPrefix(t, "*")
+	}
+
+	if p.Embed && !namedType.MatchString(t) {
+		return "", fmt.Errorf("x-go-embed: %s can't be embedded", t)
+	}
+
+	return t, nil
+}
+
+// embedName returns a name of embedded field of Go type t.
+func embedName(t string) string {
+	t = strings.TrimPrefix(t, "*")
+
+	return t[strings.LastIndex(t, ".")+1:]
+}
+
+// isRef reports whether schema s is a reference only.
+func isRef(s *ast.Schema) bool {
+	return s.Type == 0 && s.Ref != ""
+}
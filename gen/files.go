@@ -0,0 +1,251 @@
+package gen
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ekhabarov/jsg/ast"
+	"github.com/ekhabarov/jsg/lib"
+	"github.com/iancoleman/strcase"
+)
+
+// helpersFile is a name of the file with Optional wrappers shared by all
+// other files.
+const helpersFile = "optional.go"
+
+var ErrNoID = errors.New("schema has no $id")
+
+// GenerateAll generates a package from schemas and writes it into
+// Config.OutputDir split into files according to Config.Layout. Every file is
+// written into a temporary one first, which are renamed only after all of
+// them are written, so failures of generation or writing don't leave
+// half-written files. Renaming isn't atomic for the whole package though: if
+// it fails, files renamed before keep their new content.
+func GenerateAll(schemas []*ast.Schema, opts ...Option) error {
+	c, err := newConfig(opts)
+	if err != nil {
+		return err
+	}
+
+	if !token.IsIdentifier(c.PackageName) {
+		return fmt.Errorf("invalid package name %q", c.PackageName)
+	}
+
+	files := map[string]*file{}
+	// owners maps file names to schemas or types they're generated for.
+	owners := map[string]string{}
+	// defined maps type names to $id of their schemas.
+	defined := map[string]string{}
+	// helpers is a file with Optional wrappers of all files.
+	helpers := newFile()
+
+	for _, s := range schemas {
+		if s.ID == "" {
+			return ErrNoID
+		}
+
+		c.schemas[s.ID] = s
+
+		if err := ast.Walk(s, checkExtensions); err != nil {
+			return fmt.Errorf("%s: %w", s.ID, err)
+		}
+	}
+
+	for _, s := range schemas {
+		if err := ast.Walk(s, c.checkBounds(s.ID)); err != nil {
+			return fmt.Errorf("%s: %w", s.ID, err)
+		}
+	}
+
+	for _, s := range schemas {
+		tds, err := types(c, s)
+		if err != nil {
+			return fmt.Errorf("%s: %w", s.ID, err)
+		}
+
+		for _, td := range tds {
+			if id, ok := defined[td.name]; ok {
+				return fmt.Errorf("%s: type %s is already generated from %s", s.ID, td.name, id)
+			}
+
+			defined[td.name] = s.ID
+
+			n, owner := fileName(c, s, td)
+			if o, ok := owners[n]; ok && o != owner {
+				return fmt.Errorf("%s: file %s is already generated for %s", s.ID, n, o)
+			}
+
+			owners[n] = owner
+
+			f, ok := files[n]
+			if !ok {
+				f = newFile()
+				files[n] = f
+			}
+
+			if err := f.typ(td, c); err != nil {
+				return fmt.Errorf("%s: %w", s.ID, err)
+			}
+
+			for o, t := range f.optionals {
+				t = helpers.imports.adopt(t, f.imports)
+				if w, ok := helpers.optionals[o]; ok && w != t {
+					return fmt.Errorf("%s: wrapper %s is already generated for %s", s.ID, o, w)
+				}
+
+				helpers.optionals[o] = t
+			}
+		}
+	}
+
+	out := map[string][]byte{}
+
+	for n, f := range files {
+		b, err := f.render(c.PackageName, c.Layout == SingleFile)
+		if err != nil {
+			return fmt.Errorf("%s: %w", n, err)
+		}
+
+		out[n] = b
+	}
+
+	if c.Layout != SingleFile && len(helpers.optionals) > 0 {
+		if o, ok := owners[helpersFile]; ok {
+			return fmt.Errorf("file %s is already generated for %s", helpersFile, o)
+		}
+
+		b, err := helpers.render(c.PackageName, true)
+		if err != nil {
+			return fmt.Errorf("%s: %w", helpersFile, err)
+		}
+
+		out[helpersFile] = b
+	}
+
+	return write(c.OutputDir, out)
+}
+
+// fileName returns a name of the file for type td of schema s and its owner,
+// i.e. a schema or a type the file is generated for.
+func fileName(c *Config, s *ast.Schema, td typeDef) (string, string) {
+	switch c.Layout {
+	case FilePerDef:
+		return goFile(strcase.ToSnake(td.name)), td.name
+	case SingleFile:
+		return goFile(c.PackageName), ""
+	}
+
+	// URLName has already succeeded in types.
+	n, _ := lib.URLName(s.ID)
+
+	return goFile(strcase.ToSnake(n)), s.ID
+}
+
+// goFile returns a name of Go file with base name n, which go build doesn't
+// ignore or constrain: names with _test, _GOOS or _GOARCH suffixes, e.g.
+// "user_test" or "platform_linux", get "_gen" suffix, and names starting with
+// "_" or "." get "x" prefix.
+func goFile(n string) string {
+	if strings.HasPrefix(n, "_") || strings.HasPrefix(n, ".") {
+		n = "x" + n
+	}
+
+	parts := strings.Split(n, "_")
+	last := parts[len(parts)-1]
+
+	if len(parts) > 1 && (last == "test" || knownOS[last] || knownArch[last]) {
+		n += "_gen"
+	}
+
+	return n + ".go"
+}
+
+// knownOS and knownArch are GOOS and GOARCH values, which go build
+// recognizes in file names, see go/build.
+var (
+	knownOS = map[string]bool{
+		"aix": true, "android": true, "darwin": true, "dragonfly": true,
+		"freebsd": true, "hurd": true, "illumos": true, "ios": true, "js": true,
+		"linux": true, "nacl": true, "netbsd": true, "openbsd": true,
+		"plan9": true, "solaris": true, "wasip1": true, "windows": true,
+		"zos": true,
+	}
+
+	knownArch = map[string]bool{
+		"386": true, "amd64": true, "amd64p32": true, "arm": true, "armbe": true,
+		"arm64": true, "arm64be": true, "loong64": true, "mips": true,
+		"mipsle": true, "mips64": true, "mips64le": true, "mips64p32": true,
+		"mips64p32le": true, "ppc": true, "ppc64": true, "ppc64le": true,
+		"riscv": true, "riscv64": true, "s390": true, "s390x": true,
+		"sparc": true, "sparc64": true, "wasm": true,
+	}
+)
+
+// write writes files into dir: every file goes into a temporary one, which
+// are renamed when all files are written. Temporary files are removed on
+// failure, but files already renamed aren't restored.
+func write(dir string, files map[string][]byte) (err error) {
+	if dir == "" {
+		dir = "."
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	names := make([]string, 0, len(files))
+	for n := range files {
+		names = append(names, n)
+	}
+
+	sort.Strings(names)
+
+	temps := map[string]string{}
+
+	defer func() {
+		if err == nil {
+			return
+		}
+
+		for _, t := range temps {
+			os.Remove(t)
+		}
+	}()
+
+	for _, n := range names {
+		t, err := os.CreateTemp(dir, "."+n+".*")
+		if err != nil {
+			return fmt.Errorf("failed to create temporary file: %w", err)
+		}
+
+		temps[n] = t.Name()
+
+		_, err = t.Write(files[n])
+		if cerr := t.Close(); err == nil {
+			err = cerr
+		}
+
+		if err == nil {
+			err = os.Chmod(t.Name(), 0o644)
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", n, err)
+		}
+	}
+
+	for _, n := range names {
+		if err := os.Rename(temps[n], filepath.Join(dir, n)); err != nil {
+			return fmt.Errorf("failed to write %s: %w", n, err)
+		}
+
+		delete(temps, n)
+	}
+
+	return nil
+}
@@ -7,9 +7,11 @@ import (
 	"go/format"
 	"io"
 	"sort"
+	"strings"
 
 	"github.com/ekhabarov/jsg/ast"
 	"github.com/ekhabarov/jsg/lib"
+	"github.com/iancoleman/strcase"
 )
 
 var (
@@ -22,26 +24,46 @@ type option func(string) string
 
 type piece func() string
 
-// One schema output per file.
-
-func Generate(w io.Writer, s *ast.Schema) error {
-	buf := bytes.NewBuffer([]byte{})
-
-	p(buf, header())
+// Generate writes code generated from schema s and its $defs into w as a
+// single file.
+func Generate(w io.Writer, s *ast.Schema, opts ...Option) error {
+	c, err := newConfig(opts)
+	if err != nil {
+		return err
+	}
 
 	if s.ID == "" {
-		fmt.Fprintf(w, "%s", buf.String())
+		fmt.Fprintf(w, "%s", header(c.PackageName))
 
 		return nil
 	}
 
-	if err := structure(buf, s); err != nil {
-		return fmt.Errorf("failed to build struct header: %w", err)
+	c.schemas[s.ID] = s
+
+	if err := ast.Walk(s, checkExtensions); err != nil {
+		return err
+	}
+
+	if err := ast.Walk(s, c.checkBounds(s.ID)); err != nil {
+		return err
+	}
+
+	f := newFile()
+
+	tds, err := types(c, s)
+	if err != nil {
+		return err
+	}
+
+	for _, td := range tds {
+		if err := f.typ(td, c); err != nil {
+			return fmt.Errorf("failed to build struct header: %w", err)
+		}
 	}
 
-	b, err := format.Source(buf.Bytes())
+	b, err := f.render(c.PackageName, true)
 	if err != nil {
-		return fmt.Errorf("gofmt failed: %w", err)
+		return err
 	}
 
 	fmt.Fprintf(w, "%s", b)
@@ -49,31 +71,163 @@ func Generate(w io.Writer, s *ast.Schema) error {
 	return nil
 }
 
-func p(w io.Writer, s string, args ...interface{}) {
-	fmt.Fprintf(w, s, args...)
+func header(pkg string) string {
+	return "// Code generated by jsg. DO NOT EDIT.\n\npackage " + pkg + "\n"
 }
 
-func header() string {
-	return "// Code generated by jsg. DO NOT EDIT.\n\npackage schema\n"
+// file accumulates code of one generated file.
+type file struct {
+	imports imports
+	// map of Optional wrappers, name => wrapped type
+	optionals map[string]string
+	body      *bytes.Buffer
 }
 
-func structure(out io.Writer, s *ast.Schema) error {
-	if len(s.Properties) < 1 {
-		return ErrNoProps
+func newFile() *file {
+	return &file{
+		imports:   imports{},
+		optionals: map[string]string{},
+		body:      bytes.NewBuffer([]byte{}),
 	}
+}
 
-	name, err := lib.URLName(s.ID)
-	if err != nil {
-		return fmt.Errorf("failed to find schema name: %w", err)
+// render returns formatted source of the file in package pkg. Optional
+// wrappers are added if helpers is set, otherwise they're expected to be
+// rendered into a separate file.
+func (f *file) render(pkg string, helpers bool) ([]byte, error) {
+	code := bytes.NewBuffer([]byte{})
+
+	if _, err := io.Copy(code, f.body); err != nil {
+		return nil, ErrWriteStruct
+	}
+
+	if helpers && len(f.optionals) > 0 {
+		f.imports.use("encoding/json", "json")
+
+		ok := []string{}
+		for o := range f.optionals {
+			ok = append(ok, o)
+		}
+
+		sort.Strings(ok)
+
+		for _, o := range ok {
+			fmt.Fprintf(code, optionalTmpl, o, f.optionals[o])
+		}
 	}
 
-	// temporary buffer
 	w := bytes.NewBuffer([]byte{})
-	imports := bytes.NewBuffer([]byte{})
-	// map of unique imports
-	uimports := map[string]struct{}{}
+	fmt.Fprint(w, header(pkg))
+
+	if err := writeImports(w, f.imports, code.Bytes()); err != nil {
+		return nil, err
+	}
+
+	if _, err := io.Copy(w, code); err != nil {
+		return nil, ErrWriteStruct
+	}
+
+	b, err := format.Source(w.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("gofmt failed: %w", err)
+	}
 
-	fmt.Fprintf(w, "type %s struct {\n", name)
+	return b, nil
+}
+
+// typeDef is a Go type generated from a schema or its $defs entry.
+type typeDef struct {
+	name string
+	// id is $id of the root schema.
+	id string
+	// ptr is a JSON pointer to the schema within the root one.
+	ptr string
+	s   *ast.Schema
+	// def is set for $defs entries.
+	def bool
+}
+
+// types returns types generated from schema s: one for s itself, unless it
+// has $defs only, and one per $defs entry. Schemas mapped to existing types
+// and ones with x-go-skip are skipped. Types with the same name are errors.
+func types(c *Config, s *ast.Schema) ([]typeDef, error) {
+	tds := []typeDef{}
+	// defined maps type names to $id of the root schema or pointers to $defs
+	// entries they're generated for.
+	defined := map[string]string{}
+
+	if (len(s.Properties) > 0 || len(s.Defs) == 0) && !s.Skip && c.located(s.ID, "", "") == nil {
+		name, err := lib.URLName(s.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find schema name: %w", err)
+		}
+
+		if s.GoName != "" {
+			name = s.GoName
+		}
+
+		defined[name] = s.ID
+		tds = append(tds, typeDef{name: name, id: s.ID, s: s})
+	}
+
+	names := []string{}
+	for n := range s.Defs {
+		names = append(names, n)
+	}
+
+	sort.Strings(names)
+
+	for _, n := range names {
+		d := s.Defs[n]
+		ptr := "/$defs/" + lib.EscapePointer(n)
+
+		if d.Skip || c.located(s.ID, ptr, "") != nil {
+			continue
+		}
+
+		name := strcase.ToCamel(n)
+		if d.GoName != "" {
+			name = d.GoName
+		}
+
+		if o, ok := defined[name]; ok {
+			return nil, fmt.Errorf("%s: type %s is already generated for %s", ptr, name, o)
+		}
+
+		defined[name] = ptr
+
+		tds = append(tds, typeDef{
+			name: name,
+			id:   s.ID,
+			ptr:  ptr,
+			s:    &d,
+			def:  true,
+		})
+	}
+
+	return tds, nil
+}
+
+// typ writes type td into the file. Schemas with properties become structs,
+// other $defs entries become named types.
+func (f *file) typ(td typeDef, c *Config) error {
+	if td.def && len(td.s.Properties) == 0 {
+		return f.named(td, c)
+	}
+
+	return f.structure(td, c)
+}
+
+func (f *file) structure(td typeDef, c *Config) error {
+	s := td.s
+
+	if len(s.Properties) < 1 {
+		return ErrNoProps
+	}
+
+	w := f.body
+
+	fmt.Fprintf(w, "\ntype %s struct {\n", td.name)
 
 	keys := []string{}
 
@@ -83,45 +237,295 @@ func structure(out io.Writer, s *ast.Schema) error {
 
 	sort.Strings(keys)
 
+	required := map[string]bool{}
+	for _, n := range s.Required {
+		required[n] = true
+	}
+
+	// fields maps names of fields to properties.
+	fields := map[string]string{}
+
 	for _, n := range keys {
 		p := s.Properties[n]
+		if p.Skip {
+			continue
+		}
+
+		ptr := td.ptr + "/properties/" + lib.EscapePointer(n)
 
-		t, imp, err := ast.GoType(p.Type, p.Format, p.Ref)
+		t, err := fieldType(c, td.id, ptr, &p, f.imports)
 		if err != nil {
-			return fmt.Errorf("go type not found: schema type: %q, format: %v", p.Type, p.Format)
+			return fmt.Errorf("%s: %w", ptr, err)
 		}
 
-		if imp != "" {
-			uimports[imp] = struct{}{}
+		name := fieldName(n, &p)
+		if p.Embed {
+			name = embedName(t)
 		}
 
-		fmt.Fprintf(w, "%s %s\n", n, t)
+		if o, ok := fields[name]; ok {
+			return fmt.Errorf("%s: field %s is already generated for property %q", ptr, name, o)
+		}
+
+		fields[name] = n
+
+		if p.Embed {
+			fmt.Fprintf(w, "%s\n", t)
+
+			continue
+		}
+
+		opt := optional(s, &p)
+
+		tags, err := fieldTags(c, n, &p, required[n], opt)
+		if err != nil {
+			return fmt.Errorf("%s: %w", ptr, err)
+		}
+
+		if opt {
+			o := optionalName(t)
+			if w, ok := f.optionals[o]; ok && w != t {
+				return fmt.Errorf("%s: wrapper %s is already generated for %s", ptr, o, w)
+			}
+
+			f.optionals[o] = t
+			t = o
+
+			if c.ValidatorTags {
+				c.warn(ptr, "x-go-optional", "optional fields can't be checked by validator tags")
+			}
+		} else if c.ValidatorTags {
+			if tag := validatorTag(c, ptr, t, &p, required[n]); tag != "" {
+				tags = append(tags, fmt.Sprintf("validate:\"%s\"", tag))
+			}
+		}
+
+		if len(tags) > 0 {
+			fmt.Fprintf(w, "%s %s `%s`\n", name, t, strings.Join(tags, " "))
+
+			continue
+		}
+
+		fmt.Fprintf(w, "%s %s\n", name, t)
 	}
 
 	fmt.Fprintln(w, "}")
 
-	if len(uimports) > 0 {
-		ik := []string{}
-		for i := range uimports {
-			ik = append(ik, i)
+	if c.ValidatorTags {
+		if s.MinProperties != nil || s.MaxProperties != nil {
+			c.warn(td.ptr, "minProperties", "no validator tags for number of properties")
 		}
 
-		sort.Strings(ik)
+		return nil
+	}
+
+	if err := validation(w, c, td, f.imports); err != nil {
+		return fmt.Errorf("failed to build Validate method: %w", err)
+	}
 
-		for _, k := range ik {
-			fmt.Fprintf(imports, "import %q\n", k)
+	return nil
+}
+
+// named writes a named type for $defs entry, which isn't an object, e.g.
+// type Code string. Entries with $ref only become aliases.
+func (f *file) named(td typeDef, c *Config) error {
+	s := td.s
+
+	if s.Type == 0 && s.Ref != "" {
+		t, err := goType(c, td.id, td.ptr, s, f.imports)
+		if err != nil {
+			return fmt.Errorf("%s: %w", td.ptr, err)
 		}
 
-		_, err = io.Copy(out, imports)
+		fmt.Fprintf(f.body, "\ntype %s = %s\n", td.name, strings.TrimPrefix(t, "*"))
+
+		return nil
+	}
+
+	t, err := goType(c, td.id, td.ptr, s, f.imports)
+	if err != nil {
+		return fmt.Errorf("%s: go type not found: schema type: %q, format: %v", td.ptr, s.Type, s.Format)
+	}
+
+	fmt.Fprintf(f.body, "\ntype %s %s\n", td.name, t)
+
+	if c.ValidatorTags {
+		return nil
+	}
+
+	if err := valueValidation(f.body, c, td, t, f.imports); err != nil {
+		return fmt.Errorf("failed to build Validate method: %w", err)
+	}
+
+	return nil
+}
+
+// goType returns a Go type of schema s located at ptr within schema id and
+// adds imports it requires to im. Arrays with "items" become slices of the
+// items type.
+func goType(c *Config, id, ptr string, s *ast.Schema, im imports) (string, error) {
+	if m := c.mapping(id, ptr, s); m != nil {
+		if m.Import == "" {
+			return m.GoType, nil
+		}
+
+		return im.qualify(m.GoType, m.Import), nil
+	}
+
+	// Referenced type may be renamed with x-go-name.
+	if isRef(s) {
+		if t := c.target(id, s.Ref); t != nil && t.GoName != "" {
+			return "*" + t.GoName, nil
+		}
+	}
+
+	if s.Type == ast.Array && s.Items != nil {
+		t, err := goType(c, id, ptr+"/items", s.Items, im)
 		if err != nil {
-			return ErrWriteImports
+			return "", err
+		}
+
+		// JSON represents []uint8 as base64 string.
+		if t == "uint8" && c.mapping(id, ptr+"/items", s.Items) == nil {
+			t = "uint16"
 		}
+
+		return "[]" + t, nil
+	}
+
+	if t, imp := c.numberType(s); t != "" {
+		return im.qualify(t, imp), nil
+	}
+
+	if s.Type == ast.Integer {
+		return c.integerType(s), nil
 	}
 
-	_, err = io.Copy(out, w)
+	t, imp, err := ast.GoType(s.Type, s.Format, s.Ref)
 	if err != nil {
-		return ErrWriteStruct
+		return "", err
+	}
+
+	if imp == "" {
+		return t, nil
 	}
 
+	return im.qualify(t, imp), nil
+}
+
+// optional reports whether property p of schema s must be wrapped into an
+// Optional type. Property level x-go-optional overrides the schema level one.
+func optional(s, p *ast.Schema) bool {
+	if p.Optional != nil {
+		return *p.Optional
+	}
+
+	return s.Optional != nil && *s.Optional
+}
+
+// optionalName returns a name of Optional wrapper for Go type t made of the
+// whole qualified type, e.g. string => OptionalString, *big.Int =>
+// OptionalBigIntPtr, []int => OptionalIntSlice. A package name is omitted, if
+// it's the type name, e.g. time.Time => OptionalTime.
+func optionalName(t string) string {
+	suffix := ""
+
+	for {
+		if strings.HasPrefix(t, "*") {
+			t, suffix = t[1:], "Ptr"+suffix
+		} else if strings.HasPrefix(t, "[]") {
+			t, suffix = t[2:], "Slice"+suffix
+		} else {
+			break
+		}
+	}
+
+	t = strings.TrimSuffix(t, "{}")
+
+	if i := strings.LastIndex(t, "."); i >= 0 && strings.EqualFold(t[:i], t[i+1:]) {
+		t = t[i+1:]
+	}
+
+	n := ""
+	for _, p := range strings.Split(t, ".") {
+		n += strcase.ToCamel(p)
+	}
+
+	return "Optional" + n + suffix
+}
+
+// optionalTmpl is a template of three-state wrapper, where %[1]s is a wrapper
+// name and %[2]s is a wrapped type.
+const optionalTmpl = `
+// %[1]s is a three-state wrapper for %[2]s, which distinguishes between
+// absent, null and set values.
+type %[1]s struct {
+	set   bool
+	null  bool
+	value %[2]s
+}
+
+// Set sets the value.
+func (o *%[1]s) Set(v %[2]s) {
+	*o = %[1]s{set: true, value: v}
+}
+
+// SetNull sets the value to explicit null.
+func (o *%[1]s) SetNull() {
+	*o = %[1]s{set: true, null: true}
+}
+
+// Unset makes the value absent.
+func (o *%[1]s) Unset() {
+	*o = %[1]s{}
+}
+
+// IsSet reports whether the value is present, including explicit null.
+func (o %[1]s) IsSet() bool {
+	return o.set
+}
+
+// IsNull reports whether the value is present and is null.
+func (o %[1]s) IsNull() bool {
+	return o.set && o.null
+}
+
+// IsZero reports whether the value is absent. It's used by omitzero option of
+// encoding/json.
+func (o %[1]s) IsZero() bool {
+	return !o.set
+}
+
+// Value returns the value, or zero value if it's absent or null.
+func (o %[1]s) Value() %[2]s {
+	return o.value
+}
+
+// MarshalJSON implements json.Marshaler.
+func (o %[1]s) MarshalJSON() ([]byte, error) {
+	if !o.set || o.null {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(&o.value)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It's called only for present
+// values, so absent ones stay unset.
+func (o *%[1]s) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		o.SetNull()
+
+		return nil
+	}
+
+	var v %[2]s
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	o.Set(v)
+
 	return nil
 }
+`
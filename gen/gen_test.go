@@ -2,7 +2,11 @@ package gen_test
 
 import (
 	"bytes"
+	"encoding/json"
 	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/ekhabarov/jsg/ast"
 	"github.com/ekhabarov/jsg/gen"
@@ -11,14 +15,64 @@ import (
 	. "github.com/onsi/gomega"
 )
 
+var semver, _ = ast.RegisterFormat(ast.FormatDef{
+	Name:   "gen-semver",
+	GoType: "semver.Version",
+	Import: "github.com/Masterminds/semver",
+})
+
+// Formats of different packages with the same name.
+var acmeID, _ = ast.RegisterFormat(ast.FormatDef{
+	Name:   "gen-acme-id",
+	GoType: "id.ID",
+	Import: "github.com/acme/id",
+})
+
+var otherID, _ = ast.RegisterFormat(ast.FormatDef{
+	Name:   "gen-other-id",
+	GoType: "id.ID",
+	Import: "github.com/other/id/v2",
+})
+
+var glob, _ = ast.RegisterFormat(ast.FormatDef{
+	Name:   "gen-glob",
+	GoType: "regexp.Glob",
+	Import: "github.com/acme/regexp",
+})
+
+var unknown = func() ast.StringFormat {
+	s, err := ast.Parse(strings.NewReader(`{"format": "gen-iso-country"}`))
+	if err != nil {
+		panic(err)
+	}
+
+	return s.Format
+}()
+
 var _ = Describe("Gen", func() {
+	yes, no := true, false
+	one, five := uint32(1), uint32(5)
+	zero, one64, two, half, tenth := ast.NewDecimal(0), ast.NewDecimal(1), ast.NewDecimal(2), ast.NewDecimal(150.5), ast.NewDecimal(0.1)
+	cent, _ := ast.ParseDecimal("0.01")
+	enum := func(values ...string) []json.RawMessage {
+		raw := make([]json.RawMessage, 0, len(values))
+		for _, v := range values {
+			raw = append(raw, json.RawMessage(v))
+		}
+
+		return raw
+	}
+	int64Min, _ := ast.ParseDecimal("-9223372036854775808")
+	int64Max, _ := ast.ParseDecimal("9223372036854775807")
+	safe, _ := ast.ParseDecimal("9007199254740993")
+	low, high, byteMax, portMax, big := ast.NewDecimal(-40000), ast.NewDecimal(40000), ast.NewDecimal(255), ast.NewDecimal(65535), ast.NewDecimal(1e19)
 
 	Context("Generate", func() {
 
 		DescribeTable("Call",
-			func(schema ast.Schema, expFile string) {
+			func(schema ast.Schema, expFile string, opts ...gen.Option) {
 				w := bytes.NewBuffer([]byte{})
-				err := gen.Generate(w, &schema)
+				err := gen.Generate(w, &schema, opts...)
 				Expect(err).NotTo(HaveOccurred())
 
 				data, err := ioutil.ReadFile("./testdata/" + expFile + ".golden")
@@ -73,8 +127,703 @@ var _ = Describe("Gen", func() {
 					"Sub":    {Ref: "https://example.com/inner.json"},
 				},
 			}, "struct_with_ref.go"),
+
+			Entry("Custom formats", ast.Schema{
+				ID: "https://example.com/custom_formats.json",
+				Properties: map[string]ast.Schema{
+					"Version": {Type: ast.String, Format: semver},
+					"Country": {Type: ast.String, Format: unknown},
+				},
+			}, "custom_formats.go"),
+
+			Entry("Import aliases", ast.Schema{
+				ID: "https://example.com/aliases.json",
+				Properties: map[string]ast.Schema{
+					"Acme":  {Type: ast.String, Format: acmeID},
+					"Other": {Type: ast.Array, Items: &ast.Schema{Type: ast.String, Format: otherID}},
+					"Glob":  {Type: ast.String, Format: glob},
+					"Code":  {Type: ast.String, Pattern: "^[a-z]+$"},
+				},
+			}, "import_aliases.go"),
+
+			Entry("Vendor extensions", ast.Schema{
+				ID:       "https://example.com/extensions.json",
+				Required: []string{"Code"},
+				Properties: map[string]ast.Schema{
+					"id":       {Type: ast.String, GoName: "ID"},
+					"Code":     {Type: ast.String, MinLength: &one, Pointer: &yes},
+					"Amount":   {Type: ast.Number, GoType: "big.Float", GoPackage: "math/big"},
+					"Price":    {Ref: "#/$defs/money"},
+					"Note":     {Type: ast.String, OmitEmpty: &yes},
+					"Base":     {Ref: "#/$defs/base", Embed: true},
+					"Owner":    {Ref: "#/$defs/person", Pointer: &no},
+					"Internal": {Type: ast.String, Skip: true},
+				},
+				Defs: map[string]ast.Schema{
+					"base": {Type: ast.Object, Properties: map[string]ast.Schema{
+						"Created": {Type: ast.String, Format: ast.FormatDateTime},
+					}},
+					"person": {Type: ast.Object, GoName: "Party", Properties: map[string]ast.Schema{
+						"Name": {Type: ast.String, MaxLength: &five},
+					}},
+					"legacy": {Type: ast.String, Skip: true},
+					"money":  {Type: ast.Number, GoType: "big.Rat", GoPackage: "math/big"},
+				},
+			}, "vendor_extensions.go"),
+
+			Entry("Type mappings", ast.Schema{
+				ID: "https://example.com/payment.json",
+				Properties: map[string]ast.Schema{
+					"ID":      {Type: ast.String, Format: ast.FormatUUID},
+					"Count":   {Type: ast.Integer, Minimum: one64, MultipleOf: two},
+					"Amount":  {Ref: "#/$defs/money"},
+					"Refunds": {Type: ast.Array, Items: &ast.Schema{Ref: "#/$defs/money"}},
+					"Payer":   {Ref: "https://example.com/user.json"},
+					"Source":  {Type: ast.String, Format: ast.FormatIPv4},
+					"Created": {Ref: "#/$defs/timestamp"},
+				},
+				Defs: map[string]ast.Schema{
+					"money":     {Type: ast.Object, Properties: map[string]ast.Schema{"Units": {Type: ast.Integer}}},
+					"timestamp": {Ref: "#/$defs/money"},
+				},
+			}, "type_mappings.go", gen.Mappings(
+				gen.Mapping{Format: "uuid", GoType: "uuid.UUID", Import: "github.com/google/uuid"},
+				gen.Mapping{Format: "ipv4", GoType: "netip.Addr", Import: "net/netip"},
+				gen.Mapping{Type: "integer", GoType: "int64"},
+				gen.Mapping{Pointer: "/$defs/money", GoType: "money.Amount", Import: "example.com/money"},
+				gen.Mapping{Ref: "https://example.com/user.json", GoType: "*users.User", Import: "example.com/users"},
+			)),
+
+			Entry("Integer widths", ast.Schema{
+				ID: "https://example.com/widths.json",
+				Properties: map[string]ast.Schema{
+					"Byte":   {Type: ast.Integer, Minimum: zero, Maximum: byteMax},
+					"Port":   {Type: ast.Integer, Minimum: one64, Maximum: portMax},
+					"Delta":  {Type: ast.Integer, ExclusiveMinimum: low, Maximum: high},
+					"Count":  {Type: ast.Integer, Minimum: zero},
+					"Any":    {Type: ast.Integer},
+					"Huge":   {Type: ast.Integer, Minimum: zero, ExclusiveMaximum: big},
+					"Levels": {Type: ast.Array, Items: &ast.Schema{Type: ast.Integer, Minimum: zero, Maximum: two}},
+					"Mode":   {Type: ast.Integer, Minimum: zero, Maximum: two, Enum: enum("0", "2", "-1")},
+				},
+			}, "integer_widths.go", gen.Integers(gen.BoundedInt)),
+
+			Entry("JSON numbers", ast.Schema{
+				ID:       "https://example.com/json_numbers.json",
+				Required: []string{"Price"},
+				Properties: map[string]ast.Schema{
+					"Price":  {Type: ast.Number, MultipleOf: cent, Maximum: high},
+					"Count":  {Type: ast.Integer, Minimum: one64, Enum: enum("1")},
+					"Limits": {Type: ast.Array, Items: &ast.Schema{Type: ast.Integer, ExclusiveMinimum: zero}},
+				},
+			}, "json_numbers.go", gen.Numbers(gen.JSONNumber)),
+
+			Entry("Exact numbers", ast.Schema{
+				ID:       "https://example.com/exact_numbers.json",
+				Required: []string{"Total"},
+				Properties: map[string]ast.Schema{
+					"Price": {Type: ast.Number, MultipleOf: cent, Minimum: zero},
+					"Ratio": {Type: ast.Number, MultipleOf: ast.NewDecimal(0.25), Maximum: one64},
+					"Total": {Type: ast.Integer, Maximum: big},
+					"Tip":   {Type: ast.Number, Minimum: zero, Optional: &yes},
+					"Fee":   {Type: ast.Number, MultipleOf: cent, Pointer: &yes},
+				},
+			}, "exact_numbers.go", gen.Numbers(gen.BigNumber), gen.Mappings(
+				gen.Mapping{Decimal: true, GoType: "decimal.Decimal", Import: "github.com/shopspring/decimal"},
+			)),
+
+			Entry("Optional fields", ast.Schema{
+				ID:       "https://example.com/patch.json",
+				Optional: &yes,
+				Properties: map[string]ast.Schema{
+					"Name":  {Type: ast.String},
+					"Tags":  {Type: ast.Array},
+					"Sub":   {Ref: "https://example.com/inner.json"},
+					"Total": {Type: ast.Integer, Optional: &no},
+				},
+			}, "optional_fields.go"),
+
+			Entry("Validation", ast.Schema{
+				ID:            "https://example.com/account.json",
+				Required:      []string{"Owner", "Tags", "Note"},
+				MaxProperties: &five,
+				Properties: map[string]ast.Schema{
+					"Name": {
+						Type:      ast.String,
+						MinLength: &one,
+						MaxLength: &five,
+						Pattern:   `^\w+$`,
+					},
+					"Email": {Type: ast.String, Format: ast.FormatEmail},
+					"Age": {
+						Type:             ast.Integer,
+						Minimum:          zero,
+						ExclusiveMaximum: half,
+						MultipleOf:       two,
+					},
+					"Ratio": {Type: ast.Number, Maximum: one64, MultipleOf: tenth},
+					"Tags": {
+						Type:        ast.Array,
+						MinItems:    &one,
+						UniqueItems: true,
+						Items:       &ast.Schema{Type: ast.String, MaxLength: &five},
+					},
+					"Owner":  {Ref: "https://example.com/inner.json"},
+					"Others": {Type: ast.Array, Items: &ast.Schema{Ref: "https://example.com/inner.json"}},
+					"Note":   {Type: ast.String, MinLength: &one, Optional: &yes},
+					"Kind":   {Type: ast.String, Enum: enum(`"basic"`, `"pro"`, "null")},
+					"Level":  {Type: ast.Integer, Enum: enum("1", "2", "2.5")},
+				},
+			}, "validation.go"),
+
+			Entry("Validator tags", ast.Schema{
+				ID:       "https://example.com/tags.json",
+				Required: []string{"Name", "Owner"},
+				Properties: map[string]ast.Schema{
+					"Name":  {Type: ast.String, MinLength: &one, MaxLength: &five},
+					"Email": {Type: ast.String, Format: ast.FormatEmail},
+					"Age":   {Type: ast.Integer, Minimum: zero, ExclusiveMaximum: half},
+					"Kind":  {Type: ast.String, Enum: enum(`"basic"`, `"pro"`)},
+					"Tags": {
+						Type:        ast.Array,
+						MinItems:    &one,
+						UniqueItems: true,
+						Items:       &ast.Schema{Type: ast.String, MaxLength: &five},
+					},
+					"Owner":  {Ref: "https://example.com/inner.json"},
+					"Others": {Type: ast.Array, Items: &ast.Schema{Ref: "https://example.com/inner.json"}},
+					"Note":   {Type: ast.String},
+				},
+			}, "validator_tags.go", gen.ValidatorTags()),
+
+			Entry("Struct tags", ast.Schema{
+				ID:       "https://example.com/person.json",
+				Required: []string{"FirstName"},
+				Properties: map[string]ast.Schema{
+					"FirstName": {Type: ast.String, MinLength: &one},
+					"LastName":  {Type: ast.String},
+					"Nickname":  {Type: ast.String, Optional: &yes},
+					"Password":  {Type: ast.String, Tags: map[string]string{"db": "-", "yaml": "-", "secret": "true"}},
+				},
+			}, "struct_tags.go", gen.Tags(
+				gen.Tag{Key: "json", OmitEmpty: true},
+				gen.Tag{Key: "yaml", Naming: gen.Snake, OmitEmpty: true},
+				gen.Tag{Key: "db", Naming: gen.Snake},
+				gen.Tag{Key: "mapstructure", Naming: gen.Camel, OmitEmpty: true},
+			), gen.ValidatorTags()),
+
+			Entry("Definitions", ast.Schema{
+				ID:       "https://example.com/order.json",
+				Required: []string{"Code"},
+				Properties: map[string]ast.Schema{
+					"Code":    {Ref: "#/$defs/code"},
+					"Address": {Ref: "#/$defs/address"},
+				},
+				Defs: map[string]ast.Schema{
+					"code":  {Type: ast.String, MinLength: &one, Pattern: "^[A-Z]+$"},
+					"codes": {Type: ast.Array, Items: &ast.Schema{Ref: "#/$defs/code"}, MaxItems: &five},
+					"alias": {Ref: "#/$defs/code"},
+					"address": {
+						Type:     ast.Object,
+						Required: []string{"City"},
+						Properties: map[string]ast.Schema{
+							"City": {Type: ast.String, MaxLength: &five},
+						},
+					},
+				},
+			}, "definitions.go", gen.PackageName("orders")),
+		)
+
+		DescribeTable("Errors",
+			func(schema ast.Schema, msg string, opts ...gen.Option) {
+				err := gen.Generate(bytes.NewBuffer([]byte{}), &schema, opts...)
+				Expect(err).To(MatchError(ContainSubstring(msg)))
+			},
+
+			Entry("Invalid mapping", ast.Schema{ID: "https://example.com/errors.json"},
+				`mapping 0: goType "int64" requires import path`,
+				gen.Mappings(gen.Mapping{Type: "integer", GoType: "int64", Import: "math"})),
+
+			Entry("Unsupported x-go-* keyword", ast.Schema{
+				ID: "https://example.com/errors.json",
+				Properties: map[string]ast.Schema{"A": {
+					Type:       ast.String,
+					Extensions: map[string]json.RawMessage{"x-go-nmae": json.RawMessage(`"B"`)},
+				}},
+			}, `/properties/A: unsupported keyword "x-go-nmae"`),
+
+			Entry("x-go-name: unexported", ast.Schema{
+				ID:         "https://example.com/errors.json",
+				Properties: map[string]ast.Schema{"A": {Type: ast.String, GoName: "a"}},
+			}, `/properties/A: x-go-name: "a" isn't an exported Go identifier`),
+
+			Entry("x-go-name: duplicate field", ast.Schema{
+				ID: "https://example.com/errors.json",
+				Properties: map[string]ast.Schema{
+					"A": {Type: ast.String},
+					"B": {Type: ast.String, GoName: "A"},
+				},
+			}, `/properties/B: field A is already generated for property "A"`),
+
+			Entry("duplicate type: root and $defs", ast.Schema{
+				ID:         "https://example.com/errors.json",
+				Properties: map[string]ast.Schema{"A": {Type: ast.String}},
+				Defs:       map[string]ast.Schema{"errors": {Properties: map[string]ast.Schema{"B": {Type: ast.String}}}},
+			}, `/$defs/errors: type Errors is already generated for https://example.com/errors.json`),
+
+			Entry("duplicate type: x-go-name of $defs", ast.Schema{
+				ID: "https://example.com/errors.json",
+				Defs: map[string]ast.Schema{
+					"a": {Type: ast.String},
+					"b": {Type: ast.String, GoName: "A"},
+				},
+			}, `/$defs/b: type A is already generated for /$defs/a`),
+
+			Entry("x-go-type: invalid", ast.Schema{
+				ID:         "https://example.com/errors.json",
+				Properties: map[string]ast.Schema{"A": {Type: ast.String, GoType: "[]"}},
+			}, `/properties/A: x-go-type: invalid Go type "[]"`),
+
+			Entry("x-go-type: no package", ast.Schema{
+				ID:         "https://example.com/errors.json",
+				Properties: map[string]ast.Schema{"A": {Type: ast.String, GoType: "big.Int"}},
+			}, `/properties/A: x-go-type: "big.Int" requires x-go-package`),
+
+			Entry("x-go-package: no type", ast.Schema{
+				ID:         "https://example.com/errors.json",
+				Properties: map[string]ast.Schema{"A": {Type: ast.String, GoPackage: "math/big"}},
+			}, `/properties/A: x-go-package: x-go-type is required`),
+
+			Entry("x-go-embed: slice", ast.Schema{
+				ID:         "https://example.com/errors.json",
+				Properties: map[string]ast.Schema{"A": {Type: ast.Array, Items: &ast.Schema{Type: ast.String}, Embed: true}},
+			}, `/properties/A: x-go-embed: []string can't be embedded`),
+
+			Entry("x-go-embed: optional", ast.Schema{
+				ID:         "https://example.com/errors.json",
+				Properties: map[string]ast.Schema{"A": {Ref: "#/$defs/a", Embed: true, Optional: &yes}},
+			}, `/properties/A: x-go-embed: optional fields can't be embedded`),
+
+			Entry("x-go-optional: wrapper name collision", ast.Schema{
+				ID: "https://example.com/errors.json",
+				Properties: map[string]ast.Schema{
+					"A": {Type: ast.Integer, GoType: "big.Int", GoPackage: "math/big", Optional: &yes},
+					"B": {Type: ast.Integer, GoType: "BigInt", Optional: &yes},
+				},
+			}, `/properties/B: wrapper OptionalBigInt is already generated for big.Int`),
+
+			Entry("pattern: rejected by RE2", ast.Schema{
+				ID:         "https://example.com/errors.json",
+				Properties: map[string]ast.Schema{"A": {Type: ast.String, Pattern: "a**"}},
+			}, `property "A": pattern: `),
+
+			Entry("x-go-tags: invalid key", ast.Schema{
+				ID:         "https://example.com/errors.json",
+				Properties: map[string]ast.Schema{"A": {Type: ast.String, Tags: map[string]string{"a b": "x"}}},
+			}, `/properties/A: x-go-tags: invalid tag key "a b"`),
+
+			Entry("x-go-tags: invalid value", ast.Schema{
+				ID:         "https://example.com/errors.json",
+				Properties: map[string]ast.Schema{"A": {Type: ast.String, Tags: map[string]string{"db": "`"}}},
+			}, `/properties/A: x-go-tags: invalid db tag value "`+"`"+`"`),
+		)
+
+		It("keeps pointers in Optional wrappers of recursive types", func() {
+			out := bytes.NewBuffer([]byte{})
+
+			err := gen.Generate(out, &ast.Schema{
+				ID: "https://example.com/list.json",
+				Defs: map[string]ast.Schema{
+					"Node": {
+						Type:       ast.Object,
+						Properties: map[string]ast.Schema{"Next": {Ref: "#/$defs/Node", Optional: &yes}},
+					},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(out.String()).To(ContainSubstring("Next OptionalNodePtr `json:\"Next,omitzero\"`"))
+			Expect(out.String()).To(ContainSubstring("value *Node\n"))
+		})
+
+		It("checks Optional pointers, which are set to nil", func() {
+			out := bytes.NewBuffer([]byte{})
+
+			err := gen.Generate(out, &ast.Schema{
+				ID:       "https://example.com/patch.json",
+				Optional: &yes,
+				Properties: map[string]ast.Schema{
+					"Code": {Type: ast.String, MinLength: &one, Pointer: &yes},
+					"Sub":  {Ref: "https://example.com/inner.json"},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(out.String()).To(ContainSubstring(
+				"if v.Code.IsSet() && !v.Code.IsNull() && v.Code.Value() != nil {\n\t\tx := *v.Code.Value()\n"))
+			Expect(out.String()).To(ContainSubstring(
+				"x := v.Sub.Value()\n\n\t\tif x != nil {\n\t\t\tif err := x.Validate(); err != nil {"))
+		})
+
+		It("counts fields omitted by encoding/json conditionally", func() {
+			out := bytes.NewBuffer([]byte{})
+
+			err := gen.Generate(out, &ast.Schema{
+				ID:            "https://example.com/sizes.json",
+				Required:      []string{"A"},
+				MinProperties: &five,
+				Properties: map[string]ast.Schema{
+					"A": {Type: ast.String},
+					"B": {Type: ast.Integer},
+					"C": {Type: ast.Array, Items: &ast.Schema{Type: ast.String}},
+					"D": {Ref: "https://example.com/inner.json"},
+					"E": {Type: ast.String, Tags: map[string]string{"json": "-"}},
+					"F": {Type: ast.String, Format: ast.FormatDateTime},
+					"G": {Type: ast.String, OmitEmpty: &no},
+				},
+			}, gen.Tags(gen.Tag{Key: "json", OmitEmpty: true}))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(out.String()).To(ContainSubstring(`	n := 3
+	if v.B != 0 {
+		n++
+	}
+	if len(v.C) > 0 {
+		n++
+	}
+	if v.D != nil {
+		n++
+	}
+
+	if n < 5 {`))
+		})
+
+		It("generates unique names of pattern variables", func() {
+			out := bytes.NewBuffer([]byte{})
+
+			err := gen.Generate(out, &ast.Schema{
+				ID: "https://example.com/names.json",
+				Defs: map[string]ast.Schema{
+					"AB": {Type: ast.Object, Properties: map[string]ast.Schema{"C": {Type: ast.String, Pattern: "^c$"}}},
+					"A":  {Type: ast.Object, Properties: map[string]ast.Schema{"BC": {Type: ast.String, Pattern: "^bc$"}}},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(out.String()).To(ContainSubstring(`var aBCPattern = regexp.MustCompile("^bc$")`))
+			Expect(out.String()).To(ContainSubstring(`var aBCPattern2 = regexp.MustCompile("^c$")`))
+		})
+
+		It("limits validator bounds to the range of field types", func() {
+			huge, _ := ast.ParseDecimal("1e30")
+			tiny, _ := ast.ParseDecimal("-1e30")
+			out := bytes.NewBuffer([]byte{})
+
+			err := gen.Generate(out, &ast.Schema{
+				ID: "https://example.com/limits.json",
+				Properties: map[string]ast.Schema{
+					"A": {Type: ast.Integer, Minimum: tiny, Maximum: huge},
+					"B": {Type: ast.Integer, Minimum: huge},
+					"C": {Type: ast.Integer, ExclusiveMaximum: tiny},
+				},
+			}, gen.ValidatorTags())
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(out.String()).To(ContainSubstring("A int\n"))
+			Expect(out.String()).To(ContainSubstring("B int `validate:\"omitempty,gte=9223372036854775807\"`"))
+			Expect(out.String()).To(ContainSubstring("C int `validate:\"omitempty,lte=-9223372036854775808\"`"))
+		})
+
+		It("keeps bounds as written in the schema", func() {
+			out := bytes.NewBuffer([]byte{})
+
+			err := gen.Generate(out, &ast.Schema{
+				ID: "https://example.com/bounds.json",
+				Properties: map[string]ast.Schema{
+					"C": {Type: ast.Number, Maximum: safe},
+					"N": {Type: ast.Integer, Maximum: safe},
+				},
+			}, gen.Integers(gen.Int64))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(out.String()).To(ContainSubstring("if v.C > 9007199254740993 {"))
+			Expect(out.String()).To(ContainSubstring("if v.N > 9007199254740993 {"))
+		})
+
+		It("reports constraints, which generated code doesn't check", func() {
+			warnings := []string{}
+
+			err := gen.Generate(bytes.NewBuffer([]byte{}), &ast.Schema{
+				ID:            "https://example.com/warnings.json",
+				MinProperties: &one,
+				Properties: map[string]ast.Schema{
+					"Code":  {Type: ast.String, Pattern: "^[A-Z]+$", Enum: enum(`"A B"`)},
+					"Step":  {Type: ast.Number, MultipleOf: tenth, Enum: enum("1")},
+					"Host":  {Type: ast.String, Format: ast.FormatIdnHostname},
+					"Items": {Type: ast.Array, UniqueItems: true},
+					"Patch": {Type: ast.String, MinLength: &one, Optional: &yes},
+				},
+			}, gen.ValidatorTags(), gen.Warnings(func(w gen.Warning) {
+				warnings = append(warnings, w.String())
+			}))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(warnings).To(Equal([]string{
+				`/properties/Code/pattern: no validator tag for regular expressions`,
+				`/properties/Code/enum: value "A B" can't be used in validator tag`,
+				`/properties/Host/format: no validator tag for "idn-hostname" format`,
+				`/properties/Items/uniqueItems: validator compares interface{} items by identity`,
+				`/properties/Patch/x-go-optional: optional fields can't be checked by validator tags`,
+				`/properties/Step/multipleOf: no validator tag for multipleOf`,
+				`/properties/Step/enum: 1 of 1 values can't be used in validator tag for float64`,
+				`/minProperties: no validator tags for number of properties`,
+			}))
+		})
+
+		DescribeTable("reports number constraints, which generated types can't hold or check",
+			func(s ast.Schema, exp []string, opts ...gen.Option) {
+				warnings := []string{}
+
+				err := gen.Generate(bytes.NewBuffer([]byte{}), &s, append(opts, gen.Warnings(func(w gen.Warning) {
+					warnings = append(warnings, w.String())
+				}))...)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(warnings).To(Equal(exp))
+			},
+
+			Entry("int", ast.Schema{
+				ID:         "https://example.com/warnings.json",
+				Properties: map[string]ast.Schema{"A": {Type: ast.Integer, Minimum: zero, ExclusiveMaximum: big}},
+			}, []string{`/properties/A/exclusiveMaximum: 1e+19 is out of int range`}),
+
+			Entry("int64", ast.Schema{
+				ID:         "https://example.com/warnings.json",
+				Properties: map[string]ast.Schema{"A": {Type: ast.Integer, Maximum: big}},
+			}, []string{`/properties/A/maximum: 1e+19 is out of int64 range`}, gen.Integers(gen.Int64)),
+
+			Entry("bounded", ast.Schema{
+				ID: "https://example.com/warnings.json",
+				Defs: map[string]ast.Schema{
+					"a": {Type: ast.Integer, Minimum: low, Maximum: big},
+					"b": {Type: ast.Integer, Minimum: zero, Maximum: big},
+				},
+			}, []string{`/$defs/a/maximum: 1e+19 is out of int64 range`}, gen.Integers(gen.BoundedInt)),
+
+			Entry("exact int64 limits", ast.Schema{
+				ID:         "https://example.com/warnings.json",
+				Properties: map[string]ast.Schema{"A": {Type: ast.Integer, Minimum: int64Min, Maximum: int64Max}},
+			}, []string{}, gen.Integers(gen.BoundedInt)),
+
+			Entry("exact numbers", ast.Schema{
+				ID:         "https://example.com/warnings.json",
+				Properties: map[string]ast.Schema{"A": {Type: ast.Integer, Maximum: big}},
+			}, []string{}, gen.Numbers(gen.BigNumber)),
+
+			Entry("validator tags", ast.Schema{
+				ID:         "https://example.com/warnings.json",
+				Properties: map[string]ast.Schema{"A": {Type: ast.Number, Minimum: zero, MultipleOf: cent}},
+			}, []string{
+				`/properties/A/multipleOf: no validator tag for multipleOf`,
+				`/properties/A/minimum: no validator tag for json.Number bounds`,
+			}, gen.Numbers(gen.JSONNumber), gen.ValidatorTags()),
+
+			Entry("mapped", ast.Schema{
+				ID:         "https://example.com/warnings.json",
+				Properties: map[string]ast.Schema{"A": {Type: ast.Integer, Maximum: big}},
+			}, []string{}, gen.Mappings(gen.Mapping{Type: "integer", GoType: "float64"})),
 		)
 
 	})
 
+	Context("ReadMappings", func() {
+		It("reads mappings", func() {
+			mm, err := gen.ReadMappings(strings.NewReader(`[
+				{"format": "uuid", "goType": "uuid.UUID", "import": "github.com/google/uuid"},
+				{"type": "integer", "goType": "int64"},
+				{"decimal": true, "goType": "decimal.Decimal", "import": "github.com/shopspring/decimal"},
+				{"ref": "https://example.com/money.json", "goType": "money.Amount", "import": "example.com/money"}
+			]`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(mm).To(Equal([]gen.Mapping{
+				{Format: "uuid", GoType: "uuid.UUID", Import: "github.com/google/uuid"},
+				{Type: "integer", GoType: "int64"},
+				{Decimal: true, GoType: "decimal.Decimal", Import: "github.com/shopspring/decimal"},
+				{Ref: "https://example.com/money.json", GoType: "money.Amount", Import: "example.com/money"},
+			}))
+		})
+
+		DescribeTable("Errors",
+			func(data, msg string) {
+				_, err := gen.ReadMappings(strings.NewReader(data))
+				Expect(err).To(MatchError(ContainSubstring(msg)))
+			},
+
+			Entry("Malformed JSON", `{`, "failed to read mappings"),
+			Entry("Unknown field", `[{"kind": "integer", "goType": "int64"}]`, `unknown field "kind"`),
+			Entry("No key", `[{"goType": "int64"}]`, "mapping 0: exactly one of type, format, decimal, ref and pointer is required"),
+			Entry("Two keys", `[{"type": "string", "format": "uuid", "goType": "string"}]`, "exactly one of"),
+			Entry("Decimal and type", `[{"decimal": true, "type": "number", "goType": "float64"}]`, "exactly one of"),
+			Entry("Unknown type", `[{"type": "int", "goType": "int64"}]`, `unknown type "int"`),
+			Entry("Invalid pointer", `[{"pointer": "$defs/a", "goType": "A"}]`, `invalid pointer "$defs/a"`),
+			Entry("No Go type", `[{"type": "integer"}]`, "goType is required"),
+			Entry("No import", `[{"format": "uuid", "goType": "uuid.UUID"}]`, `goType "uuid.UUID" requires import path`),
+		)
+	})
+
+	Context("GenerateAll", func() {
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "jsg")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		files := func() []string {
+			entries, err := ioutil.ReadDir(dir)
+			Expect(err).NotTo(HaveOccurred())
+
+			names := []string{}
+			for _, e := range entries {
+				names = append(names, e.Name())
+			}
+
+			return names
+		}
+
+		schemas := func() []*ast.Schema {
+			return []*ast.Schema{
+				{
+					ID: "https://example.com/user-profile.json",
+					Properties: map[string]ast.Schema{
+						"Name": {Type: ast.String, Optional: &yes},
+						"Home": {Ref: "#/$defs/address"},
+					},
+					Defs: map[string]ast.Schema{
+						"address": {Type: ast.Object, Properties: map[string]ast.Schema{"City": {Type: ast.String}}},
+					},
+				},
+				{
+					ID: "https://example.com/common.json",
+					Defs: map[string]ast.Schema{
+						"email-address": {Type: ast.String, Format: ast.FormatEmail},
+					},
+				},
+			}
+		}
+
+		DescribeTable("Layout",
+			func(layout gen.FileLayout, expected []string) {
+				err := gen.GenerateAll(schemas(), gen.OutputDir(dir), gen.Layout(layout), gen.PackageName("users"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(files()).To(Equal(expected))
+
+				for _, n := range expected {
+					data, err := ioutil.ReadFile(filepath.Join(dir, n))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(data)).To(ContainSubstring("\npackage users\n"))
+				}
+			},
+
+			Entry("File per schema", gen.FilePerSchema, []string{"common.go", "optional.go", "user_profile.go"}),
+			Entry("File per def", gen.FilePerDef, []string{"address.go", "email_address.go", "optional.go", "user_profile.go"}),
+			Entry("Single file", gen.SingleFile, []string{"users.go"}),
+		)
+
+		DescribeTable("Errors",
+			func(schemas []*ast.Schema, msg string, opts ...gen.Option) {
+				err := gen.GenerateAll(schemas, append(opts, gen.OutputDir(dir))...)
+				Expect(err).To(MatchError(ContainSubstring(msg)))
+				Expect(files()).To(BeEmpty())
+			},
+
+			Entry("Invalid package name", schemas(), `invalid package name "my-users"`, gen.PackageName("my-users")),
+			Entry("Schema without ID", append(schemas(), &ast.Schema{}), "schema has no $id"),
+			Entry("Duplicate type", append(schemas(), &ast.Schema{
+				ID:   "https://example.com/other.json",
+				Defs: map[string]ast.Schema{"address": {Type: ast.String}},
+			}), "type Address is already generated from https://example.com/user-profile.json"),
+			Entry("Duplicate file", append(schemas(), &ast.Schema{
+				ID:   "https://example.com/v2/common.json",
+				Defs: map[string]ast.Schema{"phone": {Type: ast.String}},
+			}), "file common.go is already generated for https://example.com/common.json"),
+			Entry("Invalid type", append(schemas(), &ast.Schema{
+				ID:         "https://example.com/broken.json",
+				Properties: map[string]ast.Schema{"A": {Type: ast.String, Tags: map[string]string{"a b": "x"}}},
+			}), "x-go-tags: invalid tag key"),
+		)
+
+		It("escapes file names, which go build ignores or constrains", func() {
+			schemas := []*ast.Schema{
+				{ID: "https://example.com/user_test.json", Defs: map[string]ast.Schema{"a": {Type: ast.String}}},
+				{ID: "https://example.com/platform-linux.json", Defs: map[string]ast.Schema{"b": {Type: ast.String}}},
+				{ID: "https://example.com/cpu-arm.json", Defs: map[string]ast.Schema{"c": {Type: ast.String}}},
+				{ID: "https://example.com/linux.json", Defs: map[string]ast.Schema{"e": {Type: ast.String}}},
+			}
+
+			Expect(gen.GenerateAll(schemas, gen.OutputDir(dir))).To(Succeed())
+			Expect(files()).To(Equal([]string{
+				"cpu_arm_gen.go", "linux.go", "platform_linux_gen.go", "user_test_gen.go",
+			}))
+
+			Expect(os.RemoveAll(dir)).To(Succeed())
+			Expect(gen.GenerateAll(schemas, gen.OutputDir(dir), gen.Layout(gen.SingleFile), gen.PackageName("_test"))).To(Succeed())
+			Expect(files()).To(Equal([]string{"x_test_gen.go"}))
+		})
+
+		It("imports packages where they're used", func() {
+			date := []*ast.Schema{{
+				ID: "https://example.com/event.json",
+				Properties: map[string]ast.Schema{
+					"At": {Type: ast.String, Format: ast.FormatDateTime, Optional: &yes},
+				},
+			}}
+
+			Expect(gen.GenerateAll(date, gen.OutputDir(dir))).To(Succeed())
+
+			event, err := ioutil.ReadFile(filepath.Join(dir, "event.go"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(event)).NotTo(ContainSubstring(`"time"`))
+
+			optional, err := ioutil.ReadFile(filepath.Join(dir, "optional.go"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(optional)).To(ContainSubstring("import (\n\t\"encoding/json\"\n\t\"time\"\n)"))
+		})
+
+		It("skips schemas mapped to existing types", func() {
+			err := gen.GenerateAll(schemas(), gen.OutputDir(dir), gen.Mappings(
+				gen.Mapping{Pointer: "/$defs/email-address", GoType: "mail.Address", Import: "net/mail"},
+				gen.Mapping{Ref: "https://example.com/user-profile.json#/$defs/address", GoType: "geo.Address", Import: "example.com/geo"},
+			))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(files()).To(Equal([]string{"optional.go", "user_profile.go"}))
+
+			data, err := ioutil.ReadFile(filepath.Join(dir, "user_profile.go"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).To(ContainSubstring("Home geo.Address"))
+			Expect(string(data)).NotTo(ContainSubstring("type Address"))
+		})
+
+		It("keeps existing files on failure", func() {
+			Expect(gen.GenerateAll(schemas(), gen.OutputDir(dir))).To(Succeed())
+
+			before, err := ioutil.ReadFile(filepath.Join(dir, "common.go"))
+			Expect(err).NotTo(HaveOccurred())
+
+			broken := append(schemas(), &ast.Schema{})
+			broken[1].Defs["phone"] = ast.Schema{Type: ast.String}
+			Expect(gen.GenerateAll(broken, gen.OutputDir(dir))).NotTo(Succeed())
+
+			after, err := ioutil.ReadFile(filepath.Join(dir, "common.go"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(after).To(Equal(before))
+			Expect(files()).To(Equal([]string{"common.go", "optional.go", "user_profile.go"}))
+		})
+	})
+
 })
@@ -0,0 +1,229 @@
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	goast "go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// reserved maps names of packages used by generated code itself to their
+// import paths. Other packages with these names are always aliased.
+var reserved = map[string]string{
+	"formats": formatsImport,
+	"json":    "encoding/json",
+	"math":    "math",
+	"reflect": "reflect",
+	"regexp":  "regexp",
+	"rt":      rtImport,
+	"strconv": "strconv",
+	"utf8":    "unicode/utf8",
+}
+
+// version matches major version elements of import paths, e.g. "v2".
+var version = regexp.MustCompile(`^v[0-9]+$`)
+
+// imports maps import paths of a file to names packages are referred by.
+type imports map[string]string
+
+// use adds package path, which is referred as n, and returns a name of the
+// package within the file. Name differs from n if it's taken by another
+// package.
+func (im imports) use(path, n string) string {
+	if name, ok := im[path]; ok {
+		return name
+	}
+
+	name := n
+	if im.taken(name, path) {
+		name = alias(path, n)
+	}
+
+	for i := 2; im.taken(name, path); i++ {
+		name = n + strconv.Itoa(i)
+	}
+
+	im[path] = name
+
+	return name
+}
+
+// taken reports whether name is used by a package other than path.
+func (im imports) taken(name, path string) bool {
+	if p, ok := reserved[name]; ok && p != path {
+		return true
+	}
+
+	for p, n := range im {
+		if n == name && p != path {
+			return true
+		}
+	}
+
+	return false
+}
+
+// qualify adds an import of package path, which Go type t belongs to, e.g.
+// *uuid.UUID, and returns t qualified by the package name within the file.
+func (im imports) qualify(t, path string) string {
+	i := strings.LastIndexAny(t, "*]") + 1
+	prefix, name := t[:i], t[i:]
+
+	dot := strings.Index(name, ".")
+	if dot < 0 {
+		im.use(path, base(path))
+
+		return t
+	}
+
+	return prefix + im.use(path, name[:dot]) + name[dot:]
+}
+
+// adopt returns Go type t taken from a file with imports from, qualified by
+// package name within im.
+func (im imports) adopt(t string, from imports) string {
+	i := strings.LastIndexAny(t, "*]") + 1
+
+	dot := strings.Index(t[i:], ".")
+	if dot < 0 {
+		return t
+	}
+
+	for p, n := range from {
+		if n == t[i:i+dot] {
+			return im.qualify(t, p)
+		}
+	}
+
+	return t
+}
+
+// alias returns an alias of package n imported from path made of its parent
+// path element, e.g. "github.com/acme/types" => "acmetypes".
+func alias(path, n string) string {
+	el := strings.Split(path, "/")
+	if len(el) > 1 && version.MatchString(el[len(el)-1]) {
+		el = el[:len(el)-1]
+	}
+
+	if len(el) < 2 {
+		return n
+	}
+
+	parent := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			return r
+		}
+
+		return -1
+	}, strings.ToLower(el[len(el)-2]))
+
+	return parent + n
+}
+
+// base returns a default package name of import path, i.e. its last element
+// except major version one.
+func base(path string) string {
+	el := strings.Split(path, "/")
+	if len(el) > 1 && version.MatchString(el[len(el)-1]) {
+		return el[len(el)-2]
+	}
+
+	return el[len(el)-1]
+}
+
+// writeImports writes import declaration of packages referred in code, which
+// is a Go source without package clause and imports. Standard library packages
+// go first, then others.
+func writeImports(w io.Writer, im imports, code []byte) error {
+	used, err := qualifiers(code)
+	if err != nil {
+		return err
+	}
+
+	std, other := []string{}, []string{}
+
+	for p, n := range im {
+		if !used[n] {
+			continue
+		}
+
+		spec := strconv.Quote(p)
+		if n != base(p) {
+			spec = n + " " + spec
+		}
+
+		if strings.Contains(strings.Split(p, "/")[0], ".") {
+			other = append(other, spec)
+		} else {
+			std = append(std, spec)
+		}
+	}
+
+	sort.Slice(std, func(i, j int) bool { return path(std[i]) < path(std[j]) })
+	sort.Slice(other, func(i, j int) bool { return path(other[i]) < path(other[j]) })
+
+	switch {
+	case len(std)+len(other) == 0:
+		return nil
+	case len(std)+len(other) == 1:
+		fmt.Fprintf(w, "\nimport %s\n", strings.Join(append(std, other...), ""))
+
+		return nil
+	}
+
+	fmt.Fprintln(w, "\nimport (")
+
+	for _, spec := range std {
+		fmt.Fprintf(w, "%s\n", spec)
+	}
+
+	if len(std) > 0 && len(other) > 0 {
+		fmt.Fprintln(w)
+	}
+
+	for _, spec := range other {
+		fmt.Fprintf(w, "%s\n", spec)
+	}
+
+	fmt.Fprintln(w, ")")
+
+	return nil
+}
+
+// path returns an import path of import spec.
+func path(spec string) string {
+	return spec[strings.Index(spec, `"`):]
+}
+
+// qualifiers returns names of packages referred in code.
+func qualifiers(code []byte) (map[string]bool, error) {
+	src := bytes.NewBufferString("package p\n")
+	src.Write(code)
+
+	f, err := parser.ParseFile(token.NewFileSet(), "", src.Bytes(), 0)
+	if err != nil {
+		return nil, fmt.Errorf("gofmt failed: %w", err)
+	}
+
+	used := map[string]bool{}
+
+	goast.Inspect(f, func(n goast.Node) bool {
+		if s, ok := n.(*goast.SelectorExpr); ok {
+			// Local variables are resolved by parser, package names aren't.
+			if id, ok := s.X.(*goast.Ident); ok && id.Obj == nil {
+				used[id.Name] = true
+			}
+		}
+
+		return true
+	})
+
+	return used, nil
+}
@@ -0,0 +1,230 @@
+package gen
+
+import (
+	"math/big"
+
+	"github.com/ekhabarov/jsg/ast"
+)
+
+// intRange is a range of values of a sized integer type.
+type intRange struct {
+	min, max *big.Rat
+}
+
+func signed(bits uint) intRange {
+	end := new(big.Int).Lsh(big.NewInt(1), bits-1)
+
+	return intRange{
+		min: new(big.Rat).SetInt(new(big.Int).Neg(end)),
+		max: new(big.Rat).SetInt(end.Sub(end, big.NewInt(1))),
+	}
+}
+
+func unsigned(bits uint) intRange {
+	end := new(big.Int).Lsh(big.NewInt(1), bits)
+
+	return intRange{
+		min: new(big.Rat),
+		max: new(big.Rat).SetInt(end.Sub(end, big.NewInt(1))),
+	}
+}
+
+// sized are sized integer types from the narrowest one.
+var sized = []struct {
+	name string
+	intRange
+}{
+	{"uint8", unsigned(8)},
+	{"uint16", unsigned(16)},
+	{"uint32", unsigned(32)},
+	{"uint64", unsigned(64)},
+	{"int8", signed(8)},
+	{"int16", signed(16)},
+	{"int32", signed(32)},
+	{"int64", signed(64)},
+}
+
+// rangeOf returns a range of sized integer type t.
+func rangeOf(t string) (intRange, bool) {
+	for _, s := range sized {
+		if s.name == t {
+			return s.intRange, true
+		}
+	}
+
+	return intRange{}, false
+}
+
+// contains reports whether r is in the range, nil r is infinite.
+func (ir intRange) contains(r *big.Rat) bool {
+	return r != nil && r.Cmp(ir.min) >= 0 && r.Cmp(ir.max) <= 0
+}
+
+// numberType returns a Go type of number or integer schema s and its import
+// path, if it's chosen by Numbers.
+func (c *Config) numberType(s *ast.Schema) (string, string) {
+	if s.Type != ast.Number && s.Type != ast.Integer {
+		return "", ""
+	}
+
+	switch {
+	case c.Numbers == JSONNumber:
+		return "json.Number", "encoding/json"
+	case c.Numbers == BigNumber && s.Type == ast.Integer:
+		return "*big.Int", "math/big"
+	case c.Numbers == BigNumber:
+		return "rt.BigFloat", rtImport
+	}
+
+	return "", ""
+}
+
+// integerType returns a Go type of integer schema s.
+func (c *Config) integerType(s *ast.Schema) string {
+	switch c.Integers {
+	case Int64:
+		return "int64"
+	case BoundedInt:
+	default:
+		return "int"
+	}
+
+	lo, hi := limits(s)
+
+	for _, t := range sized {
+		if t.contains(lo) && t.contains(hi) {
+			return t.name
+		}
+	}
+
+	if lo != nil && lo.Sign() >= 0 {
+		return "uint64"
+	}
+
+	return "int64"
+}
+
+// limits returns the lowest and the highest integers allowed by bounds of
+// schema s, which are nil if there is no bound.
+func limits(s *ast.Schema) (*big.Rat, *big.Rat) {
+	var lo, hi *big.Rat
+
+	for _, b := range bounds(s) {
+		switch b.keyword {
+		case "minimum", "exclusiveMinimum":
+			if lo == nil || b.value.Cmp(lo) > 0 {
+				lo = b.value
+			}
+		default:
+			if hi == nil || b.value.Cmp(hi) < 0 {
+				hi = b.value
+			}
+		}
+	}
+
+	return lo, hi
+}
+
+// bound is the lowest or the highest integer allowed by keyword.
+type bound struct {
+	keyword string
+	value   *big.Rat
+	// lit is the keyword value as written in the schema.
+	lit *ast.Decimal
+}
+
+// bounds returns integer bounds of schema s.
+func bounds(s *ast.Schema) []bound {
+	bb := []bound{}
+	one := big.NewRat(1, 1)
+
+	if s.Minimum != nil {
+		bb = append(bb, bound{"minimum", ceil(s.Minimum.Rat()), s.Minimum})
+	}
+
+	if s.ExclusiveMinimum != nil {
+		v := floor(s.ExclusiveMinimum.Rat())
+		bb = append(bb, bound{"exclusiveMinimum", v.Add(v, one), s.ExclusiveMinimum})
+	}
+
+	if s.Maximum != nil {
+		bb = append(bb, bound{"maximum", floor(s.Maximum.Rat()), s.Maximum})
+	}
+
+	if s.ExclusiveMaximum != nil {
+		v := ceil(s.ExclusiveMaximum.Rat())
+		bb = append(bb, bound{"exclusiveMaximum", v.Sub(v, one), s.ExclusiveMaximum})
+	}
+
+	return bb
+}
+
+// floor returns the greatest integer less than or equal to r.
+func floor(r *big.Rat) *big.Rat {
+	// Euclidean division rounds down for positive divisors.
+	return new(big.Rat).SetInt(new(big.Int).Div(r.Num(), r.Denom()))
+}
+
+// ceil returns the least integer greater than or equal to r.
+func ceil(r *big.Rat) *big.Rat {
+	f := floor(new(big.Rat).Neg(r))
+
+	return f.Neg(f)
+}
+
+// checkBounds returns a function, which warns about bounds of integer
+// schemas within schema id, which don't fit generated Go types. Type int is
+// considered 64-bit.
+func (c *Config) checkBounds(id string) ast.WalkFunc {
+	return func(ptr string, s *ast.Schema) error {
+		if s.Type != ast.Integer || s.Skip || c.Numbers != Float || c.mapping(id, ptr, s) != nil {
+			return nil
+		}
+
+		t := c.integerType(s)
+
+		r, ok := rangeOf(t)
+		if !ok {
+			r = signed(64)
+		}
+
+		for _, b := range bounds(s) {
+			if !r.contains(b.value) {
+				c.warn(ptr, b.keyword, "%s is out of %s range", b.lit, t)
+			}
+		}
+
+		return nil
+	}
+}
+
+// fits reports whether r can be used as a constant of integer Go type t.
+func fits(t string, r *big.Rat) bool {
+	if ir, ok := rangeOf(t); ok {
+		return r.IsInt() && ir.contains(r)
+	}
+
+	return integer(t) && whole(r) && (t != "uint" || r.Sign() >= 0)
+}
+
+// implied reports whether values of integer Go type t always satisfy bound
+// v of keyword, e.g. minimum 0 of uint8.
+func implied(t, keyword string, v *big.Rat) bool {
+	ir, ok := rangeOf(t)
+	if !ok {
+		return false
+	}
+
+	switch keyword {
+	case "minimum":
+		return v.Cmp(ir.min) <= 0
+	case "exclusiveMinimum":
+		return v.Cmp(ir.min) < 0
+	case "maximum":
+		return v.Cmp(ir.max) >= 0
+	case "exclusiveMaximum":
+		return v.Cmp(ir.max) > 0
+	}
+
+	return false
+}
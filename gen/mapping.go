@@ -0,0 +1,295 @@
+package gen
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/ekhabarov/jsg/ast"
+	"github.com/ekhabarov/jsg/lib"
+)
+
+// typeNames maps schema type names to types.
+var typeNames = map[string]ast.SchemaType{
+	"string":  ast.String,
+	"number":  ast.Number,
+	"integer": ast.Integer,
+	"object":  ast.Object,
+	"array":   ast.Array,
+	"boolean": ast.Boolean,
+	"null":    ast.Null,
+}
+
+// Mapping overrides a Go type generated for schemas matching its key: Type,
+// Format, Decimal, Ref or Pointer, exactly one of them is set. Schemas mapped
+// by Ref or Pointer aren't generated, e.g. a $defs entry mapped to a type of
+// another package.
+type Mapping struct {
+	// Type is a schema type, e.g. "integer".
+	Type string `json:"type,omitempty"`
+	// Format is a string format, e.g. "uuid".
+	Format string `json:"format,omitempty"`
+	// Decimal matches numbers with multipleOf, which float64 can't represent
+	// exactly, e.g. 0.01 of money amounts.
+	Decimal bool `json:"decimal,omitempty"`
+	// Ref is an URI of a schema or its subschema, e.g.
+	// "https://example.com/money.json" or
+	// "https://example.com/order.json#/$defs/money". It matches the schema
+	// and every $ref pointing to it. URI with fragment only, e.g.
+	// "#/$defs/money", matches subschemas of every generated schema.
+	Ref string `json:"ref,omitempty"`
+	// Pointer is a JSON pointer of a subschema, e.g. "/$defs/money", it's the
+	// same as Ref with fragment only.
+	Pointer string `json:"pointer,omitempty"`
+	// GoType is a Go type, e.g. "uuid.UUID" or "*money.Amount".
+	GoType string `json:"goType"`
+	// Import is an import path of a package with GoType, if necessary.
+	Import string `json:"import,omitempty"`
+}
+
+// Mappings adds Go type mappings. Ref and Pointer mappings take precedence
+// over Format ones, then Decimal and Type ones go.
+func Mappings(m ...Mapping) Option {
+	return func(c *Config) {
+		c.Mappings = append(c.Mappings, m...)
+	}
+}
+
+// ReadMappings reads a JSON array of mappings, e.g.
+//
+//	[
+//		{"format": "uuid", "goType": "uuid.UUID", "import": "github.com/google/uuid"},
+//		{"decimal": true, "goType": "decimal.Decimal", "import": "github.com/shopspring/decimal"},
+//		{"type": "integer", "goType": "int64"}
+//	]
+func ReadMappings(r io.Reader) ([]Mapping, error) {
+	d := json.NewDecoder(r)
+	d.DisallowUnknownFields()
+
+	mm := []Mapping{}
+	if err := d.Decode(&mm); err != nil {
+		return nil, fmt.Errorf("failed to read mappings: %w", err)
+	}
+
+	for i, m := range mm {
+		if err := m.validate(); err != nil {
+			return nil, fmt.Errorf("mapping %d: %w", i, err)
+		}
+	}
+
+	return mm, nil
+}
+
+func (m Mapping) validate() error {
+	keys := 0
+	for _, k := range []string{m.Type, m.Format, m.Ref, m.Pointer} {
+		if k != "" {
+			keys++
+		}
+	}
+
+	if m.Decimal {
+		keys++
+	}
+
+	if keys != 1 {
+		return errors.New("exactly one of type, format, decimal, ref and pointer is required")
+	}
+
+	if _, ok := typeNames[m.Type]; m.Type != "" && !ok {
+		return fmt.Errorf("unknown type %q", m.Type)
+	}
+
+	if m.Pointer != "" && !strings.HasPrefix(m.Pointer, "/") {
+		return fmt.Errorf("invalid pointer %q", m.Pointer)
+	}
+
+	if m.Ref != "" {
+		if _, err := url.Parse(m.Ref); err != nil {
+			return fmt.Errorf("invalid ref: %w", err)
+		}
+	}
+
+	if m.GoType == "" {
+		return errors.New("goType is required")
+	}
+
+	if qualified := strings.Contains(m.GoType, "."); qualified != (m.Import != "") {
+		return fmt.Errorf("goType %q requires import path, if and only if it's qualified", m.GoType)
+	}
+
+	return nil
+}
+
+// uri returns base and fragment of the mapping URI.
+func (m Mapping) uri() (string, string) {
+	if m.Pointer != "" {
+		return "", m.Pointer
+	}
+
+	return split(m.Ref)
+}
+
+// mapping returns a mapping of schema s located at ptr within schema id, or
+// nil if its Go type isn't overridden. Mapping made of x-go-type of the
+// schema takes precedence over configured ones.
+func (c *Config) mapping(id, ptr string, s *ast.Schema) *Mapping {
+	if s.GoType != "" {
+		return &Mapping{Pointer: ptr, GoType: s.GoType, Import: s.GoPackage}
+	}
+
+	if m := c.located(id, ptr, s.Ref); m != nil {
+		return m
+	}
+
+	if s.Format != "" {
+		for i, m := range c.Mappings {
+			if m.Format != "" && m.Format == s.Format.Name() {
+				return &c.Mappings[i]
+			}
+		}
+	}
+
+	if decimal(s) {
+		for i, m := range c.Mappings {
+			if m.Decimal {
+				return &c.Mappings[i]
+			}
+		}
+	}
+
+	for i, m := range c.Mappings {
+		if t, ok := typeNames[m.Type]; ok && t == s.Type {
+			return &c.Mappings[i]
+		}
+	}
+
+	return nil
+}
+
+// decimal reports whether number schema s has multipleOf, which float64
+// can't represent exactly, i.e. its denominator isn't a power of two.
+func decimal(s *ast.Schema) bool {
+	if s.Type != ast.Number || s.MultipleOf == nil {
+		return false
+	}
+
+	d := s.MultipleOf.Rat().Denom()
+
+	return d.BitLen() != int(d.TrailingZeroBits())+1
+}
+
+// located returns Ref or Pointer mapping of a schema located at ptr within
+// schema id, which may refer to another schema with ref. References to $defs
+// entries, which are references themselves, are followed.
+func (c *Config) located(id, ptr, ref string) *Mapping {
+	locations := [][2]string{{id, ptr}}
+
+	for i := 0; ref != "" && i < maxRefs; i++ {
+		b, f, ok := resolve(id, ref)
+		if !ok {
+			break
+		}
+
+		locations = append(locations, [2]string{b, f})
+
+		t := c.schema(b, f)
+		if t == nil || t.Type != 0 || t.GoType != "" {
+			break
+		}
+
+		id, ref = b, t.Ref
+	}
+
+	for _, l := range locations {
+		if t := c.schema(l[0], l[1]); t != nil && t.GoType != "" {
+			return &Mapping{Ref: l[0] + "#" + l[1], GoType: t.GoType, Import: t.GoPackage}
+		}
+
+		for i, m := range c.Mappings {
+			if m.Ref == "" && m.Pointer == "" {
+				continue
+			}
+
+			if b, f := m.uri(); (b == "" || b == l[0]) && f == l[1] {
+				return &c.Mappings[i]
+			}
+		}
+	}
+
+	return nil
+}
+
+// target returns a generated schema or its $defs entry, which ref found in
+// schema id refers to, or nil.
+func (c *Config) target(id, ref string) *ast.Schema {
+	b, f, ok := resolve(id, ref)
+	if !ok {
+		return nil
+	}
+
+	return c.schema(b, f)
+}
+
+// resolve resolves ref against base URI id, and returns base and fragment of
+// the result.
+func resolve(id, ref string) (string, string, bool) {
+	base, err := url.Parse(id)
+	if err != nil {
+		return "", "", false
+	}
+
+	r, err := url.Parse(ref)
+	if err != nil {
+		return "", "", false
+	}
+
+	b, f := split(base.ResolveReference(r).String())
+
+	return b, f, true
+}
+
+// maxRefs limits a chain of references followed by located.
+const maxRefs = 16
+
+// schema returns a generated schema or its $defs entry located at ptr, or
+// nil.
+func (c *Config) schema(id, ptr string) *ast.Schema {
+	s, ok := c.schemas[id]
+	if !ok {
+		return nil
+	}
+
+	if ptr == "" {
+		return s
+	}
+
+	if !strings.HasPrefix(ptr, "/$defs/") {
+		return nil
+	}
+
+	d, ok := s.Defs[lib.UnescapePointer(strings.TrimPrefix(ptr, "/$defs/"))]
+	if !ok {
+		return nil
+	}
+
+	return &d
+}
+
+// split splits URI into base and fragment.
+func split(uri string) (string, string) {
+	i := strings.Index(uri, "#")
+	if i < 0 {
+		return uri, ""
+	}
+
+	f, err := url.PathUnescape(uri[i+1:])
+	if err != nil {
+		f = uri[i+1:]
+	}
+
+	return uri[:i], f
+}
@@ -0,0 +1,297 @@
+package gen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ekhabarov/jsg/ast"
+)
+
+// formatTags maps formats to github.com/go-playground/validator tags. Formats
+// with specific Go types, like uuid.UUID, are checked by the type itself.
+var formatTags = map[ast.StringFormat]string{
+	ast.FormatEmail:    "email",
+	ast.FormatHostname: "hostname_rfc1123",
+	ast.FormatURI:      "uri",
+	ast.FormatUUID:     "uuid",
+	ast.FormatIPv4:     "ipv4",
+	ast.FormatIPv6:     "ipv6",
+}
+
+// fieldTags returns struct tags of a field generated for property n, which
+// is required or optional, i.e. wrapped into Optional type. Tags configured
+// for all fields go first, then x-go-tags ones.
+func fieldTags(c *Config, n string, p *ast.Schema, required, optional bool) ([]string, error) {
+	keys := []string{}
+	values := map[string]string{}
+
+	set := func(k, v string) {
+		if _, ok := values[k]; !ok {
+			keys = append(keys, k)
+		}
+
+		values[k] = v
+	}
+
+	for _, t := range c.Tags {
+		omit := t.OmitEmpty && !required
+		if p.OmitEmpty != nil {
+			omit = *p.OmitEmpty
+		}
+
+		v := t.Naming.apply(n)
+		if omit {
+			v += ",omitempty"
+		}
+
+		set(t.Key, v)
+	}
+
+	// Renamed fields keep property names in JSON.
+	_, ok := values["json"]
+	if !ok && (fieldName(n, p) != n || p.OmitEmpty != nil && *p.OmitEmpty) {
+		v := n
+		if p.OmitEmpty != nil && *p.OmitEmpty {
+			v += ",omitempty"
+		}
+
+		set("json", v)
+	}
+
+	// Absent optional value must be omitted, otherwise it becomes null.
+	if optional {
+		v, ok := values["json"]
+		if !ok {
+			v = n
+		}
+
+		set("json", strings.TrimSuffix(v, ",omitempty")+",omitzero")
+	}
+
+	overrides := make([]string, 0, len(p.Tags))
+	for k := range p.Tags {
+		overrides = append(overrides, k)
+	}
+
+	sort.Strings(overrides)
+
+	for _, k := range overrides {
+		if k == "" || strings.ContainsAny(k, " \t:\"`") {
+			return nil, fmt.Errorf("x-go-tags: invalid tag key %q", k)
+		}
+
+		if strings.ContainsAny(p.Tags[k], "\"`") {
+			return nil, fmt.Errorf("x-go-tags: invalid %s tag value %q", k, p.Tags[k])
+		}
+
+		set(k, p.Tags[k])
+	}
+
+	tags := make([]string, 0, len(keys))
+	for _, k := range keys {
+		tags = append(tags, fmt.Sprintf("%s:\"%s\"", k, values[k]))
+	}
+
+	return tags, nil
+}
+
+// validatorTag returns `validate` tag value for a field of Go type t generated
+// from schema s located at ptr.
+func validatorTag(c *Config, ptr, t string, s *ast.Schema, required bool) string {
+	tags := []string{}
+
+	// Validator's required rejects zero values, e.g. 0 or "", which are valid
+	// JSON values, so only absence of nillable ones is checked.
+	if required && nillable(t) {
+		tags = append(tags, "required")
+	}
+
+	r := rules(c, ptr, strings.TrimPrefix(t, "*"), s)
+	if len(r) > 0 && !required {
+		tags = append(tags, "omitempty")
+	}
+
+	return strings.Join(append(tags, r...), ",")
+}
+
+// rules returns validator rules for a value of Go type t.
+func rules(c *Config, ptr, t string, s *ast.Schema) []string {
+	r := []string{}
+
+	switch {
+	case s.Type == 0 && s.Ref != "":
+		// Nested structs are validated by their own tags.
+	case t == "string":
+		if s.MinLength != nil {
+			r = append(r, fmt.Sprintf("min=%d", *s.MinLength))
+		}
+
+		if s.MaxLength != nil {
+			r = append(r, fmt.Sprintf("max=%d", *s.MaxLength))
+		}
+
+		if s.Pattern != "" {
+			c.warn(ptr, "pattern", "no validator tag for regular expressions")
+		}
+
+		if f, ok := formatTags[s.Format]; ok {
+			r = append(r, f)
+		} else if s.Format.Known() {
+			c.warn(ptr, "format", "no validator tag for %q format", s.Format.Name())
+		}
+
+		r = append(r, oneof(c, ptr, t, s)...)
+	case integer(t) || float(t):
+		bounds := []struct {
+			tag     string
+			keyword string
+			value   *ast.Decimal
+		}{
+			{"gte", "minimum", s.Minimum},
+			{"gt", "exclusiveMinimum", s.ExclusiveMinimum},
+			{"lte", "maximum", s.Maximum},
+			{"lt", "exclusiveMaximum", s.ExclusiveMaximum},
+		}
+
+		// Validator parses parameters of int fields as 64-bit integers, so
+		// bounds are limited to the range of the field type, where int and
+		// uint are considered 64-bit.
+		ir, ok := rangeOf(t)
+		if !ok {
+			ir = signed(64)
+			if t == "uint" {
+				ir = unsigned(64)
+			}
+		}
+
+		for _, b := range bounds {
+			if b.value == nil || implied(t, b.keyword, b.value.Rat()) {
+				continue
+			}
+
+			v := b.value.Rat()
+
+			if !integer(t) {
+				r = append(r, b.tag+"="+b.value.String())
+
+				continue
+			}
+
+			lower := b.tag == "gte" || b.tag == "gt"
+
+			// Validator parses parameters of int fields as integers, so
+			// fractional bounds are rounded to the nearest integer inside.
+			tag := b.tag
+			switch {
+			case v.IsInt():
+			case lower:
+				tag, v = "gte", ceil(v)
+			default:
+				tag, v = "lte", floor(v)
+			}
+
+			// Bounds beyond the range are either implied by the type, or
+			// leave the value at the range end only.
+			switch {
+			case lower && v.Cmp(ir.min) < 0, !lower && v.Cmp(ir.max) > 0:
+				continue
+			case lower && v.Cmp(ir.max) > 0:
+				tag, v = "gte", ir.max
+			case !lower && v.Cmp(ir.min) < 0:
+				tag, v = "lte", ir.min
+			}
+
+			r = append(r, tag+"="+v.RatString())
+		}
+
+		if s.MultipleOf != nil {
+			c.warn(ptr, "multipleOf", "no validator tag for multipleOf")
+		}
+
+		r = append(r, oneof(c, ptr, t, s)...)
+	case strings.HasPrefix(t, "[]"):
+		elem := strings.TrimPrefix(t, "[]")
+
+		if s.MinItems != nil {
+			r = append(r, fmt.Sprintf("min=%d", *s.MinItems))
+		}
+
+		if s.MaxItems != nil {
+			r = append(r, fmt.Sprintf("max=%d", *s.MaxItems))
+		}
+
+		if s.UniqueItems {
+			if comparable(elem) {
+				r = append(r, "unique")
+			} else {
+				c.warn(ptr, "uniqueItems", "validator compares %s items by identity", elem)
+			}
+		}
+
+		if s.Items != nil {
+			if ir := rules(c, ptr+"/items", elem, s.Items); len(ir) > 0 || s.Items.Ref != "" {
+				r = append(append(r, "dive"), ir...)
+			}
+		}
+	default:
+		if s.Enum != nil {
+			c.warn(ptr, "enum", "no validator tag for %s enum", t)
+		}
+
+		if !exact(t, s) {
+			break
+		}
+
+		if s.MultipleOf != nil {
+			c.warn(ptr, "multipleOf", "no validator tag for multipleOf")
+		}
+
+		for _, b := range bounds(s) {
+			c.warn(ptr, b.keyword, "no validator tag for %s bounds", t)
+		}
+	}
+
+	return r
+}
+
+// oneof returns oneof rule for enum of s. Only strings and integers are
+// supported by the rule.
+func oneof(c *Config, ptr, t string, s *ast.Schema) []string {
+	if s.Enum == nil {
+		return nil
+	}
+
+	values := []string{}
+
+	for _, e := range s.Enum {
+		switch v := scalar(e).(type) {
+		case string:
+			if t != "string" {
+				continue
+			}
+
+			if v == "" || strings.ContainsAny(v, " ,|'\"\\`") {
+				c.warn(ptr, "enum", "value %q can't be used in validator tag", v)
+
+				return nil
+			}
+
+			values = append(values, v)
+		case *ast.Decimal:
+			if r := v.Rat(); fits(t, r) {
+				values = append(values, r.RatString())
+			}
+		}
+	}
+
+	if n := len(s.Enum) - len(values); n > 0 {
+		c.warn(ptr, "enum", "%d of %d values can't be used in validator tag for %s", n, len(s.Enum), t)
+	}
+
+	if len(values) == 0 {
+		return nil
+	}
+
+	return []string{"oneof=" + strings.Join(values, " ")}
+}
@@ -0,0 +1,681 @@
+package gen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ekhabarov/jsg/ast"
+	"github.com/ekhabarov/jsg/lib"
+	"github.com/ekhabarov/jsg/regex"
+	"github.com/iancoleman/strcase"
+)
+
+const (
+	// rtImport is an import path of runtime package used by generated code.
+	rtImport = "github.com/ekhabarov/jsg/rt"
+	// formatsImport is an import path of package checking formats.
+	formatsImport = "github.com/ekhabarov/jsg/formats"
+)
+
+// formatChecks maps formats generated as strings to functions of formats
+// package, which check them.
+var formatChecks = map[ast.StringFormat]string{
+	ast.FormatEmail:               "Email",
+	ast.FormatIdnEmail:            "IdnEmail",
+	ast.FormatHostname:            "Hostname",
+	ast.FormatIdnHostname:         "IdnHostname",
+	ast.FormatURI:                 "URI",
+	ast.FormatURIReference:        "URIReference",
+	ast.FormatIRI:                 "IRI",
+	ast.FormatIRIReference:        "IRIReference",
+	ast.FormatURITemplate:         "URITemplate",
+	ast.FormatJSONPointer:         "JSONPointer",
+	ast.FormatRelativeJSONPointer: "RelativeJSONPointer",
+}
+
+// validator writes Validate method of a generated type.
+type validator struct {
+	c *Config
+	// typ is a name of the generated type.
+	typ string
+	// id is $id of the root schema.
+	id string
+	// ptr is a JSON pointer to the schema of the type.
+	ptr     string
+	imports imports
+	// vars are package level variables, e.g. compiled patterns.
+	vars *bytes.Buffer
+}
+
+// validation writes Validate method of struct td.
+func validation(w io.Writer, c *Config, td typeDef, im imports) error {
+	v := &validator{c: c, typ: td.name, id: td.id, ptr: td.ptr, imports: im, vars: bytes.NewBuffer([]byte{})}
+	body := bytes.NewBuffer([]byte{})
+	s := td.s
+
+	keys := []string{}
+	for n := range s.Properties {
+		keys = append(keys, n)
+	}
+
+	sort.Strings(keys)
+
+	required := map[string]bool{}
+	for _, n := range s.Required {
+		required[n] = true
+	}
+
+	for _, n := range keys {
+		p := s.Properties[n]
+		if p.Skip {
+			continue
+		}
+
+		if err := v.property(body, n, s, &p, required[n]); err != nil {
+			return fmt.Errorf("property %q: %w", n, err)
+		}
+	}
+
+	if err := v.size(body, keys, s, required); err != nil {
+		return err
+	}
+
+	v.method(w, body)
+
+	return nil
+}
+
+// valueValidation writes Validate method of named type td with underlying Go
+// type t.
+func valueValidation(w io.Writer, c *Config, td typeDef, t string, im imports) error {
+	v := &validator{c: c, typ: td.name, id: td.id, ptr: td.ptr, imports: im, vars: bytes.NewBuffer([]byte{})}
+	checks := bytes.NewBuffer([]byte{})
+
+	if err := v.checks(checks, "", td.ptr, "x", `""`, t, td.s, 0); err != nil {
+		return err
+	}
+
+	body := bytes.NewBuffer([]byte{})
+	if checks.Len() > 0 {
+		fmt.Fprintf(body, "x := %s(*v)\n\n%s", t, checks)
+	}
+
+	v.method(w, body)
+
+	return nil
+}
+
+// method writes Validate method with checks in body.
+func (v *validator) method(w io.Writer, body *bytes.Buffer) {
+	v.imports.use(rtImport, "rt")
+
+	fmt.Fprintf(w, "%s", v.vars)
+	fmt.Fprintf(w, "\n// Validate checks v against constraints of its schema. It returns\n")
+	fmt.Fprintf(w, "// rt.Errors with all violations found.\n")
+	fmt.Fprintf(w, "func (v *%s) Validate() error {\n", v.typ)
+	fmt.Fprintf(w, "var errs rt.Errors\n\n")
+	fmt.Fprintf(w, "%s", body)
+	fmt.Fprintf(w, "return errs.Err()\n}\n")
+}
+
+// property writes checks of property n of schema s.
+func (v *validator) property(w io.Writer, n string, s, p *ast.Schema, required bool) error {
+	ptr := v.ptr + "/properties/" + lib.EscapePointer(n)
+
+	t, err := fieldType(v.c, v.id, ptr, p, v.imports)
+	if err != nil {
+		return err
+	}
+
+	field := "v." + fieldName(n, p)
+	path := strconv.Quote("/" + lib.EscapePointer(n))
+
+	// Fields of embedded types are promoted, so are their errors.
+	if p.Embed {
+		field, path = "v."+embedName(t), `""`
+	}
+
+	checks := bytes.NewBuffer([]byte{})
+
+	if optional(s, p) {
+		if required {
+			fmt.Fprintf(w, "if !%s.IsSet() {\nerrs.Add(%s, \"required\", nil, nil)\n}\n\n", field, path)
+		}
+
+		// Values of pointers to types other than generated ones, e.g.
+		// *string, are checked, if they aren't nil. Generated types and exact
+		// numbers are checked by pointer, which checks guard against nil.
+		value, vt := "x := %[1]s.Value()", t
+		if strings.HasPrefix(t, "*") && !isRef(p) && !exact(t, p) {
+			value, vt = "x := *%[1]s.Value()", strings.TrimPrefix(t, "*")
+		}
+
+		if err := v.checks(checks, n, ptr, "x", path, vt, p, 0); err != nil {
+			return err
+		}
+
+		if checks.Len() == 0 {
+			return nil
+		}
+
+		if vt != t {
+			fmt.Fprintf(w, "if %[1]s.IsSet() && !%[1]s.IsNull() && %[1]s.Value() != nil {\n"+value+"\n\n%[2]s}\n\n", field, block(checks))
+		} else {
+			fmt.Fprintf(w, "if %[1]s.IsSet() && !%[1]s.IsNull() {\n"+value+"\n\n%[2]s}\n\n", field, block(checks))
+		}
+
+		return nil
+	}
+
+	// Absence is detectable for nillable types only.
+	if required && nillable(t) {
+		fmt.Fprintf(w, "if %s == nil {\nerrs.Add(%s, \"required\", nil, nil)\n}\n\n", field, path)
+	}
+
+	// Pointers to values other than generated types, e.g. *string. Exact
+	// numbers are checked by pointer.
+	if strings.HasPrefix(t, "*") && !isRef(p) && !exact(t, p) {
+		if err := v.checks(checks, n, ptr, "x", path, strings.TrimPrefix(t, "*"), p, 0); err != nil {
+			return err
+		}
+
+		if checks.Len() > 0 {
+			fmt.Fprintf(w, "if %[1]s != nil {\nx := *%[1]s\n\n%[2]s}\n\n", field, block(checks))
+		}
+
+		return nil
+	}
+
+	return v.checks(w, n, ptr, field, path, t, p, 0)
+}
+
+// checks writes checks of value expr of Go type t against schema s located
+// at ptr, where path is a Go expression of the value's JSON pointer and depth
+// is a nesting level of array items.
+func (v *validator) checks(w io.Writer, name, ptr, expr, path, t string, s *ast.Schema, depth int) error {
+	if s.Enum != nil {
+		v.enum(w, ptr, expr, path, t, s)
+	}
+
+	switch {
+	case isRef(s) && v.c.mapping(v.id, ptr, s) != nil:
+		// Mapped types aren't generated, so they may have no Validate method.
+	case isRef(s):
+		call := fmt.Sprintf("if err := %s.Validate(); err != nil {\nerrs.Nest(%s, err)\n}\n", expr, path)
+
+		if strings.HasPrefix(t, "*") {
+			fmt.Fprintf(w, "if %s != nil {\n%s}\n\n", expr, call)
+		} else {
+			fmt.Fprintf(w, "%s\n", call)
+		}
+	case t == "string":
+		return v.stringChecks(w, name, ptr, expr, path, s)
+	case integer(t) || float(t):
+		v.numberChecks(w, expr, path, t, s)
+	case exact(t, s):
+		v.exactChecks(w, expr, path, t, s, depth)
+	case strings.HasPrefix(t, "[]"):
+		return v.arrayChecks(w, name, ptr, expr, path, t, s, depth)
+	}
+
+	return nil
+}
+
+// enum writes enum check for values of scalar Go type t.
+func (v *validator) enum(w io.Writer, ptr, expr, path, t string, s *ast.Schema) {
+	values := []string{}
+	seen := map[string]bool{}
+
+	for _, e := range s.Enum {
+		l, key := "", ""
+
+		switch x := scalar(e).(type) {
+		case string:
+			if t == "string" {
+				l = strconv.Quote(x)
+			}
+		case *ast.Decimal:
+			// Equal numbers, e.g. 1 and 1.0, are duplicate cases.
+			if float(t) || fits(t, x.Rat()) {
+				l, key = lit(t, x), x.Rat().RatString()
+			}
+		case bool:
+			if t == "bool" {
+				l = strconv.FormatBool(x)
+			}
+		}
+
+		if key == "" {
+			key = l
+		}
+
+		// Values of other types never match, e.g. 1.5 for int.
+		if l != "" && !seen[key] {
+			values = append(values, l)
+			seen[key] = true
+		}
+	}
+
+	if len(values) == 0 {
+		v.c.warn(ptr, "enum", "no enum values can be checked for %s", t)
+
+		return
+	}
+
+	list := strings.Join(values, ", ")
+
+	fmt.Fprintf(w, "switch %s {\ncase %s:\ndefault:\n", expr, list)
+	fmt.Fprintf(w, "errs.Add(%s, \"enum\", []interface{}{%s}, %s)\n}\n\n", path, list, expr)
+}
+
+func (v *validator) stringChecks(w io.Writer, name, ptr, expr, path string, s *ast.Schema) error {
+	if s.MaxLength != nil || s.MinLength != nil {
+		v.imports.use("unicode/utf8", "utf8")
+	}
+
+	if s.MaxLength != nil {
+		fmt.Fprintf(w, "if l := utf8.RuneCountInString(%s); l > %d {\n", expr, *s.MaxLength)
+		fmt.Fprintf(w, "errs.Add(%s, \"maxLength\", %d, l)\n}\n\n", path, *s.MaxLength)
+	}
+
+	if s.MinLength != nil {
+		fmt.Fprintf(w, "if l := utf8.RuneCountInString(%s); l < %d {\n", expr, *s.MinLength)
+		fmt.Fprintf(w, "errs.Add(%s, \"minLength\", %d, l)\n}\n\n", path, *s.MinLength)
+	}
+
+	if s.Pattern != "" {
+		// Generated code compiles translated pattern on initialization, so
+		// it must be accepted by RE2.
+		re, err := regex.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("pattern: %w", err)
+		}
+
+		pv := v.c.variable(strcase.ToLowerCamel(v.typ + " " + name + " pattern"))
+		v.imports.use("regexp", "regexp")
+
+		fmt.Fprintf(v.vars, "\n// %s is a translation of %q.\n", pv, s.Pattern)
+		fmt.Fprintf(v.vars, "var %s = regexp.MustCompile(%q)\n", pv, re.String())
+
+		fmt.Fprintf(w, "if !%s.MatchString(%s) {\n", pv, expr)
+		fmt.Fprintf(w, "errs.Add(%s, \"pattern\", %q, %s)\n}\n\n", path, s.Pattern, expr)
+	}
+
+	if f, ok := formatChecks[s.Format]; ok {
+		v.imports.use(formatsImport, "formats")
+
+		fmt.Fprintf(w, "if err := formats.%s(%s); err != nil {\n", f, expr)
+		fmt.Fprintf(w, "errs.Wrap(%s, \"format\", %q, %s, err)\n}\n\n", path, s.Format.Name(), expr)
+	} else if def, ok := s.Format.Custom(); ok && def.Check != nil {
+		v.c.warn(ptr, "format", "checker of custom format %q isn't available in generated code", def.Name)
+	}
+
+	return nil
+}
+
+func (v *validator) numberChecks(w io.Writer, expr, path, t string, s *ast.Schema) {
+	if m := s.MultipleOf; m != nil && m.Rat().Sign() > 0 {
+		if fits(t, m.Rat()) {
+			fmt.Fprintf(w, "if %s%%%s != 0 {\n", expr, lit(t, m))
+		} else {
+			v.imports.use("math", "math")
+			x := expr
+			if t != "float64" {
+				x = "float64(" + expr + ")"
+			}
+
+			// Tolerance is relative to the quotient, since rounding errors
+			// of floats grow with their magnitude.
+			fmt.Fprintf(w, "if q := %s / %s; math.Abs(q-math.Round(q)) > 1e-9*math.Max(1, math.Abs(q)) {\n", x, lit(t, m))
+		}
+
+		fmt.Fprintf(w, "errs.Add(%s, \"multipleOf\", %s, %s)\n}\n\n", path, arg(t, m), expr)
+	}
+
+	bounds := []struct {
+		keyword string
+		value   *ast.Decimal
+		// op is a comparison, which fails the check.
+		op string
+	}{
+		{"maximum", s.Maximum, ">"},
+		{"exclusiveMaximum", s.ExclusiveMaximum, ">="},
+		{"minimum", s.Minimum, "<"},
+		{"exclusiveMinimum", s.ExclusiveMinimum, "<="},
+	}
+
+	for _, b := range bounds {
+		if b.value == nil || implied(t, b.keyword, b.value.Rat()) {
+			continue
+		}
+
+		x := expr
+		// Fractional or large bound can't be compared with int directly.
+		if integer(t) && !fits(t, b.value.Rat()) {
+			x = "float64(" + expr + ")"
+		}
+
+		fmt.Fprintf(w, "if %s %s %s {\n", x, b.op, lit(t, b.value))
+		fmt.Fprintf(w, "errs.Add(%s, %q, %s, %s)\n}\n\n", path, b.keyword, arg(t, b.value), expr)
+	}
+}
+
+// exactChecks writes checks of number expr of Go type t, which isn't a Go
+// number type, e.g. json.Number. Values are compared exactly with rt.Rat.
+func (v *validator) exactChecks(w io.Writer, expr, path, t string, s *ast.Schema, depth int) {
+	// Methods of big numbers have pointer receivers.
+	if big, ok := v.imports["math/big"]; ok && (t == big+".Int" || t == big+".Float") {
+		expr = "&" + expr
+	}
+
+	r := "r"
+	if depth > 0 {
+		r += strconv.Itoa(depth)
+	}
+
+	checks := bytes.NewBuffer([]byte{})
+
+	if m := s.MultipleOf; m != nil {
+		fmt.Fprintf(checks, "if !rt.MultipleOf(%s, %q) {\n", r, m.String())
+		fmt.Fprintf(checks, "errs.Add(%s, \"multipleOf\", json.Number(%q), %s)\n}\n\n", path, m.String(), expr)
+	}
+
+	bounds := []struct {
+		keyword string
+		value   *ast.Decimal
+		// op is a comparison, which fails the check.
+		op string
+	}{
+		{"maximum", s.Maximum, ">"},
+		{"exclusiveMaximum", s.ExclusiveMaximum, ">="},
+		{"minimum", s.Minimum, "<"},
+		{"exclusiveMinimum", s.ExclusiveMinimum, "<="},
+	}
+
+	for _, b := range bounds {
+		if b.value == nil {
+			continue
+		}
+
+		fmt.Fprintf(checks, "if rt.Compare(%s, %q) %s 0 {\n", r, b.value.String(), b.op)
+		fmt.Fprintf(checks, "errs.Add(%s, %q, json.Number(%q), %s)\n}\n\n", path, b.keyword, b.value.String(), expr)
+	}
+
+	if checks.Len() == 0 {
+		return
+	}
+
+	v.imports.use("encoding/json", "json")
+	fmt.Fprintf(w, "if %s, ok := rt.Rat(%s); ok {\n%s}\n\n", r, expr, block(checks))
+}
+
+func (v *validator) arrayChecks(w io.Writer, name, ptr, expr, path, t string, s *ast.Schema, depth int) error {
+	i, j, e := "i", "j", "e"
+	if depth > 0 {
+		d := strconv.Itoa(depth)
+		i, j, e = i+d, j+d, e+d
+	}
+
+	if s.MaxItems != nil {
+		fmt.Fprintf(w, "if l := len(%s); l > %d {\n", expr, *s.MaxItems)
+		fmt.Fprintf(w, "errs.Add(%s, \"maxItems\", %d, l)\n}\n\n", path, *s.MaxItems)
+	}
+
+	if s.MinItems != nil {
+		fmt.Fprintf(w, "if l := len(%s); l < %d {\n", expr, *s.MinItems)
+		fmt.Fprintf(w, "errs.Add(%s, \"minItems\", %d, l)\n}\n\n", path, *s.MinItems)
+	}
+
+	elem := strings.TrimPrefix(t, "[]")
+
+	if s.UniqueItems {
+		eq := fmt.Sprintf("%[1]s[%[2]s] == %[1]s[%[3]s]", expr, i, j)
+		if !comparable(elem) {
+			v.imports.use("reflect", "reflect")
+			eq = fmt.Sprintf("reflect.DeepEqual(%[1]s[%[2]s], %[1]s[%[3]s])", expr, i, j)
+		}
+
+		fmt.Fprintf(w, "for %[1]s := range %[2]s {\nfor %[3]s := %[1]s + 1; %[3]s < len(%[2]s); %[3]s++ {\n", i, expr, j)
+		fmt.Fprintf(w, "if %s {\n", eq)
+		fmt.Fprintf(w, "errs.Add(%s, \"uniqueItems\", true, []int{%s, %s})\n}\n}\n}\n\n", path, i, j)
+	}
+
+	if s.Items == nil {
+		return nil
+	}
+
+	items := bytes.NewBuffer([]byte{})
+	ipath := fmt.Sprintf("%s + \"/\" + strconv.Itoa(%s)", path, i)
+	if strings.HasSuffix(path, `"`) {
+		ipath = fmt.Sprintf("%s/\" + strconv.Itoa(%s)", strings.TrimSuffix(path, `"`), i)
+	}
+
+	if err := v.checks(items, name+" items", ptr+"/items", e, ipath, elem, s.Items, depth+1); err != nil {
+		return fmt.Errorf("items: %w", err)
+	}
+
+	if items.Len() > 0 {
+		v.imports.use("strconv", "strconv")
+		fmt.Fprintf(w, "for %s, %s := range %s {\n%s}\n\n", i, e, expr, block(items))
+	}
+
+	return nil
+}
+
+// size writes minProperties and maxProperties checks of schema s with
+// properties keys. Optional fields count only if they're set, fields with
+// omitempty or omitzero json options count only if encoding/json doesn't
+// omit them, fields with "-" json tag don't count, other fields are always
+// marshaled.
+func (v *validator) size(w io.Writer, keys []string, s *ast.Schema, required map[string]bool) error {
+	if s.MinProperties == nil && s.MaxProperties == nil {
+		return nil
+	}
+
+	n, conds := 0, []string{}
+
+	for _, k := range keys {
+		p := s.Properties[k]
+		if p.Skip {
+			continue
+		}
+
+		field := "v." + fieldName(k, &p)
+
+		if p.Embed {
+			n++
+
+			continue
+		}
+
+		if optional(s, &p) {
+			conds = append(conds, field+".IsSet()")
+
+			continue
+		}
+
+		tags, err := fieldTags(v.c, k, &p, required[k], false)
+		if err != nil {
+			return fmt.Errorf("property %q: %w", k, err)
+		}
+
+		name, opts := reflect.StructTag(strings.Join(tags, " ")).Get("json"), ""
+		if i := strings.Index(name, ","); i >= 0 {
+			name, opts = name[:i], name[i:]
+		}
+
+		if name == "-" && opts == "" {
+			continue
+		}
+
+		cond := ""
+
+		if strings.Contains(opts+",", ",omitempty,") || strings.Contains(opts+",", ",omitzero,") {
+			ptr := v.ptr + "/properties/" + lib.EscapePointer(k)
+
+			t, err := fieldType(v.c, v.id, ptr, &p, v.imports)
+			if err != nil {
+				return fmt.Errorf("property %q: %w", k, err)
+			}
+
+			cond = v.encoded(field, t, strings.Contains(opts+",", ",omitempty,"))
+		}
+
+		if cond == "" {
+			n++
+		} else {
+			conds = append(conds, cond)
+		}
+	}
+
+	fmt.Fprintf(w, "n := %d\n", n)
+
+	for _, c := range conds {
+		fmt.Fprintf(w, "if %s {\nn++\n}\n", c)
+	}
+
+	fmt.Fprintln(w)
+
+	if s.MaxProperties != nil {
+		fmt.Fprintf(w, "if n > %d {\n", *s.MaxProperties)
+		fmt.Fprintf(w, "errs.Add(\"\", \"maxProperties\", %d, n)\n}\n\n", *s.MaxProperties)
+	}
+
+	if s.MinProperties != nil {
+		fmt.Fprintf(w, "if n < %d {\n", *s.MinProperties)
+		fmt.Fprintf(w, "errs.Add(\"\", \"minProperties\", %d, n)\n}\n\n", *s.MinProperties)
+	}
+
+	return nil
+}
+
+// encoded returns a condition, under which encoding/json writes field expr of
+// Go type t with omitempty option, or omitzero one otherwise. It returns an
+// empty string for types, which values are always written, e.g. structs.
+func (v *validator) encoded(expr, t string, omitempty bool) string {
+	rt := v.imports[rtImport]
+	slice := strings.HasPrefix(t, "[]") || strings.HasPrefix(t, "map[") || t == rt+".IPv4" || t == rt+".IPv6"
+
+	switch {
+	case t == "string" || t == v.imports["encoding/json"]+".Number":
+		return expr + ` != ""`
+	case t == "bool":
+		return expr
+	case integer(t) || float(t):
+		return expr + " != 0"
+	case slice && omitempty:
+		return "len(" + expr + ") > 0"
+	case slice || strings.HasPrefix(t, "*") || t == "interface{}":
+		return expr + " != nil"
+	}
+
+	return ""
+}
+
+// block returns statements of b without trailing empty line, so they can be
+// put into a block.
+func block(b *bytes.Buffer) string {
+	return strings.TrimSuffix(b.String(), "\n\n") + "\n"
+}
+
+// nillable reports whether a value of Go type t can be nil.
+func nillable(t string) bool {
+	return strings.HasPrefix(t, "*") || strings.HasPrefix(t, "[]") || strings.HasPrefix(t, "map[") || t == "interface{}"
+}
+
+// comparable reports whether values of Go type t can be compared with ==.
+func comparable(t string) bool {
+	return t == "string" || t == "bool" || integer(t) || float(t)
+}
+
+// exact reports whether number schema s has Go type t, which isn't a Go
+// number type, e.g. json.Number or *big.Int.
+func exact(t string, s *ast.Schema) bool {
+	t = strings.TrimPrefix(t, "*")
+
+	return (s.Type == ast.Number || s.Type == ast.Integer) && !integer(t) && !float(t)
+}
+
+// integer reports whether t is a Go integer type.
+func integer(t string) bool {
+	switch t {
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		return true
+	}
+
+	return false
+}
+
+// float reports whether t is a Go floating-point type.
+func float(t string) bool {
+	return t == "float32" || t == "float64"
+}
+
+// scalar returns enum value raw as string, *ast.Decimal or bool, or nil if
+// it's null, an array or an object.
+func scalar(raw json.RawMessage) interface{} {
+	d := json.NewDecoder(bytes.NewReader(raw))
+	d.UseNumber()
+
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return nil
+	}
+
+	switch x := v.(type) {
+	case json.Number:
+		n, err := ast.ParseDecimal(x.String())
+		if err != nil {
+			return nil
+		}
+
+		return n
+	case string, bool:
+		return x
+	}
+
+	return nil
+}
+
+// whole reports whether r is an integer, which fits int on 32-bit platforms
+// too, so it can be used as int constant.
+func whole(r *big.Rat) bool {
+	return r.IsInt() && r.Num().IsInt64() && r.Num().Int64() >= math.MinInt32 && r.Num().Int64() <= math.MaxInt32
+}
+
+// lit returns Go literal of number d for a value of Go type t. Integers are
+// written as digits, other numbers as written in the schema, so no precision
+// is lost, e.g. 9007199254740993.
+func lit(t string, d *ast.Decimal) string {
+	if r := d.Rat(); fits(t, r) {
+		return r.RatString()
+	}
+
+	return d.String()
+}
+
+// arg returns Go expression of number d for a value of Go type t passed as
+// interface{}. Integers, which overflow int on 32-bit platforms, are typed,
+// e.g. uint64(18446744073709551615).
+func arg(t string, d *ast.Decimal) string {
+	l := lit(t, d)
+
+	r := d.Rat()
+	if !r.IsInt() || whole(r) || strings.ContainsAny(l, ".eE") {
+		return l
+	}
+
+	if fits(t, r) {
+		return t + "(" + l + ")"
+	}
+
+	return "float64(" + l + ")"
+}
@@ -0,0 +1,176 @@
+// Package jsonvalue handles JSON values decoded into interface{}, where
+// numbers are json.Number, float64 or Go integers, e.g. instances and
+// keyword values of schemas.
+package jsonvalue
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// Type returns JSON type of decoded value i, e.g. "object", where numbers
+// with zero fractional part are "integer". It returns empty string for values
+// of other Go types, NaN and infinities.
+func Type(i interface{}) string {
+	switch i.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+
+	n := Rat(i)
+
+	switch {
+	case n == nil:
+		return ""
+	case n.IsInt():
+		return "integer"
+	}
+
+	return "number"
+}
+
+// Rat converts numeric value i to rational, it returns nil for non-numeric
+// values, NaN and infinities.
+func Rat(i interface{}) *big.Rat {
+	var s string
+
+	switch v := i.(type) {
+	case json.Number:
+		s = v.String()
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil
+		}
+
+		s = strconv.FormatFloat(v, 'g', -1, 64)
+	case float32:
+		if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+			return nil
+		}
+
+		s = strconv.FormatFloat(float64(v), 'g', -1, 32)
+	case int:
+		s = strconv.FormatInt(int64(v), 10)
+	case int8:
+		s = strconv.FormatInt(int64(v), 10)
+	case int16:
+		s = strconv.FormatInt(int64(v), 10)
+	case int32:
+		s = strconv.FormatInt(int64(v), 10)
+	case int64:
+		s = strconv.FormatInt(v, 10)
+	case uint:
+		s = strconv.FormatUint(uint64(v), 10)
+	case uint8:
+		s = strconv.FormatUint(uint64(v), 10)
+	case uint16:
+		s = strconv.FormatUint(uint64(v), 10)
+	case uint32:
+		s = strconv.FormatUint(uint64(v), 10)
+	case uint64:
+		s = strconv.FormatUint(v, 10)
+	default:
+		return nil
+	}
+
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil
+	}
+
+	return r
+}
+
+// Equal reports whether JSON values a and b are equal, where numbers are
+// equal if their mathematical values are, e.g. 1 and 1.0.
+func Equal(a, b interface{}) bool {
+	if x, y := Rat(a), Rat(b); x != nil || y != nil {
+		return x != nil && y != nil && x.Cmp(y) == 0
+	}
+
+	switch x := a.(type) {
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+
+		for i := range x {
+			if !Equal(x[i], y[i]) {
+				return false
+			}
+		}
+
+		return true
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+
+		for k, v := range x {
+			w, ok := y[k]
+			if !ok || !Equal(v, w) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return a == b
+}
+
+// Canonical returns canonical JSON representation of value raw: object
+// members are sorted and numbers are in the shortest exact form, so equal
+// values have the same representation. Invalid JSON is returned as is.
+func Canonical(raw json.RawMessage) string {
+	d := json.NewDecoder(bytes.NewReader(raw))
+	d.UseNumber()
+
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return string(raw)
+	}
+
+	b, _ := json.Marshal(canonical(v))
+
+	return string(b)
+}
+
+// canonical returns JSON value v with numbers in the shortest exact form.
+func canonical(v interface{}) interface{} {
+	switch x := v.(type) {
+	case json.Number:
+		if r, ok := new(big.Rat).SetString(x.String()); ok {
+			// Decimal fractions have finite number of decimal places.
+			n := 0
+			for t := new(big.Rat).Set(r); !t.IsInt(); n++ {
+				t.Mul(t, big.NewRat(10, 1))
+			}
+
+			return json.Number(r.FloatString(n))
+		}
+	case []interface{}:
+		for i := range x {
+			x[i] = canonical(x[i])
+		}
+	case map[string]interface{}:
+		for k := range x {
+			x[k] = canonical(x[k])
+		}
+	}
+
+	return v
+}
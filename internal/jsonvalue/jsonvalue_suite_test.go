@@ -0,0 +1,13 @@
+package jsonvalue_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestJsonvalue(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Jsonvalue Suite")
+}
@@ -0,0 +1,53 @@
+package jsonvalue_test
+
+import (
+	"encoding/json"
+	"math"
+
+	"github.com/ekhabarov/jsg/internal/jsonvalue"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Jsonvalue", func() {
+
+	DescribeTable("Type",
+		func(i interface{}, exp string) {
+			Expect(jsonvalue.Type(i)).To(Equal(exp))
+		},
+
+		Entry("null", nil, "null"),
+		Entry("boolean", true, "boolean"),
+		Entry("string", "a", "string"),
+		Entry("array", []interface{}{}, "array"),
+		Entry("object", map[string]interface{}{}, "object"),
+		Entry("integer", json.Number("1.0"), "integer"),
+		Entry("number", 1.5, "number"),
+		Entry("NaN", math.NaN(), ""),
+		Entry("other", struct{}{}, ""),
+	)
+
+	DescribeTable("Equal",
+		func(a, b interface{}, exp bool) {
+			Expect(jsonvalue.Equal(a, b)).To(Equal(exp))
+		},
+
+		Entry("numbers of different types", json.Number("1.0"), 1, true),
+		Entry("exact numbers", json.Number("9007199254740993"), float64(9007199254740992), false),
+		Entry("number and string", json.Number("1"), "1", false),
+		Entry("arrays", []interface{}{1.0, "a"}, []interface{}{json.Number("1"), "a"}, true),
+		Entry("objects", map[string]interface{}{"a": 1.0}, map[string]interface{}{"a": 2.0}, false),
+		Entry("null", nil, nil, true),
+	)
+
+	DescribeTable("Canonical",
+		func(raw, exp string) {
+			Expect(jsonvalue.Canonical(json.RawMessage(raw))).To(Equal(exp))
+		},
+
+		Entry("numbers", `[1.0, 1e2, 0.10]`, `[1,100,0.1]`),
+		Entry("object members", `{"b": 1, "a": {"d": 2, "c": 3}}`, `{"a":{"c":3,"d":2},"b":1}`),
+		Entry("invalid JSON", `{`, `{`),
+	)
+})
@@ -0,0 +1,208 @@
+// Package udiff makes unified diffs of text files without external tools.
+package udiff
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// context is a number of unchanged lines around changes.
+const context = 3
+
+// Unified returns unified diff of texts a and b, which are named from and to
+// in its header, or nil if texts are equal.
+func Unified(from, to string, a, b []byte) []byte {
+	if bytes.Equal(a, b) {
+		return nil
+	}
+
+	ops := edits(lines(a), lines(b))
+
+	out := &bytes.Buffer{}
+	fmt.Fprintf(out, "--- %s\n+++ %s\n", from, to)
+
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+
+			continue
+		}
+
+		// A hunk spans changes, which are separated by at most 2*context
+		// unchanged lines, and context lines around them.
+		start, end := i-context, i
+		if start < 0 {
+			start = 0
+		}
+
+		for j := i; j < len(ops) && j-end <= 2*context+1; j++ {
+			if ops[j].kind != ' ' {
+				end = j
+			}
+		}
+
+		i, end = end+1, end+1+context
+		if end > len(ops) {
+			end = len(ops)
+		}
+
+		hunk(out, ops[start:end])
+	}
+
+	return out.Bytes()
+}
+
+// op is an edit of a line: ' ' keeps, '-' deletes and '+' inserts it. x and
+// y are indices of the line in old and new texts, the one of the other text
+// is where the line is.
+type op struct {
+	kind byte
+	x, y int
+	line string
+}
+
+// hunk writes hunk of ops with its header.
+func hunk(out *bytes.Buffer, ops []op) {
+	var xn, yn int
+
+	for _, o := range ops {
+		if o.kind != '+' {
+			xn++
+		}
+
+		if o.kind != '-' {
+			yn++
+		}
+	}
+
+	fmt.Fprintf(out, "@@ -%s +%s @@\n", span(ops[0].x, xn), span(ops[0].y, yn))
+
+	for _, o := range ops {
+		out.WriteByte(o.kind)
+		out.WriteString(o.line)
+
+		if !strings.HasSuffix(o.line, "\n") {
+			out.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// span returns a range of n lines starting at index i in hunk header, which
+// starts with the line before the hunk if it has no lines, like diff -u.
+func span(i, n int) string {
+	switch n {
+	case 0:
+		return fmt.Sprintf("%d,0", i)
+	case 1:
+		return fmt.Sprintf("%d", i+1)
+	}
+
+	return fmt.Sprintf("%d,%d", i+1, n)
+}
+
+// lines splits text b into lines, which keep their line feeds.
+func lines(b []byte) []string {
+	var ll []string
+
+	for len(b) > 0 {
+		i := bytes.IndexByte(b, '\n') + 1
+		if i == 0 {
+			i = len(b)
+		}
+
+		ll = append(ll, string(b[:i]))
+		b = b[i:]
+	}
+
+	return ll
+}
+
+// edits returns the shortest edit script, which turns lines x into lines y,
+// found with Myers' algorithm.
+func edits(x, y []string) []op {
+	n, m := len(x), len(y)
+	max := n + m
+
+	// v maps diagonals k to the furthest index in x reached on them, trace
+	// keeps v before each step.
+	v := make([]int, 2*max+2)
+	trace := [][]int{}
+
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v...))
+
+		if furthest(x, y, v, d) {
+			break
+		}
+	}
+
+	ops := []op{}
+	i, j := n, m
+
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := i - j
+
+		pk := k - 1
+		if k == -d || k != d && v[max+k-1] < v[max+k+1] {
+			pk = k + 1
+		}
+
+		pi := v[max+pk]
+		pj := pi - pk
+
+		for i > pi && j > pj {
+			i, j = i-1, j-1
+			ops = append(ops, op{kind: ' ', x: i, y: j, line: x[i]})
+		}
+
+		if d == 0 {
+			break
+		}
+
+		if i == pi {
+			j--
+			ops = append(ops, op{kind: '+', x: i, y: j, line: y[j]})
+		} else {
+			i--
+			ops = append(ops, op{kind: '-', x: i, y: j, line: x[i]})
+		}
+
+		i, j = pi, pj
+	}
+
+	for l, r := 0, len(ops)-1; l < r; l, r = l+1, r-1 {
+		ops[l], ops[r] = ops[r], ops[l]
+	}
+
+	return ops
+}
+
+// furthest makes step d of Myers' algorithm, which updates v, and reports
+// whether the end of both x and y is reached.
+func furthest(x, y []string, v []int, d int) bool {
+	max := len(x) + len(y)
+
+	for k := -d; k <= d; k += 2 {
+		var i int
+		if k == -d || k != d && v[max+k-1] < v[max+k+1] {
+			i = v[max+k+1]
+		} else {
+			i = v[max+k-1] + 1
+		}
+
+		j := i - k
+		for i < len(x) && j < len(y) && x[i] == y[j] {
+			i, j = i+1, j+1
+		}
+
+		v[max+k] = i
+
+		if i >= len(x) && j >= len(y) {
+			return true
+		}
+	}
+
+	return false
+}
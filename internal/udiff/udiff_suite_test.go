@@ -0,0 +1,13 @@
+package udiff_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestUdiff(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Udiff Suite")
+}
@@ -0,0 +1,31 @@
+package udiff_test
+
+import (
+	"github.com/ekhabarov/jsg/internal/udiff"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Udiff", func() {
+
+	DescribeTable("Unified",
+		func(a, b, exp string) {
+			Expect(string(udiff.Unified("a.json.orig", "a.json", []byte(a), []byte(b)))).To(Equal(exp))
+		},
+
+		Entry("equal texts", "a\nb\n", "a\nb\n", ""),
+		Entry("changed line", "1\n2\n3\n4\n5\n6\n7\n8\n9\n", "1\n2\n3\n4\nx\n6\n7\n8\n9\n",
+			"--- a.json.orig\n+++ a.json\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+x\n 6\n 7\n 8\n"),
+		Entry("empty old text", "", "a\n",
+			"--- a.json.orig\n+++ a.json\n@@ -0,0 +1 @@\n+a\n"),
+		Entry("empty new text", "a\nb\n", "",
+			"--- a.json.orig\n+++ a.json\n@@ -1,2 +0,0 @@\n-a\n-b\n"),
+		Entry("missing line feed", "a\nb", "a\nb\n",
+			"--- a.json.orig\n+++ a.json\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n"),
+		Entry("close changes in one hunk", "1\n2\n3\n4\n5\n6\n7\n8\n", "x\n2\n3\n4\n5\n6\n7\ny\n",
+			"--- a.json.orig\n+++ a.json\n@@ -1,8 +1,8 @@\n-1\n+x\n 2\n 3\n 4\n 5\n 6\n 7\n-8\n+y\n"),
+		Entry("distant changes in separate hunks", "1\n2\n3\n4\n5\n6\n7\n8\n9\n", "x\n2\n3\n4\n5\n6\n7\n8\ny\n",
+			"--- a.json.orig\n+++ a.json\n@@ -1,4 +1,4 @@\n-1\n+x\n 2\n 3\n 4\n@@ -6,4 +6,4 @@\n 6\n 7\n 8\n-9\n+y\n"),
+	)
+})
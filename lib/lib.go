@@ -25,3 +25,29 @@ func URLName(id string) (string, error) {
 
 	return strcase.ToCamel(f), nil
 }
+
+// RefName returns a type name of the schema referenced by ref, which is a
+// name of $defs entry for "#/$defs/name" references and a name extracted from
+// URL otherwise.
+func RefName(ref string) (string, error) {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return "", fmt.Errorf("$ref property is invalid: %w", err)
+	}
+
+	if d := strings.TrimPrefix(u.Fragment, "/$defs/"); d != u.Fragment && d != "" && !strings.Contains(d, "/") {
+		return strcase.ToCamel(UnescapePointer(d)), nil
+	}
+
+	return URLName(ref)
+}
+
+// EscapePointer escapes reference token of JSON pointer according to RFC 6901.
+func EscapePointer(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
+
+// UnescapePointer reverts EscapePointer.
+func UnescapePointer(token string) string {
+	return strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+}
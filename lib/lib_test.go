@@ -34,4 +34,35 @@ var _ = Describe("Lib", func() {
 		)
 	})
 
+	Context("RefName", func() {
+
+		DescribeTable("Refs",
+			func(ref, expName string) {
+				got, err := lib.RefName(ref)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(got).To(Equal(expName))
+			},
+
+			Entry("", "https://example.com/name.json", "Name"),
+			Entry("", "#/$defs/street_address", "StreetAddress"),
+			Entry("", "other.json#/$defs/a~1b", "Ab"),
+			Entry("", "https://example.com/item.json#/properties/a", "Item"),
+		)
+	})
+
+	Context("JSON pointer", func() {
+
+		DescribeTable("EscapePointer",
+			func(token, escaped string) {
+				Expect(lib.EscapePointer(token)).To(Equal(escaped))
+				Expect(lib.UnescapePointer(escaped)).To(Equal(token))
+			},
+
+			Entry("", "name", "name"),
+			Entry("", "a/b", "a~1b"),
+			Entry("", "m~n", "m~0n"),
+			Entry("", "~1", "~01"),
+		)
+	})
+
 })
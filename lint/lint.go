@@ -0,0 +1,404 @@
+// Package lint checks schemas for mistakes, which are valid JSON schema, but
+// most likely aren't what the author meant, e.g. contradicting bounds or
+// defaults, which violate their own schema.
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ekhabarov/jsg/ast"
+	"github.com/ekhabarov/jsg/lib"
+)
+
+// Ignore is a vendor extension with IDs of rules, which aren't checked for
+// the schema and its subschemas, e.g. {"x-lint-ignore": ["missing-title"]}.
+// Set on the root schema it suppresses the rules for the whole file.
+const Ignore = "x-lint-ignore"
+
+// Severity of a problem.
+type Severity uint8
+
+const (
+	// Off disables a rule.
+	Off Severity = iota
+	Info
+	Warning
+	Error
+)
+
+var severities = map[Severity]string{
+	Off:     "off",
+	Info:    "info",
+	Warning: "warning",
+	Error:   "error",
+}
+
+func (s Severity) String() string {
+	return severities[s]
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// ParseSeverity returns severity by its name, e.g. "warning".
+func ParseSeverity(name string) (Severity, error) {
+	for s, n := range severities {
+		if n == name {
+			return s, nil
+		}
+	}
+
+	return Off, fmt.Errorf("unknown severity %q", name)
+}
+
+// Problem is a rule violation.
+type Problem struct {
+	// Path is a JSON pointer to the keyword, which violates the rule.
+	Path     string   `json:"path"`
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %s: %s [%s]", p.Path, p.Severity, p.Message, p.Rule)
+}
+
+// Problems are rule violations in order of their paths.
+type Problems []Problem
+
+// Max returns the highest severity of problems, it's Off if there are none.
+func (ps Problems) Max() Severity {
+	m := Off
+	for _, p := range ps {
+		if p.Severity > m {
+			m = p.Severity
+		}
+	}
+
+	return m
+}
+
+// Config defines which rules are checked and severities of their problems.
+type Config struct {
+	// Severities override default severities of rules by their IDs.
+	Severities map[string]Severity
+}
+
+// Option configures Config.
+type Option func(*Config)
+
+// SetSeverity sets severity of rule problems, Off disables the rule.
+func SetSeverity(rule string, s Severity) Option {
+	return func(c *Config) {
+		if c.Severities == nil {
+			c.Severities = map[string]Severity{}
+		}
+
+		c.Severities[rule] = s
+	}
+}
+
+// Disable disables rules.
+func Disable(rules ...string) Option {
+	return func(c *Config) {
+		for _, r := range rules {
+			SetSeverity(r, Off)(c)
+		}
+	}
+}
+
+// Lint checks schema s, which is a whole document, against all rules.
+func Lint(s *ast.Schema, opts ...Option) (Problems, error) {
+	c := Config{}
+	for _, o := range opts {
+		o(&c)
+	}
+
+	l := &linter{
+		root:       s,
+		severities: map[string]Severity{},
+		resources:  map[string]string{},
+	}
+
+	for _, r := range rules {
+		l.severities[r.ID] = r.Severity
+	}
+
+	for id, sev := range c.Severities {
+		if _, ok := l.severities[id]; !ok {
+			return nil, fmt.Errorf("unknown rule %q", id)
+		}
+
+		l.severities[id] = sev
+	}
+
+	if err := l.walk(s, "", "", s.Schema, nil); err != nil {
+		return nil, err
+	}
+
+	for _, n := range l.nodes {
+		for _, r := range rules {
+			if r.check != nil {
+				r.check(l, n)
+			}
+		}
+	}
+
+	l.unusedDefs()
+
+	sort.SliceStable(l.problems, func(i, j int) bool {
+		return l.problems[i].Path < l.problems[j].Path
+	})
+
+	return l.problems, nil
+}
+
+// linter keeps state of a single Lint call.
+type linter struct {
+	root       *ast.Schema
+	severities map[string]Severity
+	problems   Problems
+
+	// nodes are all schemas of the document.
+	nodes []node
+	// resources maps absolute URIs of schema resources to their JSON
+	// pointers within the document.
+	resources map[string]string
+	// refs are references within the document.
+	refs []reference
+	// defs are $defs entries of the document.
+	defs []node
+}
+
+// reference is a $ref within the document.
+type reference struct {
+	// from is a JSON pointer to the schema with $ref.
+	from string
+	// uri is the resolved reference including a fragment.
+	uri string
+}
+
+// node is a schema within the document.
+type node struct {
+	s   *ast.Schema
+	ptr string
+	// base is a base URI of the schema.
+	base string
+	// draft is $schema of the schema resource.
+	draft string
+	// ignored are IDs of rules suppressed for the schema.
+	ignored map[string]bool
+}
+
+// report adds a problem of rule at path ptr, unless the rule is disabled or
+// ignored.
+func (l *linter) report(n node, ptr, rule, msg string, args ...interface{}) {
+	sev := l.severities[rule]
+	if sev == Off || n.ignored[rule] {
+		return
+	}
+
+	l.problems = append(l.problems, Problem{
+		Path:     ptr,
+		Rule:     rule,
+		Severity: sev,
+		Message:  fmt.Sprintf(msg, args...),
+	})
+}
+
+// walk collects schema s at JSON pointer ptr and its subschemas, where base
+// is a base URI of s and draft is $schema of its resource.
+func (l *linter) walk(s *ast.Schema, ptr, base, draft string, ignored map[string]bool) error {
+	if s.ID != "" {
+		id, err := resolve(base, s.ID)
+		if err != nil {
+			return fmt.Errorf("%s/$id: invalid URI %q: %w", ptr, s.ID, err)
+		}
+
+		base = id
+	}
+
+	if _, ok := l.resources[base]; !ok {
+		l.resources[base] = ptr
+	}
+
+	if s.Schema != "" {
+		draft = s.Schema
+	}
+
+	ignored, err := ignore(s, ptr, ignored)
+	if err != nil {
+		return err
+	}
+
+	n := node{s: s, ptr: ptr, base: base, draft: draft, ignored: ignored}
+	l.nodes = append(l.nodes, n)
+
+	if s.Ref != "" {
+		ref, err := resolve(base, s.Ref)
+		if err != nil {
+			return fmt.Errorf("%s/$ref: invalid URI %q: %w", ptr, s.Ref, err)
+		}
+
+		l.refs = append(l.refs, reference{from: ptr, uri: ref})
+	}
+
+	for _, name := range names(s.Defs) {
+		d := s.Defs[name]
+		p := ptr + "/$defs/" + lib.EscapePointer(name)
+
+		di, err := ignore(&d, p, ignored)
+		if err != nil {
+			return err
+		}
+
+		l.defs = append(l.defs, node{s: &d, ptr: p, ignored: di})
+
+		if err := l.walk(&d, p, base, draft, ignored); err != nil {
+			return err
+		}
+	}
+
+	for i := range s.AllOf {
+		if err := l.walk(&s.AllOf[i], ptr+"/allOf/"+strconv.Itoa(i), base, draft, ignored); err != nil {
+			return err
+		}
+	}
+
+	if s.Items != nil {
+		if err := l.walk(s.Items, ptr+"/items", base, draft, ignored); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range names(s.Properties) {
+		p := s.Properties[name]
+		if err := l.walk(&p, ptr+"/properties/"+lib.EscapePointer(name), base, draft, ignored); err != nil {
+			return err
+		}
+	}
+
+	// Keywords, which AST doesn't decode, e.g. oneOf, have subschemas too.
+	subs, err := s.Subschemas()
+	if err != nil {
+		return fmt.Errorf("%s: %w", ptr, err)
+	}
+
+	for _, sub := range subs {
+		if err := l.walk(sub.Schema, ptr+sub.Ptr, base, draft, ignored); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ignore returns IDs of rules suppressed for schema s at JSON pointer ptr,
+// which are ones of its parent and ones listed in its Ignore extension.
+func ignore(s *ast.Schema, ptr string, parent map[string]bool) (map[string]bool, error) {
+	raw, ok := s.Extensions[Ignore]
+	if !ok {
+		return parent, nil
+	}
+
+	var ids []string
+	if err := json.Unmarshal(raw, &ids); err != nil {
+		return nil, fmt.Errorf("%s/%s: %w", ptr, Ignore, err)
+	}
+
+	m := map[string]bool{}
+	for id := range parent {
+		m[id] = true
+	}
+
+	for _, id := range ids {
+		if find(id) == nil {
+			return nil, fmt.Errorf("%s/%s: unknown rule %q", ptr, Ignore, id)
+		}
+
+		m[id] = true
+	}
+
+	return m, nil
+}
+
+// unusedDefs reports $defs entries, which aren't referenced within the
+// document from outside of them.
+func (l *linter) unusedDefs() {
+	for _, d := range l.defs {
+		used := false
+
+		for _, ref := range l.refs {
+			if within(ref.from, d.ptr) {
+				continue
+			}
+
+			if t, ok := l.target(ref.uri); ok && within(t, d.ptr) {
+				used = true
+
+				break
+			}
+		}
+
+		if !used {
+			l.report(d, d.ptr, "unused-defs", "isn't referenced within the document")
+		}
+	}
+}
+
+// target returns a JSON pointer to the schema referenced by URI uri, if it's
+// within the document.
+func (l *linter) target(uri string) (string, bool) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", false
+	}
+
+	fragment := u.Fragment
+	u.Fragment, u.RawFragment = "", ""
+
+	ptr, ok := l.resources[u.String()]
+
+	return ptr + fragment, ok
+}
+
+// within reports whether JSON pointer ptr points to schema at parent or
+// inside it.
+func within(ptr, parent string) bool {
+	return ptr == parent || strings.HasPrefix(ptr, parent+"/")
+}
+
+// resolve resolves reference ref against base URI.
+func resolve(base, ref string) (string, error) {
+	b, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+
+	r, err := url.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+
+	return b.ResolveReference(r).String(), nil
+}
+
+// names returns keys of m in alphabetical order.
+func names(m map[string]ast.Schema) []string {
+	nn := make([]string, 0, len(m))
+	for n := range m {
+		nn = append(nn, n)
+	}
+
+	sort.Strings(nn)
+
+	return nn
+}
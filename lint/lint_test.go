@@ -0,0 +1,263 @@
+package lint_test
+
+import (
+	"strings"
+
+	"github.com/ekhabarov/jsg/ast"
+	"github.com/ekhabarov/jsg/lint"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+func parse(schema string) *ast.Schema {
+	s, err := ast.Parse(strings.NewReader(schema))
+	Expect(err).NotTo(HaveOccurred())
+
+	return s
+}
+
+// problems returns problems as strings.
+func problems(ps lint.Problems) []string {
+	ss := []string{}
+	for _, p := range ps {
+		ss = append(ss, p.String())
+	}
+
+	return ss
+}
+
+// documentation disables rules, which check documentation of schemas.
+var documentation = lint.Disable("missing-id", "missing-title", "missing-description")
+
+var _ = Describe("Lint", func() {
+
+	DescribeTable("Rules",
+		func(schema string, exp ...string) {
+			ps, err := lint.Lint(parse(schema), documentation)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(problems(ps)).To(Equal(exp))
+		},
+
+		Entry("valid", `{"type": "integer", "minimum": 1, "maximum": 1, "default": 1}`),
+
+		// ref-siblings
+
+		Entry("ref-siblings: draft-07", `{
+			"$schema": "http://json-schema.org/draft-07/schema#",
+			"$ref": "#/$defs/a",
+			"$defs": {"a": {}},
+			"properties": {"a": {"$ref": "#/$defs/a", "type": "string", "x-go-name": "A"}}
+		}`,
+			`/$ref: warning: draft-07 ignores keywords next to $ref: "properties" [ref-siblings]`,
+			`/properties/a/$ref: warning: draft-07 ignores keywords next to $ref: "type" [ref-siblings]`,
+		),
+		Entry("ref-siblings: 2020-12", `{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"$defs": {"a": {"$schema": "http://json-schema.org/draft-04/schema#", "type": "object"}},
+			"properties": {"a": {"$ref": "#/$defs/a", "type": "object"}}
+		}`),
+
+		// numeric-range
+
+		Entry("numeric-range: minimum > maximum", `{"minimum": 2, "maximum": 1.5}`,
+			"/minimum: error: minimum 2 and maximum 1.5 leave no valid numbers [numeric-range]"),
+		Entry("numeric-range: exclusive", `{"minimum": 0, "exclusiveMinimum": 1, "maximum": 1}`,
+			"/exclusiveMinimum: error: exclusiveMinimum 1 and maximum 1 leave no valid numbers [numeric-range]"),
+		Entry("numeric-range: tightest bounds", `{"minimum": 1, "exclusiveMinimum": 0, "maximum": 2, "exclusiveMaximum": 1}`,
+			"/minimum: error: minimum 1 and exclusiveMaximum 1 leave no valid numbers [numeric-range]"),
+
+		// length-range
+
+		Entry("length-range", `{"minLength": 2, "maxLength": 1, "minItems": 1, "maxItems": 1, "minProperties": 3, "maxProperties": 0}`,
+			"/minLength: error: minLength 2 is greater than maxLength 1 [length-range]",
+			"/minProperties: error: minProperties 3 is greater than maxProperties 0 [length-range]",
+		),
+
+		// invalid-pattern
+
+		Entry("invalid-pattern", `{"items": {"pattern": "a("}}`,
+			"/items/pattern: error: missing closing ): `a(` at offset 0 in \"a(\" [invalid-pattern]"),
+		Entry("invalid-pattern: ECMA-262", `{"pattern": "^\\p{L}+$"}`),
+
+		// format-type
+
+		Entry("format-type", `{"allOf": [{"type": ["integer", "null"], "format": "date"}, {"type": ["string", "null"], "format": "date"}]}`,
+			`/allOf/0/format: warning: format "date" applies to strings, but type is ["integer","null"] [format-type]`),
+
+		// required-undeclared
+
+		Entry("required-undeclared", `{
+			"properties": {"a": {}},
+			"allOf": [{"properties": {"b": {}}}],
+			"required": ["a", "b", "c"]
+		}`,
+			`/required/2: warning: property "c" isn't declared [required-undeclared]`,
+		),
+		Entry("required-undeclared: no properties", `{"required": ["a"]}`),
+		Entry("required-undeclared: additionalProperties", `{"properties": {}, "additionalProperties": {}, "required": ["a"]}`),
+		Entry("required-undeclared: referenced", `{"properties": {}, "allOf": [{"$ref": "a.json"}], "required": ["a"]}`),
+
+		// unused-defs
+
+		Entry("unused-defs", `{
+			"$id": "https://example.com/root.json",
+			"properties": {
+				"a": {"$ref": "#/$defs/a/properties/x"},
+				"b": {"$ref": "b.json"},
+				"c": {"$ref": "https://example.com/root.json#/$defs/c"}
+			},
+			"$defs": {
+				"a": {"properties": {"x": {}}},
+				"b": {"$id": "b.json"},
+				"c": {},
+				"d": {"items": {"$ref": "#/$defs/d"}, "$defs": {"e": {}}},
+				"f~g": {}
+			}
+		}`,
+			"/$defs/d: warning: isn't referenced within the document [unused-defs]",
+			"/$defs/d/$defs/e: warning: isn't referenced within the document [unused-defs]",
+			"/$defs/f~0g: warning: isn't referenced within the document [unused-defs]",
+		),
+
+		Entry("unused-defs: references within unknown keywords", `{
+			"oneOf": [{"$ref": "#/$defs/a"}, {"not": {"$ref": "#/$defs/b"}}],
+			"additionalProperties": {"$ref": "#/$defs/c"},
+			"$defs": {"a": {}, "b": {}, "c": {}, "d": {}}
+		}`,
+			"/$defs/d: warning: isn't referenced within the document [unused-defs]",
+		),
+		Entry("subschemas of unknown keywords", `{"anyOf": [{"minLength": 2, "maxLength": 1}], "patternProperties": {"^a": {"minimum": 2, "maximum": 1}}}`,
+			"/anyOf/0/minLength: error: minLength 2 is greater than maxLength 1 [length-range]",
+			"/patternProperties/^a/minimum: error: minimum 2 and maximum 1 leave no valid numbers [numeric-range]",
+		),
+
+		// invalid-default, invalid-example
+
+		Entry("invalid-default", `{
+			"properties": {
+				"a": {"type": "string", "maxLength": 2, "default": "abc"},
+				"b": {"$ref": "#/$defs/b", "default": 1}
+			},
+			"$defs": {"b": {"enum": ["x", 1]}}
+		}`,
+			"/properties/a/default: error: violates the schema: /: length 3 is greater than 2 [invalid-default]",
+		),
+		Entry("invalid-example", `{"type": "object", "properties": {"a": {"type": "integer"}}, "examples": [{"a": 1}, {"a": "1"}]}`,
+			"/examples/1: error: violates the schema: /a: expected integer, got string [invalid-example]"),
+		Entry("invalid-default: resource", `{
+			"$id": "https://example.com/root.json",
+			"$defs": {"a": {"$id": "a.json", "$ref": "#/$defs/b", "default": "x", "$defs": {"b": {"type": "integer"}}}},
+			"$ref": "a.json"
+		}`,
+			"/$defs/a/default: error: violates the schema: /: expected integer, got string [invalid-default]",
+		),
+		Entry("invalid-default: external reference", `{"$ref": "https://example.com/a.json", "default": 1}`),
+		Entry("invalid-default: unsupported keywords", `{
+			"properties": {
+				"a": {"type": "object", "additionalProperties": false, "default": {"b": 1}},
+				"b": {"$ref": "#/$defs/b", "default": 1},
+				"c": {"type": "integer", "default": 1, "examples": ["x"], "deprecated": true}
+			},
+			"$defs": {"b": {"oneOf": [{"type": "string"}]}}
+		}`,
+			"/properties/c/examples/0: error: violates the schema: /: expected integer, got string [invalid-example]",
+		),
+	)
+
+	Context("Documentation", func() {
+
+		It("reports missing $id, title and description", func() {
+			ps, err := lint.Lint(parse(`{
+				"properties": {"a": {"description": "A."}, "b": {"$ref": "#/$defs/c"}, "c": {}},
+				"$defs": {"c": {"title": "C"}}
+			}`))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(problems(ps)).To(Equal([]string{
+				"/$defs/c/description: info: schema has no description [missing-description]",
+				"/$id: info: schema has no $id [missing-id]",
+				"/description: info: schema has no description [missing-description]",
+				"/properties/c/description: info: schema has no description [missing-description]",
+				"/title: info: schema has no title [missing-title]",
+			}))
+		})
+	})
+
+	Context("Configuration", func() {
+
+		const schema = `{
+			"$id": "https://example.com/root.json",
+			"title": "Root",
+			"description": "Root.",
+			"properties": {
+				"a": {"description": "A.", "minLength": 2, "maxLength": 1, "minItems": 2, "maxItems": 1},
+				"b": {
+					"description": "B.",
+					"x-lint-ignore": ["length-range"],
+					"minLength": 2,
+					"maxLength": 1,
+					"items": {"minLength": 2, "maxLength": 1}
+				}
+			}
+		}`
+
+		It("sets severity", func() {
+			ps, err := lint.Lint(parse(schema), lint.SetSeverity("length-range", lint.Warning))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(problems(ps)).To(Equal([]string{
+				"/properties/a/minItems: warning: minItems 2 is greater than maxItems 1 [length-range]",
+				"/properties/a/minLength: warning: minLength 2 is greater than maxLength 1 [length-range]",
+			}))
+			Expect(ps.Max()).To(Equal(lint.Warning))
+		})
+
+		It("disables rules", func() {
+			ps, err := lint.Lint(parse(schema), lint.Disable("length-range"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ps).To(BeEmpty())
+			Expect(ps.Max()).To(Equal(lint.Off))
+		})
+
+		It("suppresses rules for the whole file", func() {
+			ps, err := lint.Lint(parse(`{"x-lint-ignore": ["missing-id", "missing-title", "missing-description", "length-range"], "properties": {"a": {"minLength": 1, "maxLength": 0}}}`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ps).To(BeEmpty())
+		})
+
+		DescribeTable("Errors",
+			func(schema string, opt lint.Option, msg string) {
+				_, err := lint.Lint(parse(schema), opt)
+				Expect(err).To(MatchError(msg))
+			},
+
+			Entry("unknown rule", `{}`, lint.Disable("none"), `unknown rule "none"`),
+			Entry("unknown ignored rule", `{"items": {"x-lint-ignore": ["none"]}}`, documentation,
+				`/items/x-lint-ignore: unknown rule "none"`),
+			Entry("invalid suppression", `{"x-lint-ignore": "pattern"}`, documentation,
+				"/x-lint-ignore: json: cannot unmarshal string into Go value of type []string"),
+		)
+	})
+
+	DescribeTable("ParseSeverity",
+		func(name string, exp lint.Severity, ok bool) {
+			s, err := lint.ParseSeverity(name)
+			Expect(err == nil).To(Equal(ok))
+			Expect(s).To(Equal(exp))
+		},
+
+		Entry("off", "off", lint.Off, true),
+		Entry("error", "error", lint.Error, true),
+		Entry("unknown", "fatal", lint.Off, false),
+	)
+
+	It("lists rules in order of their IDs", func() {
+		rules := lint.Rules()
+		Expect(rules).To(HaveLen(12))
+
+		for i := 1; i < len(rules); i++ {
+			Expect(rules[i-1].ID < rules[i].ID).To(BeTrue())
+		}
+	})
+})
@@ -0,0 +1,335 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ekhabarov/jsg/ast"
+	"github.com/ekhabarov/jsg/regex"
+	"github.com/ekhabarov/jsg/validate"
+)
+
+// Rule is a check of schemas.
+type Rule struct {
+	ID string
+	// Severity is a default severity of rule problems.
+	Severity    Severity
+	Description string
+
+	// check checks a single schema. Rules, which check the whole document,
+	// don't have it.
+	check func(l *linter, n node)
+}
+
+var rules = []Rule{
+	{"format-type", Warning, "format is set on a schema, which doesn't allow strings", checkFormatType},
+	{"invalid-default", Error, "default violates its schema, which uses only keywords validate supports", checkDefault},
+	{"invalid-example", Error, "an item of examples violates its schema, which uses only keywords validate supports", checkExamples},
+	{"invalid-pattern", Error, "pattern isn't a valid regular expression", checkPattern},
+	{"length-range", Error, "minLength, minItems or minProperties is greater than its maximum", checkLengths},
+	{"missing-description", Info, "the root schema, a $defs entry or a property has no description", checkDescription},
+	{"missing-id", Info, "the root schema has no $id", checkID},
+	{"missing-title", Info, "the root schema or a $defs entry has no title", checkTitle},
+	{"numeric-range", Error, "minimum and maximum leave no valid numbers", checkRange},
+	{"ref-siblings", Warning, "$ref has siblings, which drafts before 2019-09 ignore", checkRefSiblings},
+	{"required-undeclared", Warning, "required names a property, which isn't declared", checkRequired},
+	{"unused-defs", Warning, "a $defs entry isn't referenced within the document", nil},
+}
+
+// Rules returns all rules in order of their IDs.
+func Rules() []Rule {
+	return append([]Rule(nil), rules...)
+}
+
+// find returns a rule by its ID or nil.
+func find(id string) *Rule {
+	for i := range rules {
+		if rules[i].ID == id {
+			return &rules[i]
+		}
+	}
+
+	return nil
+}
+
+func checkID(l *linter, n node) {
+	if n.ptr == "" && n.s.ID == "" {
+		l.report(n, "/$id", "missing-id", "schema has no $id")
+	}
+}
+
+func checkTitle(l *linter, n node) {
+	if n.s.Title == "" && (n.ptr == "" || isDef(n.ptr)) {
+		l.report(n, n.ptr+"/title", "missing-title", "schema has no title")
+	}
+}
+
+func checkDescription(l *linter, n node) {
+	if n.s.Description == "" && n.s.Ref == "" && (n.ptr == "" || isDef(n.ptr) || isProperty(n.ptr)) {
+		l.report(n, n.ptr+"/description", "missing-description", "schema has no description")
+	}
+}
+
+// isDef reports whether JSON pointer ptr points to a $defs entry.
+func isDef(ptr string) bool {
+	return parent(ptr) == "$defs"
+}
+
+// isProperty reports whether JSON pointer ptr points to a property.
+func isProperty(ptr string) bool {
+	return parent(ptr) == "properties"
+}
+
+// parent returns the last but one token of JSON pointer ptr.
+func parent(ptr string) string {
+	tokens := strings.Split(ptr, "/")
+	if len(tokens) < 3 {
+		return ""
+	}
+
+	return tokens[len(tokens)-2]
+}
+
+// old matches URIs of drafts, where $ref overrides sibling keywords.
+var old = regexp.MustCompile(`draft-0[3-7]\b`)
+
+// ignorable are keywords, which don't change validation, if they're
+// ignored next to $ref.
+var ignorable = map[string]bool{
+	"$ref":     true,
+	"$schema":  true,
+	"$comment": true,
+	"$defs":    true,
+}
+
+func checkRefSiblings(l *linter, n node) {
+	d := old.FindString(n.draft)
+	if n.s.Ref == "" || d == "" {
+		return
+	}
+
+	b, err := json.Marshal(n.s)
+	if err != nil {
+		return
+	}
+
+	keywords := map[string]json.RawMessage{}
+	if err := json.Unmarshal(b, &keywords); err != nil {
+		return
+	}
+
+	siblings := []string{}
+	for k := range keywords {
+		if !ignorable[k] && !strings.HasPrefix(k, "x-") {
+			siblings = append(siblings, strconv.Quote(k))
+		}
+	}
+
+	if len(siblings) > 0 {
+		sort.Strings(siblings)
+
+		l.report(n, n.ptr+"/$ref", "ref-siblings", "%s ignores keywords next to $ref: %s", d, strings.Join(siblings, ", "))
+	}
+}
+
+// bound is a lower or an upper bound of numbers.
+type bound struct {
+	keyword   string
+	value     *ast.Decimal
+	exclusive bool
+}
+
+// tightest returns the tightest of inclusive and exclusive bounds, where
+// sign is 1 for lower bounds and -1 for upper ones.
+func tightest(sign int, incl, excl bound) *bound {
+	switch {
+	case incl.value == nil && excl.value == nil:
+		return nil
+	case incl.value == nil:
+		return &excl
+	case excl.value == nil:
+		return &incl
+	}
+
+	if incl.value.Rat().Cmp(excl.value.Rat())*sign > 0 {
+		return &incl
+	}
+
+	return &excl
+}
+
+func checkRange(l *linter, n node) {
+	lower := tightest(1, bound{"minimum", n.s.Minimum, false}, bound{"exclusiveMinimum", n.s.ExclusiveMinimum, true})
+	upper := tightest(-1, bound{"maximum", n.s.Maximum, false}, bound{"exclusiveMaximum", n.s.ExclusiveMaximum, true})
+
+	if lower == nil || upper == nil {
+		return
+	}
+
+	c := lower.value.Rat().Cmp(upper.value.Rat())
+	if c > 0 || c == 0 && (lower.exclusive || upper.exclusive) {
+		l.report(n, n.ptr+"/"+lower.keyword, "numeric-range", "%s %s and %s %s leave no valid numbers",
+			lower.keyword, lower.value, upper.keyword, upper.value)
+	}
+}
+
+func checkLengths(l *linter, n node) {
+	for _, r := range []struct {
+		min, max *uint32
+		keyword  string
+	}{
+		{n.s.MinLength, n.s.MaxLength, "Length"},
+		{n.s.MinItems, n.s.MaxItems, "Items"},
+		{n.s.MinProperties, n.s.MaxProperties, "Properties"},
+	} {
+		if r.min != nil && r.max != nil && *r.min > *r.max {
+			l.report(n, n.ptr+"/min"+r.keyword, "length-range", "min%s %d is greater than max%s %d",
+				r.keyword, *r.min, r.keyword, *r.max)
+		}
+	}
+}
+
+func checkPattern(l *linter, n node) {
+	if n.s.Pattern == "" {
+		return
+	}
+
+	if _, err := regex.Compile(n.s.Pattern); err != nil {
+		l.report(n, n.ptr+"/pattern", "invalid-pattern", "%v", err)
+	}
+}
+
+func checkFormatType(l *linter, n node) {
+	if n.s.Format == "" || n.s.Type == 0 || n.s.Type&ast.String != 0 {
+		return
+	}
+
+	t, err := json.Marshal(n.s.Type)
+	if err != nil {
+		return
+	}
+
+	l.report(n, n.ptr+"/format", "format-type", "format %q applies to strings, but type is %s", n.s.Format.Name(), t)
+}
+
+func checkRequired(l *linter, n node) {
+	if len(n.s.Required) == 0 {
+		return
+	}
+
+	// Properties matched by patterns or additionalProperties, or declared in
+	// referenced schemas aren't known.
+	if _, ok := n.s.Unknown["patternProperties"]; ok {
+		return
+	}
+
+	if a, ok := n.s.Unknown["additionalProperties"]; ok && string(bytes.TrimSpace(a)) != "false" {
+		return
+	}
+
+	declared := map[string]bool{}
+	found := n.s.Properties != nil
+
+	for name := range n.s.Properties {
+		declared[name] = true
+	}
+
+	for _, a := range n.s.AllOf {
+		if a.Ref != "" {
+			return
+		}
+
+		for name := range a.Properties {
+			declared[name] = true
+			found = true
+		}
+	}
+
+	if !found {
+		return
+	}
+
+	for i, name := range n.s.Required {
+		if !declared[name] {
+			l.report(n, n.ptr+"/required/"+strconv.Itoa(i), "required-undeclared", "property %q isn't declared", name)
+		}
+	}
+}
+
+func checkDefault(l *linter, n node) {
+	if d, ok := n.s.Unknown["default"]; ok {
+		l.instance(n, n.ptr+"/default", "invalid-default", d)
+	}
+}
+
+func checkExamples(l *linter, n node) {
+	raw, ok := n.s.Unknown["examples"]
+	if !ok {
+		return
+	}
+
+	var examples []json.RawMessage
+	if err := json.Unmarshal(raw, &examples); err != nil {
+		return
+	}
+
+	for i, e := range examples {
+		l.instance(n, n.ptr+"/examples/"+strconv.Itoa(i), "invalid-example", e)
+	}
+}
+
+// instance reports a problem of rule at ptr, if JSON value raw violates
+// schema n. Schemas, which can't be compiled, e.g. ones with external
+// references, or use keywords validate package doesn't support, e.g. oneOf,
+// aren't checked.
+func (l *linter) instance(n node, ptr, rule string, raw json.RawMessage) {
+	if l.severities[rule] == Off || n.ignored[rule] {
+		return
+	}
+
+	v, err := l.validator(n)
+	if err != nil {
+		return
+	}
+
+	i, err := validate.Decode(bytes.NewReader(raw))
+	if err != nil {
+		return
+	}
+
+	if err := v.ValidateValue(i); err != nil {
+		l.report(n, ptr, rule, "violates the schema: %v", err)
+	}
+}
+
+// synthetic is a base URI of documents without $id.
+const synthetic = "urn:jsg:lint"
+
+// validator compiles schema n within the document.
+func (l *linter) validator(n node) (*validate.Validator, error) {
+	root := *l.root
+
+	base := n.base
+	if root.ID == "" {
+		root.ID = synthetic
+
+		if base == "" {
+			base = synthetic
+		}
+	}
+
+	ref := base + "#" + url.PathEscape(strings.TrimPrefix(n.ptr, l.resources[n.base]))
+
+	v, err := validate.Compile(&ast.Schema{Ref: ref}, validate.WithResource(&root))
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile %s: %w", ref, err)
+	}
+
+	return v, nil
+}
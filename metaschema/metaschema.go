@@ -0,0 +1,568 @@
+// Package metaschema validates schema documents against official metaschemas
+// of JSON schema drafts, which are embedded into the package, so validation
+// works offline.
+//
+// https://json-schema.org/specification-links.html
+package metaschema
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"math/big"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ekhabarov/jsg/internal/jsonvalue"
+	"github.com/ekhabarov/jsg/lib"
+)
+
+// URIs of supported drafts, which are values of $schema.
+const (
+	Draft4    = "http://json-schema.org/draft-04/schema#"
+	Draft6    = "http://json-schema.org/draft-06/schema#"
+	Draft7    = "http://json-schema.org/draft-07/schema#"
+	Draft2019 = "https://json-schema.org/draft/2019-09/schema"
+	Draft2020 = "https://json-schema.org/draft/2020-12/schema"
+)
+
+//go:embed schemas
+var files embed.FS
+
+var (
+	load sync.Once
+	// resources maps URIs of metaschemas without fragments to decoded
+	// metaschemas.
+	resources map[string]interface{}
+	loadErr   error
+)
+
+// metaschemas returns all embedded metaschemas.
+func metaschemas() (map[string]interface{}, error) {
+	load.Do(func() {
+		resources = map[string]interface{}{}
+
+		loadErr = fs.WalkDir(files, "schemas", func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+
+			b, err := files.ReadFile(path)
+			if err != nil {
+				return err
+			}
+
+			s, err := decode(b)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+
+			m := s.(map[string]interface{})
+
+			id, _ := m["$id"].(string)
+			if id == "" {
+				id, _ = m["id"].(string)
+			}
+
+			resources[strings.TrimSuffix(id, "#")] = s
+
+			return nil
+		})
+	})
+
+	return resources, loadErr
+}
+
+// Error is a value of schema document, which violates its metaschema.
+type Error struct {
+	// Path is a JSON pointer to the value within the document.
+	Path    string
+	Message string
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("%s: %s", location(e.Path), e.Message)
+}
+
+// Errors are all violations of metaschema in order of their paths.
+type Errors []Error
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+func location(ptr string) string {
+	if ptr == "" {
+		return "/"
+	}
+
+	return ptr
+}
+
+// Validate validates JSON schema document b against the metaschema of the
+// draft set by its $schema. Documents without $schema are validated against
+// draft 2020-12. $schema of other dialects is reported as an error, while the
+// rest of the document is still validated against draft 2020-12. It returns
+// Errors, if the document is invalid.
+func Validate(b []byte) error {
+	doc, err := decode(b)
+	if err != nil {
+		return err
+	}
+
+	return ValidateValue(doc)
+}
+
+// ValidateValue validates decoded JSON schema document doc, where numbers are
+// json.Number or float64, like Validate does.
+func ValidateValue(doc interface{}) error {
+	ms, err := metaschemas()
+	if err != nil {
+		return err
+	}
+
+	uri := Draft2020
+	fails := []failure{}
+
+	if m, ok := doc.(map[string]interface{}); ok {
+		if s, ok := m["$schema"].(string); ok {
+			if _, ok := ms[strings.TrimSuffix(s, "#")]; ok {
+				uri = s
+			} else {
+				fails = append(fails, failure{Error: Error{Path: "/$schema", Message: fmt.Sprintf("unknown dialect %q", s)}})
+			}
+		}
+	}
+
+	uri = strings.TrimSuffix(uri, "#")
+
+	e := &evaluator{
+		resources: ms,
+		root:      ms[uri],
+		base:      uri,
+		draft4:    uri == strings.TrimSuffix(Draft4, "#"),
+	}
+
+	fails = append(fails, e.eval(e.root, uri, doc, "")...)
+	if len(fails) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(fails, func(i, j int) bool {
+		return fails[i].Path < fails[j].Path
+	})
+
+	errs := Errors{}
+	seen := map[Error]bool{}
+
+	for _, f := range fails {
+		if !seen[f.Error] {
+			seen[f.Error] = true
+			errs = append(errs, f.Error)
+		}
+	}
+
+	return errs
+}
+
+// decode decodes a single JSON value keeping numbers as json.Number.
+func decode(b []byte) (interface{}, error) {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// failure is an error of evaluation.
+type failure struct {
+	Error
+	// expected are types of the instance at Path, if it has a wrong type.
+	expected []string
+}
+
+// evaluator evaluates instances against metaschemas. It supports only
+// keywords, which metaschemas use.
+type evaluator struct {
+	resources map[string]interface{}
+	// root is the metaschema of the dialect, which $dynamicRef and
+	// $recursiveRef always resolve to, since it's the outermost dynamic scope.
+	root interface{}
+	base string
+	// draft4 makes exclusiveMinimum a boolean modifier of minimum.
+	draft4 bool
+	// patterns are compiled pattern keywords.
+	patterns sync.Map
+}
+
+// eval evaluates instance inst at JSON pointer ptr against schema s, whose
+// base URI is base.
+func (e *evaluator) eval(s interface{}, base string, inst interface{}, ptr string) []failure {
+	switch s := s.(type) {
+	case bool:
+		if !s {
+			return []failure{fail(ptr, "value is not allowed")}
+		}
+
+		return nil
+	case map[string]interface{}:
+		return e.keywords(s, base, inst, ptr)
+	}
+
+	return []failure{fail(ptr, "invalid metaschema")}
+}
+
+func fail(ptr, msg string, args ...interface{}) failure {
+	return failure{Error: Error{Path: ptr, Message: fmt.Sprintf(msg, args...)}}
+}
+
+func (e *evaluator) keywords(s map[string]interface{}, base string, inst interface{}, ptr string) []failure {
+	var fails []failure
+
+	if ref, ok := s["$ref"].(string); ok {
+		fails = append(fails, e.ref(base, ref, inst, ptr)...)
+	}
+
+	_, dynamic := s["$dynamicRef"]
+	if _, recursive := s["$recursiveRef"]; dynamic || recursive {
+		fails = append(fails, e.eval(e.root, e.base, inst, ptr)...)
+	}
+
+	if t, ok := s["type"]; ok {
+		if f, ok := e.types(t, inst, ptr); !ok {
+			return append(fails, f)
+		}
+	}
+
+	if enum, ok := s["enum"].([]interface{}); ok && !contains(enum, inst) {
+		fails = append(fails, e.enum(enum, inst, ptr))
+	}
+
+	fails = append(fails, e.number(s, inst, ptr)...)
+
+	if p, ok := s["pattern"].(string); ok {
+		if str, ok := inst.(string); ok && !e.pattern(p).MatchString(str) {
+			fails = append(fails, fail(ptr, "%q does not match pattern %q", str, p))
+		}
+	}
+
+	if a, ok := inst.([]interface{}); ok {
+		fails = append(fails, e.array(s, base, a, ptr)...)
+	}
+
+	if o, ok := inst.(map[string]interface{}); ok {
+		fails = append(fails, e.object(s, base, o, ptr)...)
+	}
+
+	if all, ok := s["allOf"].([]interface{}); ok {
+		for _, a := range all {
+			fails = append(fails, e.eval(a, base, inst, ptr)...)
+		}
+	}
+
+	if schemas, ok := s["anyOf"].([]interface{}); ok {
+		fails = append(fails, e.anyOf(schemas, base, inst, ptr)...)
+	}
+
+	return fails
+}
+
+// ref evaluates inst against the schema referenced by ref.
+func (e *evaluator) ref(base, ref string, inst interface{}, ptr string) []failure {
+	b, err := url.Parse(base)
+	if err != nil {
+		return []failure{fail(ptr, "invalid metaschema URI %q", base)}
+	}
+
+	r, err := url.Parse(ref)
+	if err != nil {
+		return []failure{fail(ptr, "invalid metaschema reference %q", ref)}
+	}
+
+	u := b.ResolveReference(r)
+	fragment := u.Fragment
+	u.Fragment, u.RawFragment = "", ""
+
+	s, ok := e.resources[u.String()]
+	if fragment != "" {
+		for _, t := range strings.Split(strings.TrimPrefix(fragment, "/"), "/") {
+			m, _ := s.(map[string]interface{})
+			s, ok = m[lib.UnescapePointer(t)]
+		}
+	}
+
+	if !ok {
+		return []failure{fail(ptr, "unknown metaschema %q", u.String()+"#"+fragment)}
+	}
+
+	return e.eval(s, u.String(), inst, ptr)
+}
+
+// types checks type of inst against type keyword t.
+func (e *evaluator) types(t interface{}, inst interface{}, ptr string) (failure, bool) {
+	names := []string{}
+
+	switch t := t.(type) {
+	case string:
+		names = append(names, t)
+	case []interface{}:
+		for _, n := range t {
+			if n, ok := n.(string); ok {
+				names = append(names, n)
+			}
+		}
+	}
+
+	actual := jsonvalue.Type(inst)
+	for _, n := range names {
+		if n == actual || n == "number" && actual == "integer" {
+			return failure{}, true
+		}
+	}
+
+	f := fail(ptr, "expected %s, got %s", list(names), actual)
+	f.expected = names
+
+	return f, false
+}
+
+// enum returns a failure of inst, which isn't one of enum values. It's a
+// wrong type, if none of the values has the type of inst.
+func (e *evaluator) enum(enum []interface{}, inst interface{}, ptr string) failure {
+	f := fail(ptr, "%s is not one of %s", value(inst), values(enum))
+
+	types := []string{}
+	seen := map[string]bool{}
+
+	for _, v := range enum {
+		t := jsonvalue.Type(v)
+		if t == "integer" {
+			t = "number"
+		}
+
+		if !seen[t] {
+			seen[t] = true
+			types = append(types, t)
+		}
+	}
+
+	if t := jsonvalue.Type(inst); !seen[t] && !(t == "integer" && seen["number"]) {
+		f.expected = types
+	}
+
+	return f
+}
+
+func (e *evaluator) number(s map[string]interface{}, inst interface{}, ptr string) []failure {
+	n := jsonvalue.Rat(inst)
+	if n == nil {
+		return nil
+	}
+
+	var fails []failure
+
+	exclusive, _ := s["exclusiveMinimum"].(bool)
+
+	if m := jsonvalue.Rat(s["minimum"]); m != nil {
+		switch c := n.Cmp(m); {
+		case exclusive && e.draft4 && c <= 0:
+			fails = append(fails, fail(ptr, "%s is less than or equal to %s", n.RatString(), m.RatString()))
+		case c < 0:
+			fails = append(fails, fail(ptr, "%s is less than %s", n.RatString(), m.RatString()))
+		}
+	}
+
+	if m := jsonvalue.Rat(s["exclusiveMinimum"]); m != nil && n.Cmp(m) <= 0 {
+		fails = append(fails, fail(ptr, "%s is less than or equal to %s", n.RatString(), m.RatString()))
+	}
+
+	return fails
+}
+
+// pattern returns compiled regular expression p. Patterns of metaschemas
+// are compatible with RE2.
+func (e *evaluator) pattern(p string) *regexp.Regexp {
+	if re, ok := e.patterns.Load(p); ok {
+		return re.(*regexp.Regexp)
+	}
+
+	re := regexp.MustCompile(p)
+	e.patterns.Store(p, re)
+
+	return re
+}
+
+func (e *evaluator) array(s map[string]interface{}, base string, a []interface{}, ptr string) []failure {
+	var fails []failure
+
+	if m := jsonvalue.Rat(s["minItems"]); m != nil && big.NewRat(int64(len(a)), 1).Cmp(m) < 0 {
+		fails = append(fails, fail(ptr, "%d items is less than %s", len(a), m.RatString()))
+	}
+
+	if u, _ := s["uniqueItems"].(bool); u {
+		for i := range a {
+			for j := i + 1; j < len(a); j++ {
+				if jsonvalue.Equal(a[i], a[j]) {
+					fails = append(fails, fail(ptr, "items at %d and %d are equal", i, j))
+				}
+			}
+		}
+	}
+
+	if items, ok := s["items"]; ok {
+		if _, ok := items.([]interface{}); !ok {
+			for i, v := range a {
+				fails = append(fails, e.eval(items, base, v, ptr+"/"+strconv.Itoa(i))...)
+			}
+		}
+	}
+
+	return fails
+}
+
+func (e *evaluator) object(s map[string]interface{}, base string, o map[string]interface{}, ptr string) []failure {
+	var fails []failure
+
+	props, _ := s["properties"].(map[string]interface{})
+	additional, hasAdditional := s["additionalProperties"]
+	names, hasNames := s["propertyNames"]
+	deps, _ := s["dependencies"].(map[string]interface{})
+
+	keys := make([]string, 0, len(o))
+	for k := range o {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		p := ptr + "/" + lib.EscapePointer(k)
+
+		if hasNames {
+			fails = append(fails, e.eval(names, base, k, p)...)
+		}
+
+		if ps, ok := props[k]; ok {
+			fails = append(fails, e.eval(ps, base, o[k], p)...)
+		} else if hasAdditional {
+			fails = append(fails, e.eval(additional, base, o[k], p)...)
+		}
+
+		switch d := deps[k].(type) {
+		case nil:
+		case []interface{}:
+			for _, r := range d {
+				if r, ok := r.(string); ok {
+					if _, ok := o[r]; !ok {
+						fails = append(fails, fail(p, "requires property %q", r))
+					}
+				}
+			}
+		default:
+			fails = append(fails, e.eval(d, base, o, ptr)...)
+		}
+	}
+
+	return fails
+}
+
+// anyOf evaluates inst against schemas of anyOf keyword. If all of them
+// fail, it reports failures of the only schema, which inst has a type of, or
+// a failure of all types.
+func (e *evaluator) anyOf(schemas []interface{}, base string, inst interface{}, ptr string) []failure {
+	var (
+		candidates [][]failure
+		expected   []string
+	)
+
+	for _, a := range schemas {
+		fails := e.eval(a, base, inst, ptr)
+		if len(fails) == 0 {
+			return nil
+		}
+
+		if mismatch(fails, ptr) == nil {
+			candidates = append(candidates, fails)
+		} else {
+			expected = append(expected, mismatch(fails, ptr)...)
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		f := fail(ptr, "expected %s, got %s", list(expected), jsonvalue.Type(inst))
+		f.expected = expected
+
+		return []failure{f}
+	case 1:
+		return candidates[0]
+	}
+
+	return []failure{fail(ptr, "value doesn't match any of allowed schemas")}
+}
+
+// mismatch returns expected types, if failures fails include a wrong type of
+// the instance at ptr.
+func mismatch(fails []failure, ptr string) []string {
+	for _, f := range fails {
+		if f.Path == ptr && f.expected != nil {
+			return f.expected
+		}
+	}
+
+	return nil
+}
+
+func contains(vv []interface{}, v interface{}) bool {
+	for _, w := range vv {
+		if jsonvalue.Equal(v, w) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// list joins names, e.g. "object, boolean or array".
+func list(names []string) string {
+	if len(names) < 2 {
+		return strings.Join(names, "")
+	}
+
+	return strings.Join(names[:len(names)-1], ", ") + " or " + names[len(names)-1]
+}
+
+// value returns JSON representation of v.
+func value(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return string(b)
+}
+
+func values(vv []interface{}) string {
+	ss := make([]string, len(vv))
+	for i, v := range vv {
+		ss[i] = value(v)
+	}
+
+	return list(ss)
+}
@@ -0,0 +1,13 @@
+package metaschema_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestMetaschema(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Metaschema Suite")
+}
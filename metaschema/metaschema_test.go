@@ -0,0 +1,119 @@
+package metaschema_test
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/ekhabarov/jsg/metaschema"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Metaschema", func() {
+
+	DescribeTable("Validate",
+		func(schema, msg string) {
+			err := metaschema.Validate([]byte(schema))
+			if msg == "" {
+				Expect(err).NotTo(HaveOccurred())
+
+				return
+			}
+
+			Expect(err).To(MatchError(msg))
+		},
+
+		// 2020-12
+
+		Entry("valid", `{
+			"$id": "https://example.com/a.json#",
+			"type": ["string", "null"],
+			"maxLength": 1,
+			"properties": {"a": {"$ref": "#/$defs/a"}, "b": false},
+			"$defs": {"a": {"$anchor": "a", "minimum": 1.5}},
+			"x-go-name": "A"
+		}`, ""),
+		Entry("boolean schema", `true`, ""),
+		Entry("not a schema", `1`, "/: expected object or boolean, got integer"),
+		Entry("wrong type", `{"maxLength": "abc"}`, "/maxLength: expected integer, got string"),
+		Entry("negative length", `{"minLength": -1}`, "/minLength: -1 is less than 0"),
+		Entry("multipleOf", `{"multipleOf": 0}`, "/multipleOf: 0 is less than or equal to 0"),
+		Entry("$id with fragment", `{"$id": "a.json#b"}`, `/$id: "a.json#b" does not match pattern "^[^#]*#?$"`),
+		Entry("unknown type", `{"type": ["string", "text"]}`,
+			`/type/1: "text" is not one of "array", "boolean", "integer", "null", "number", "object" or "string"`),
+		Entry("wrong type of type", `{"type": {}}`, "/type: expected string or array, got object"),
+		Entry("duplicate types", `{"type": ["string", "string"]}`, "/type: items at 0 and 1 are equal"),
+		Entry("empty allOf", `{"allOf": []}`, "/allOf: 0 items is less than 1"),
+		Entry("nested", `{"items": {"properties": {"a~/b": {"required": "a"}}}}`,
+			"/items/properties/a~0~1b/required: expected array, got string"),
+		Entry("$defs", `{"$defs": {"a": {"enum": 1}, "b": 1}}`,
+			"/$defs/a/enum: expected array, got integer; /$defs/b: expected object or boolean, got integer"),
+		Entry("deprecated keywords", `{"definitions": {"a": 1}, "dependencies": {"a": 1}}`,
+			"/definitions/a: expected object or boolean, got integer; /dependencies/a: expected object, boolean or array, got integer"),
+		Entry("unknown dialect", `{"$schema": "https://example.com/schema", "minItems": true}`,
+			`/$schema: unknown dialect "https://example.com/schema"; /minItems: expected integer, got boolean`),
+
+		// 2019-09
+
+		Entry("2019-09", `{
+			"$schema": "https://json-schema.org/draft/2019-09/schema",
+			"$recursiveAnchor": true,
+			"items": [{"$recursiveRef": "#"}, {"minContains": -1}]
+		}`, "/items/1/minContains: -1 is less than 0"),
+
+		// draft-07
+
+		Entry("draft-07", `{
+			"$schema": "http://json-schema.org/draft-07/schema#",
+			"definitions": {"a": {"if": {"const": 1}, "then": 1}},
+			"dependencies": {"a": ["b", "b"]}
+		}`, "/definitions/a/then: expected object or boolean, got integer; /dependencies/a: items at 0 and 1 are equal"),
+
+		// draft-06
+
+		Entry("draft-06", `{"$schema": "http://json-schema.org/draft-06/schema", "exclusiveMinimum": true}`,
+			"/exclusiveMinimum: expected number, got boolean"),
+
+		// draft-04
+
+		Entry("draft-04", `{"$schema": "http://json-schema.org/draft-04/schema#", "exclusiveMinimum": true, "minimum": 1}`, ""),
+		Entry("draft-04: dependencies", `{"$schema": "http://json-schema.org/draft-04/schema#", "exclusiveMaximum": true}`,
+			`/exclusiveMaximum: requires property "maximum"`),
+		Entry("draft-04: boolean schema", `{"$schema": "http://json-schema.org/draft-04/schema#", "items": true}`,
+			"/items: expected object or array, got boolean"),
+	)
+
+	It("reports all problems", func() {
+		err := metaschema.Validate([]byte(`{"minLength": -1, "properties": {"a": {"type": 1}, "b": {"pattern": 1}}}`))
+
+		var errs metaschema.Errors
+		Expect(errors.As(err, &errs)).To(BeTrue())
+		Expect(errs).To(Equal(metaschema.Errors{
+			{Path: "/minLength", Message: "-1 is less than 0"},
+			{Path: "/properties/a/type", Message: "expected string or array, got integer"},
+			{Path: "/properties/b/pattern", Message: "expected string, got integer"},
+		}))
+	})
+
+	It("fails on invalid JSON", func() {
+		Expect(metaschema.Validate([]byte(`{`))).To(MatchError("unexpected EOF"))
+	})
+
+	It("validates metaschemas", func() {
+		err := filepath.WalkDir("schemas", func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+
+			b, err := os.ReadFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(metaschema.Validate(b)).To(Succeed(), path)
+
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
@@ -0,0 +1,79 @@
+package regex
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// span is an inclusive range of code points.
+type span struct {
+	lo, hi rune
+}
+
+// set is a sorted list of non-overlapping spans.
+type set []span
+
+var (
+	digits = set{{'0', '9'}}
+	word   = set{{'0', '9'}, {'A', 'Z'}, {'_', '_'}, {'a', 'z'}}
+	// space is WhiteSpace and LineTerminator as defined by ECMA-262, which is
+	// wider than RE2's \s.
+	space = set{
+		{'\t', '\r'}, {' ', ' '}, {0xA0, 0xA0}, {0x1680, 0x1680}, {0x2000, 0x200A},
+		{0x2028, 0x2029}, {0x202F, 0x202F}, {0x205F, 0x205F}, {0x3000, 0x3000}, {0xFEFF, 0xFEFF},
+	}
+)
+
+// classEscapes maps character class escapes to their sets.
+var classEscapes = map[rune]set{
+	'd': digits,
+	'D': digits.complement(),
+	'w': word,
+	'W': word.complement(),
+	's': space,
+	'S': space.complement(),
+}
+
+// complement returns a set of all code points which aren't in s.
+func (s set) complement() set {
+	c := set{}
+	next := rune(0)
+
+	for _, sp := range s {
+		if sp.lo > next {
+			c = append(c, span{next, sp.lo - 1})
+		}
+
+		next = sp.hi + 1
+	}
+
+	if next <= utf8.MaxRune {
+		c = append(c, span{next, utf8.MaxRune})
+	}
+
+	return c
+}
+
+// String returns the set as a content of RE2 character class.
+func (s set) String() string {
+	b := strings.Builder{}
+
+	for _, sp := range s {
+		b.WriteString(char(sp.lo))
+
+		if sp.hi != sp.lo {
+			b.WriteString("-" + char(sp.hi))
+		}
+	}
+
+	return b.String()
+}
+
+func char(r rune) string {
+	if r < utf8.RuneSelf && (isLetter(byte(r)) || isDigit(byte(r)) || r == '_') {
+		return string(r)
+	}
+
+	return fmt.Sprintf(`\x{%X}`, r)
+}
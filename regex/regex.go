@@ -0,0 +1,537 @@
+// Package regex translates ECMA-262 regular expressions, which JSON schema
+// uses for "pattern", "patternProperties" and "regex" format, into RE2 syntax
+// supported by Go's regexp package.
+//
+// Constructs with different semantics, like ".", "\s" or "[^]", are rewritten
+// into RE2 equivalents, while constructs RE2 can't express, like lookarounds
+// and backreferences, are reported as errors with their offsets.
+package regex
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Error is returned for patterns which are invalid or can't be translated.
+type Error struct {
+	Pattern string
+	// Offset is a byte offset of the construct in Pattern.
+	Offset int
+	Msg    string
+	// Unsupported is set for valid ECMA-262 constructs, which have no RE2
+	// equivalent.
+	Unsupported bool
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s at offset %d in %q", e.Msg, e.Offset, e.Pattern)
+}
+
+// Compile translates ECMA-262 pattern and compiles it. Offsets of errors
+// found by RE2 point to constructs of pattern they're translated from.
+func Compile(pattern string) (*regexp.Regexp, error) {
+	t := &translator{src: pattern}
+
+	if err := t.run(); err != nil {
+		return nil, err
+	}
+
+	re, err := regexp.Compile(t.out.String())
+	if err != nil {
+		e := &Error{
+			Pattern: pattern,
+			Msg:     strings.TrimPrefix(err.Error(), "error parsing regexp: "),
+		}
+
+		var se *syntax.Error
+		if errors.As(err, &se) {
+			e.Offset = t.source(strings.Index(t.out.String(), se.Expr))
+		}
+
+		return nil, e
+	}
+
+	return re, nil
+}
+
+// MustCompile is like Compile but panics if the pattern can't be compiled.
+func MustCompile(pattern string) *regexp.Regexp {
+	re, err := Compile(pattern)
+	if err != nil {
+		panic(err)
+	}
+
+	return re
+}
+
+// Translate translates ECMA-262 pattern into RE2 syntax.
+func Translate(pattern string) (string, error) {
+	t := &translator{src: pattern}
+
+	if err := t.run(); err != nil {
+		return "", err
+	}
+
+	return t.out.String(), nil
+}
+
+type translator struct {
+	src string
+	pos int
+	out strings.Builder
+	// marks map offsets in out to offsets of constructs in src they're
+	// translated from, in order of both.
+	marks []mark
+}
+
+// mark is an offset of translated construct in output and source.
+type mark struct {
+	out, src int
+}
+
+// mark records that output written next is translated from the construct at
+// current position.
+func (t *translator) mark() {
+	t.marks = append(t.marks, mark{out: t.out.Len(), src: t.pos})
+}
+
+// source returns an offset in src of the construct translated into output at
+// offset off.
+func (t *translator) source(off int) int {
+	src := 0
+
+	for _, m := range t.marks {
+		if m.out > off {
+			break
+		}
+
+		src = m.src
+	}
+
+	return src
+}
+
+func (t *translator) errorf(offset int, msg string, args ...interface{}) error {
+	return &Error{Pattern: t.src, Offset: offset, Msg: fmt.Sprintf(msg, args...)}
+}
+
+func (t *translator) unsupported(offset int, msg string) error {
+	return &Error{Pattern: t.src, Offset: offset, Msg: msg, Unsupported: true}
+}
+
+func (t *translator) run() error {
+	for t.pos < len(t.src) {
+		t.mark()
+
+		start := t.pos
+		c := t.src[t.pos]
+
+		switch c {
+		case '\\':
+			s, err := t.escape(false)
+			if err != nil {
+				return err
+			}
+
+			t.out.WriteString(s)
+
+			continue
+		case '.':
+			// ECMA-262 dot doesn't match any line terminator, RE2 one doesn't
+			// match \n only.
+			t.out.WriteString(`[^\n\r\x{2028}\x{2029}]`)
+		case '(':
+			if err := t.group(); err != nil {
+				return err
+			}
+
+			continue
+		case '[':
+			if err := t.class(); err != nil {
+				return err
+			}
+
+			continue
+		case '{':
+			if q := quantifier(t.src[t.pos:]); q != "" {
+				t.out.WriteString(q)
+				t.pos += len(q)
+
+				continue
+			}
+
+			// Annex B allows braces as literals.
+			t.out.WriteString(`\{`)
+		case '}':
+			t.out.WriteString(`\}`)
+		default:
+			_, n := utf8.DecodeRuneInString(t.src[t.pos:])
+			t.out.WriteString(t.src[t.pos : t.pos+n])
+			t.pos += n
+
+			continue
+		}
+
+		t.pos = start + 1
+	}
+
+	return nil
+}
+
+// group translates group opening.
+func (t *translator) group() error {
+	rest := t.src[t.pos:]
+
+	switch {
+	case strings.HasPrefix(rest, "(?="), strings.HasPrefix(rest, "(?!"):
+		return t.unsupported(t.pos, "lookahead is not supported")
+	case strings.HasPrefix(rest, "(?<="), strings.HasPrefix(rest, "(?<!"):
+		return t.unsupported(t.pos, "lookbehind is not supported")
+	case strings.HasPrefix(rest, "(?<"):
+		end := strings.IndexByte(rest, '>')
+		if end < 0 {
+			return t.errorf(t.pos, "unterminated group name")
+		}
+
+		name := rest[3:end]
+		if !groupName(name) {
+			return t.errorf(t.pos+3, "unsupported group name %q", name)
+		}
+
+		t.out.WriteString("(?P<" + name + ">")
+		t.pos += end + 1
+	case strings.HasPrefix(rest, "(?:"):
+		t.out.WriteString("(?:")
+		t.pos += 3
+	case strings.HasPrefix(rest, "(?"):
+		return t.errorf(t.pos, "invalid group")
+	default:
+		t.out.WriteByte('(')
+		t.pos++
+	}
+
+	return nil
+}
+
+// class translates character class.
+func (t *translator) class() error {
+	start := t.pos
+	t.pos++
+
+	negated := strings.HasPrefix(t.src[t.pos:], "^")
+	if negated {
+		t.pos++
+	}
+
+	// Empty class matches nothing, negated empty class matches anything.
+	if strings.HasPrefix(t.src[t.pos:], "]") {
+		t.pos++
+
+		if negated {
+			t.out.WriteString(`[\x00-\x{10FFFF}]`)
+		} else {
+			t.out.WriteString(`[^\x00-\x{10FFFF}]`)
+		}
+
+		return nil
+	}
+
+	t.out.WriteByte('[')
+	if negated {
+		t.out.WriteByte('^')
+	}
+
+	// escaped reports whether the previous atom is a class escape, e.g. \d.
+	escaped := false
+
+	for t.pos < len(t.src) {
+		t.mark()
+
+		switch c := t.src[t.pos]; c {
+		case ']':
+			t.out.WriteByte(']')
+			t.pos++
+
+			return nil
+		case '\\':
+			at := t.pos
+
+			s, err := t.escape(true)
+			if err != nil {
+				return err
+			}
+
+			t.out.WriteString(s)
+
+			escaped = classEscape(t.src[at:])
+
+			continue
+		case '-':
+			// Annex B: a class escape at either end makes a range a literal
+			// dash, e.g. [a-\d] matches "a", "-" and digits.
+			if escaped || classEscape(t.src[t.pos+1:]) {
+				t.out.WriteString(`\-`)
+			} else {
+				t.out.WriteByte('-')
+			}
+
+			t.pos++
+		case '[':
+			t.out.WriteString(`\[`)
+			t.pos++
+		default:
+			_, n := utf8.DecodeRuneInString(t.src[t.pos:])
+			t.out.WriteString(t.src[t.pos : t.pos+n])
+			t.pos += n
+		}
+
+		escaped = false
+	}
+
+	return t.errorf(start, "unterminated character class")
+}
+
+// classEscape reports whether s starts with a character class escape, e.g.
+// \d or \p{L}.
+func classEscape(s string) bool {
+	if len(s) < 2 || s[0] != '\\' {
+		return false
+	}
+
+	_, ok := classEscapes[rune(s[1])]
+
+	return ok || s[1] == 'p' || s[1] == 'P'
+}
+
+// escape translates escape sequence at current position, inside a character
+// class if class is set.
+func (t *translator) escape(class bool) (string, error) {
+	start := t.pos
+	t.pos++
+
+	if t.pos == len(t.src) {
+		return "", t.errorf(start, "trailing backslash")
+	}
+
+	c, n := utf8.DecodeRuneInString(t.src[t.pos:])
+	t.pos += n
+
+	// Character class escapes are expanded into ranges, so they're usable
+	// inside classes too.
+	if set, ok := classEscapes[c]; ok {
+		if class {
+			return set.String(), nil
+		}
+
+		return "[" + set.String() + "]", nil
+	}
+
+	switch c {
+	case 'b':
+		if class {
+			return `\x08`, nil
+		}
+
+		return `\b`, nil
+	case 'B':
+		if class {
+			return "", t.errorf(start, `\B is not allowed in character class`)
+		}
+
+		return `\B`, nil
+	case 't', 'n', 'r', 'f':
+		return `\` + string(c), nil
+	case 'v':
+		return `\x0B`, nil
+	case '0':
+		if t.pos < len(t.src) && isDigit(t.src[t.pos]) {
+			return "", t.unsupported(start, "octal escape is not supported")
+		}
+
+		return `\x00`, nil
+	case '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		return "", t.unsupported(start, "backreference is not supported")
+	case 'k':
+		if strings.HasPrefix(t.src[t.pos:], "<") {
+			return "", t.unsupported(start, "backreference is not supported")
+		}
+	case 'c':
+		if t.pos < len(t.src) && isLetter(t.src[t.pos]) {
+			t.pos++
+
+			return fmt.Sprintf(`\x%02X`, t.src[t.pos-1]%32), nil
+		}
+
+		// Annex B: backslash is a literal if \c isn't followed by a letter.
+		t.pos--
+
+		return `\\`, nil
+	case 'x':
+		if h := hex(t.src[t.pos:], 2); h != "" {
+			t.pos += 2
+
+			return `\x{` + h + `}`, nil
+		}
+	case 'u':
+		return t.unicodeEscape(start)
+	case 'p', 'P':
+		return t.property(start, c)
+	}
+
+	// Identity escape. RE2 allows escaping of ASCII punctuation only.
+	if c < utf8.RuneSelf && !isLetter(byte(c)) && !isDigit(byte(c)) && c != '_' {
+		return `\` + string(c), nil
+	}
+
+	return string(c), nil
+}
+
+// unicodeEscape translates \uXXXX, \u{X...} and surrogate pairs.
+func (t *translator) unicodeEscape(start int) (string, error) {
+	rest := t.src[t.pos:]
+
+	if strings.HasPrefix(rest, "{") {
+		end := strings.IndexByte(rest, '}')
+		if end < 2 || hex(rest[1:end], end-1) == "" {
+			return "", t.errorf(start, `invalid \u{...} escape`)
+		}
+
+		cp, err := strconv.ParseUint(rest[1:end], 16, 32)
+		if err != nil || cp > utf8.MaxRune {
+			return "", t.errorf(start, "code point is out of range")
+		}
+
+		t.pos += end + 1
+
+		return fmt.Sprintf(`\x{%X}`, cp), nil
+	}
+
+	h := hex(rest, 4)
+	if h == "" {
+		// Annex B: identity escape.
+		return "u", nil
+	}
+
+	t.pos += 4
+
+	r, _ := strconv.ParseUint(h, 16, 32)
+
+	if r >= 0xD800 && r <= 0xDBFF && strings.HasPrefix(t.src[t.pos:], `\u`) {
+		if l := hex(t.src[t.pos+2:], 4); l != "" {
+			lo, _ := strconv.ParseUint(l, 16, 32)
+
+			if lo >= 0xDC00 && lo <= 0xDFFF {
+				t.pos += 6
+
+				return fmt.Sprintf(`\x{%X}`, (r-0xD800)<<10+(lo-0xDC00)+0x10000), nil
+			}
+		}
+	}
+
+	if r >= 0xD800 && r <= 0xDFFF {
+		return "", t.unsupported(start, "lone surrogate is not supported")
+	}
+
+	return fmt.Sprintf(`\x{%X}`, r), nil
+}
+
+// property translates Unicode property escape, e.g. \p{Script=Greek}.
+func (t *translator) property(start int, c rune) (string, error) {
+	rest := t.src[t.pos:]
+
+	end := strings.IndexByte(rest, '}')
+	if !strings.HasPrefix(rest, "{") || end < 0 {
+		return "", t.errorf(start, "invalid property escape")
+	}
+
+	name := rest[1:end]
+
+	if i := strings.IndexByte(name, '='); i >= 0 {
+		switch name[:i] {
+		case "General_Category", "gc", "Script", "sc", "Script_Extensions", "scx":
+			name = name[i+1:]
+		default:
+			return "", t.errorf(start, "unsupported property %q", name[:i])
+		}
+	}
+
+	if _, err := regexp.Compile(`\p{` + name + `}`); err != nil {
+		return "", t.errorf(start, "unsupported property %q", name)
+	}
+
+	t.pos += end + 1
+
+	return `\` + string(c) + `{` + name + `}`, nil
+}
+
+// quantifier returns {n}, {n,} or {n,m} quantifier at the start of s, or empty
+// string if there is no quantifier.
+func quantifier(s string) string {
+	i := 1
+
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+
+	if i == 1 || i == len(s) {
+		return ""
+	}
+
+	if s[i] == ',' {
+		i++
+
+		for i < len(s) && isDigit(s[i]) {
+			i++
+		}
+	}
+
+	if i == len(s) || s[i] != '}' {
+		return ""
+	}
+
+	return s[:i+1]
+}
+
+// hex returns n leading hex digits of s, or empty string if there are less
+// than n.
+func hex(s string, n int) string {
+	if len(s) < n {
+		return ""
+	}
+
+	for i := 0; i < n; i++ {
+		if !isDigit(s[i]) && !(s[i] >= 'a' && s[i] <= 'f') && !(s[i] >= 'A' && s[i] <= 'F') {
+			return ""
+		}
+	}
+
+	return s[:n]
+}
+
+// groupName reports whether name is supported by RE2.
+func groupName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for i := 0; i < len(name); i++ {
+		if !isLetter(name[i]) && !isDigit(name[i]) && name[i] != '_' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,13 @@
+package regex_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestRegex(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Regex Suite")
+}
@@ -0,0 +1,110 @@
+package regex_test
+
+import (
+	"github.com/ekhabarov/jsg/regex"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Regex", func() {
+
+	Context("Translate", func() {
+
+		DescribeTable("Translations",
+			func(pattern, exp string) {
+				got, err := regex.Translate(pattern)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(got).To(Equal(exp))
+			},
+
+			Entry("plain", `^[a-z]+(?:-[a-z]+)*$`, `^[a-z]+(?:-[a-z]+)*$`),
+			Entry("dot", `a.b`, `a[^\n\r\x{2028}\x{2029}]b`),
+			Entry("digits", `\d+`, `[0-9]+`),
+			Entry("digits in class", `[\dA-F]`, `[0-9A-F]`),
+			Entry("non-word", `\W`, `[\x{0}-\x{2F}\x{3A}-\x{40}\x{5B}-\x{5E}\x{60}\x{7B}-\x{10FFFF}]`),
+			Entry("named group", `(?<year>\d{4})`, `(?P<year>[0-9]{4})`),
+			Entry("control escape", `\cJ`, `\x0A`),
+			Entry("control escape without letter", `\c1`, `\\c1`),
+			Entry("vertical tab", `\v`, `\x0B`),
+			Entry("null", `\0`, `\x00`),
+			Entry("hex", `\x41`, `\x{41}`),
+			Entry("unicode", `\u00E9`, `\x{E9}`),
+			Entry("unicode code point", `\u{1F600}`, `\x{1F600}`),
+			Entry("surrogate pair", `\uD83D\uDE00`, `\x{1F600}`),
+			Entry("property", `\p{Script=Greek}`, `\p{Greek}`),
+			Entry("identity escape", `\/\-\a`, `\/\-a`),
+			Entry("backspace in class", `[\b]`, `[\x08]`),
+			Entry("empty class", `[]`, `[^\x00-\x{10FFFF}]`),
+			Entry("negated empty class", `[^]`, `[\x00-\x{10FFFF}]`),
+			Entry("bracket in class", `[[]`, `[\[]`),
+			Entry("class escape at range end", `[a-\d]`, `[a\-0-9]`),
+			Entry("class escape at range start", `[\d-z]`, `[0-9\-z]`),
+			Entry("quantifier", `a{2,3}`, `a{2,3}`),
+			Entry("literal braces", `a{,3}}`, `a\{,3\}\}`),
+		)
+
+		DescribeTable("Errors",
+			func(pattern string, offset int, msg string) {
+				_, err := regex.Translate(pattern)
+
+				var e *regex.Error
+				Expect(err).To(BeAssignableToTypeOf(e))
+
+				e = err.(*regex.Error)
+				Expect(e.Offset).To(Equal(offset))
+				Expect(e.Msg).To(Equal(msg))
+			},
+
+			Entry("lookahead", `a(?=b)`, 1, "lookahead is not supported"),
+			Entry("negative lookahead", `a(?!b)`, 1, "lookahead is not supported"),
+			Entry("lookbehind", `ab(?<=b)`, 2, "lookbehind is not supported"),
+			Entry("backreference", `(a)\1`, 3, "backreference is not supported"),
+			Entry("named backreference", `(?<x>a)\k<x>`, 7, "backreference is not supported"),
+			Entry("octal", `\01`, 0, "octal escape is not supported"),
+			Entry("lone surrogate", `a\uD83D`, 1, "lone surrogate is not supported"),
+			Entry("unterminated class", `a[bc`, 1, "unterminated character class"),
+			Entry("trailing backslash", `ab\`, 2, "trailing backslash"),
+			Entry("property", `\p{Foo}`, 0, `unsupported property "Foo"`),
+		)
+	})
+
+	Context("Compile", func() {
+
+		DescribeTable("Matches",
+			func(pattern, s string, match bool) {
+				Expect(regex.MustCompile(pattern).MatchString(s)).To(Equal(match))
+			},
+
+			Entry("dot doesn't match CR", `^a.b$`, "a\rb", false),
+			Entry("dot matches other chars", `^a.b$`, "a-b", true),
+			Entry("whitespace includes NBSP", `^\s$`, " ", true),
+			Entry("whitespace includes vertical tab", `^\s$`, "\v", true),
+			Entry("non-whitespace", `^\S+$`, "abc", true),
+			Entry("digits are ASCII only", `^\d$`, "٣", false),
+			Entry("negated class with escape", `^[^\s\d]+$`, "ab", true),
+			Entry("negated class with escape, no match", `^[^\s\d]+$`, "a1", false),
+			Entry("class escape in range is a dash", `^[a-\d]+$`, "a-1", true),
+			Entry("class escape in range, no match", `^[a-\d]+$`, "b", false),
+		)
+
+		DescribeTable("Errors",
+			func(pattern string, offset int, msg string) {
+				_, err := regex.Compile(pattern)
+
+				var e *regex.Error
+				Expect(err).To(BeAssignableToTypeOf(e))
+
+				e = err.(*regex.Error)
+				Expect(e.Offset).To(Equal(offset))
+				Expect(e.Msg).To(ContainSubstring(msg))
+			},
+
+			Entry("repeat count", `a{1001}`, 1, "invalid repeat count"),
+			Entry("reversed repeat count", `x{2,1}`, 1, "invalid repeat count"),
+			Entry("after translated construct", `.\d{2,1}`, 3, "invalid repeat count"),
+			Entry("class range", `ab[z-a]`, 3, "invalid character class range"),
+		)
+	})
+
+})
@@ -0,0 +1,12 @@
+//go:build go1.22
+// +build go1.22
+
+package reverse
+
+import "go/types"
+
+// unalias returns the type denoted by alias t, e.g. encoding/json.RawMessage
+// is an alias of jsontext.Value with encoding/json v2.
+func unalias(t types.Type) types.Type {
+	return types.Unalias(t)
+}
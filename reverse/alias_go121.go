@@ -0,0 +1,11 @@
+//go:build !go1.22
+// +build !go1.22
+
+package reverse
+
+import "go/types"
+
+// unalias returns t, since type checker doesn't keep aliases before Go 1.22.
+func unalias(t types.Type) types.Type {
+	return t
+}
@@ -0,0 +1,699 @@
+// Package reverse generates JSON schemas from Go types, which are marshaled
+// with encoding/json.
+package reverse
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	goast "go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/ekhabarov/jsg/ast"
+	"github.com/iancoleman/strcase"
+)
+
+// Draft is a URI of JSON schema dialect of generated schemas.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Config defines schema generation.
+type Config struct {
+	// BaseURI is a prefix of $id of generated schemas, e.g.
+	// "https://example.com/schemas/". $id of type UserAccount is
+	// BaseURI + "user_account.json".
+	BaseURI string
+	// Flatten copies properties of embedded structs into the schema of the
+	// embedding one instead of referencing them with allOf.
+	Flatten bool
+}
+
+// Option configures Config.
+type Option func(*Config)
+
+// BaseURI sets Config.BaseURI.
+func BaseURI(uri string) Option {
+	return func(c *Config) {
+		c.BaseURI = uri
+	}
+}
+
+// Flatten sets Config.Flatten.
+func Flatten() Option {
+	return func(c *Config) {
+		c.Flatten = true
+	}
+}
+
+// Schemas loads Go package pkg, which is an import path or a directory
+// relative to the current one, e.g. "./models", with the go command and
+// returns a schema for every exported struct type of the package, in order
+// of declaration.
+func Schemas(pkg string, opts ...Option) ([]*ast.Schema, error) {
+	c := &Config{}
+	for _, o := range opts {
+		o(c)
+	}
+
+	p, err := load(pkg)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{c: c, pkg: p.types, docs: p.docs, inline: map[*types.Named]bool{}}
+
+	ss := []*ast.Schema{}
+
+	for _, n := range p.names {
+		named, ok := p.types.Scope().Lookup(n).Type().(*types.Named)
+		if !ok {
+			continue
+		}
+
+		st, ok := named.Underlying().(*types.Struct)
+		if !ok {
+			continue
+		}
+
+		s := &ast.Schema{
+			Schema:      Draft,
+			ID:          c.BaseURI + fileName(n),
+			Title:       n,
+			Description: p.docs[named.Obj().Pos()],
+			Type:        ast.Object,
+		}
+
+		if err := g.object(st, s); err != nil {
+			return nil, fmt.Errorf("%s: %w", n, err)
+		}
+
+		ss = append(ss, s)
+	}
+
+	return ss, nil
+}
+
+// fileName returns a name of schema file of type n, e.g. "user_account.json".
+func fileName(n string) string {
+	return strcase.ToSnake(n) + ".json"
+}
+
+// pkg is a loaded Go package.
+type pkg struct {
+	types *types.Package
+	// names are exported type names in order of declaration.
+	names []string
+	// docs are doc comments of types and struct fields by position of their
+	// names.
+	docs map[token.Pos]string
+}
+
+// listed is a package listed by go list.
+type listed struct {
+	ImportPath string
+	Dir        string
+	// CompiledGoFiles are Go files passed to the compiler, including ones
+	// generated by cgo from CgoFiles.
+	CompiledGoFiles []string
+	// ImportMap maps import paths in the source to resolved ones, e.g. of
+	// vendored packages.
+	ImportMap map[string]string
+	Export    string
+	DepOnly   bool
+	Error     *struct {
+		Err string
+	}
+}
+
+// load parses and type-checks Go package path with export data of its
+// dependencies built by go list. It doesn't use golang.org/x/tools/go/packages,
+// which does the same, since its versions supporting Go 1.17 can't read
+// export data of recent Go releases.
+func load(path string) (*pkg, error) {
+	out, err := exec.Command("go", "list", "-export", "-compiled", "-deps", "-json", "--", path).Output()
+	if err != nil {
+		var ee *exec.ExitError
+		if errors.As(err, &ee) {
+			err = errors.New(strings.TrimSpace(string(ee.Stderr)))
+		}
+
+		return nil, fmt.Errorf("failed to list package %s: %w", path, err)
+	}
+
+	var (
+		target  *listed
+		exports = map[string]string{}
+		d       = json.NewDecoder(bytes.NewReader(out))
+	)
+
+	for d.More() {
+		l := &listed{}
+		if err := d.Decode(l); err != nil {
+			return nil, fmt.Errorf("failed to list package %s: %w", path, err)
+		}
+
+		if l.Error != nil {
+			return nil, fmt.Errorf("failed to load package %s: %s", l.ImportPath, l.Error.Err)
+		}
+
+		exports[l.ImportPath] = l.Export
+
+		if !l.DepOnly {
+			target = l
+		}
+	}
+
+	if target == nil {
+		return nil, fmt.Errorf("package %s not found", path)
+	}
+
+	fset := token.NewFileSet()
+	files := []*goast.File{}
+
+	for _, f := range target.CompiledGoFiles {
+		// Files generated by cgo are in the build cache.
+		if !filepath.IsAbs(f) {
+			f = filepath.Join(target.Dir, f)
+		}
+
+		af, err := parser.ParseFile(fset, f, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, af)
+	}
+
+	conf := types.Config{Importer: importer.ForCompiler(fset, "gc", func(path string) (io.ReadCloser, error) {
+		if p, ok := target.ImportMap[path]; ok {
+			path = p
+		}
+
+		f, ok := exports[path]
+		if !ok || f == "" {
+			return nil, fmt.Errorf("no export data for %s", path)
+		}
+
+		return os.Open(f)
+	})}
+
+	tp, err := conf.Check(target.ImportPath, fset, files, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load package %s: %w", path, err)
+	}
+
+	p := &pkg{types: tp, docs: map[token.Pos]string{}}
+
+	for _, f := range files {
+		p.collect(f)
+	}
+
+	return p, nil
+}
+
+// collect collects exported type names declared at package level and doc
+// comments of file f. Types declared in function bodies aren't in the package
+// scope, so they're skipped.
+func (p *pkg) collect(f *goast.File) {
+	for _, d := range f.Decls {
+		gd, ok := d.(*goast.GenDecl)
+		if !ok {
+			continue
+		}
+
+		for _, s := range gd.Specs {
+			if ts, ok := s.(*goast.TypeSpec); ok && ts.Name.IsExported() {
+				p.names = append(p.names, ts.Name.Name)
+			}
+		}
+	}
+
+	goast.Inspect(f, func(n goast.Node) bool {
+		switch n := n.(type) {
+		case *goast.GenDecl:
+			for _, s := range n.Specs {
+				ts, ok := s.(*goast.TypeSpec)
+				if !ok {
+					continue
+				}
+
+				doc := ts.Doc
+				if doc == nil && len(n.Specs) == 1 {
+					doc = n.Doc
+				}
+
+				p.doc(ts.Name, doc)
+			}
+		case *goast.Field:
+			doc := n.Doc
+			if doc == nil {
+				doc = n.Comment
+			}
+
+			for _, id := range n.Names {
+				p.doc(id, doc)
+			}
+		}
+
+		return true
+	})
+}
+
+func (p *pkg) doc(id *goast.Ident, doc *goast.CommentGroup) {
+	if doc == nil {
+		return
+	}
+
+	p.docs[id.Pos()] = strings.TrimSpace(doc.Text())
+}
+
+// generator builds schemas of types of a package.
+type generator struct {
+	c    *Config
+	pkg  *types.Package
+	docs map[token.Pos]string
+	// inline are named types, which are being inlined into a schema, to
+	// detect recursive ones.
+	inline map[*types.Named]bool
+}
+
+// object adds properties of struct st to object schema s. Properties of
+// structs embedded by pointer aren't required. Embedded local structs are
+// referenced with allOf, unless Flatten is set or some of their fields are
+// dominated by other ones, see fields.
+func (g *generator) object(st *types.Struct, s *ast.Schema) error {
+	fs := g.fields(st, nil, false, map[*types.Named]bool{})
+
+	// referenced are indexes of embedded fields referenced with allOf.
+	referenced := map[int]bool{}
+
+	for i := 0; i < st.NumFields() && !g.c.Flatten; i++ {
+		named, ptr, ok := g.embedded(st, i)
+		if !ok || ptr || !g.local(named) {
+			continue
+		}
+
+		own := g.fields(named.Underlying().(*types.Struct), nil, false, map[*types.Named]bool{named: true})
+
+		n := 0
+		for _, f := range fs {
+			if f.index[0] == i {
+				n++
+			}
+		}
+
+		// Fields of the referenced schema must be the ones encoded, otherwise
+		// allOf has conflicting or extra properties.
+		if n == len(own) {
+			s.AllOf = append(s.AllOf, ast.Schema{Ref: fileName(named.Obj().Name())})
+			referenced[i] = true
+		}
+	}
+
+	for _, f := range fs {
+		if referenced[f.index[0]] {
+			continue
+		}
+
+		p, err := g.schema(f.v.Type())
+		if err != nil {
+			return fmt.Errorf("field %s: %w", f.v.Name(), err)
+		}
+
+		if hasOption(f.opts, "string") && quotable(p) {
+			p = ast.Schema{Type: ast.String}
+		}
+
+		p.Description = g.docs[f.v.Pos()]
+
+		if s.Properties == nil {
+			s.Properties = map[string]ast.Schema{}
+		}
+
+		s.Properties[f.name] = p
+		s.PropertyOrder = append(s.PropertyOrder, f.name)
+
+		// Fields omitted by encoding/json, e.g. Optional wrappers generated
+		// with omitzero, aren't required.
+		omitted := hasOption(f.opts, "omitempty") || hasOption(f.opts, "omitzero")
+
+		if _, ptr := unalias(f.v.Type()).(*types.Pointer); !f.optional && !ptr && !omitted {
+			s.Required = append(s.Required, f.name)
+		}
+	}
+
+	return nil
+}
+
+// field is a struct field, which encoding/json encodes.
+type field struct {
+	v    *types.Var
+	name string
+	opts string
+	// tagged reports whether the name is set by json tag.
+	tagged bool
+	// index is a sequence of field indexes starting from the outer struct,
+	// like reflect.StructField.Index.
+	index []int
+	// optional reports whether the field belongs to a struct embedded by
+	// pointer.
+	optional bool
+}
+
+// fields returns fields of struct st, including ones of embedded structs, in
+// order of their indexes, where index is the index of st, optional reports
+// whether st is embedded by pointer and visited are structs, which st is
+// embedded into. Fields with the same name follow encoding/json rules: the
+// shallowest one dominates, tagged one dominates untagged ones at the same
+// depth, otherwise all of them are dropped.
+func (g *generator) fields(st *types.Struct, index []int, optional bool, visited map[*types.Named]bool) []field {
+	all := []field{}
+
+	for i := 0; i < st.NumFields(); i++ {
+		f := st.Field(i)
+
+		tag := reflect.StructTag(st.Tag(i)).Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, opts := tag, ""
+		if j := strings.Index(tag, ","); j >= 0 {
+			name, opts = tag[:j], tag[j+1:]
+		}
+
+		idx := append(append([]int(nil), index...), i)
+
+		if named, ptr, ok := g.embedded(st, i); ok && name == "" {
+			// Fields of a struct embedded into itself are always dominated.
+			if !visited[named] {
+				visited[named] = true
+				all = append(all, g.fields(named.Underlying().(*types.Struct), idx, optional || ptr, visited)...)
+				delete(visited, named)
+			}
+
+			continue
+		}
+
+		if !f.Exported() {
+			continue
+		}
+
+		tagged := name != ""
+		if !tagged {
+			name = f.Name()
+		}
+
+		all = append(all, field{v: f, name: name, opts: opts, tagged: tagged, index: idx, optional: optional})
+	}
+
+	if index != nil {
+		return all
+	}
+
+	return dominant(all)
+}
+
+// dominant returns fields, which dominate others with the same name.
+func dominant(all []field) []field {
+	byName := map[string][]field{}
+	for _, f := range all {
+		byName[f.name] = append(byName[f.name], f)
+	}
+
+	fs := []field{}
+
+	for _, f := range all {
+		if winner(byName[f.name]) == f.v {
+			fs = append(fs, f)
+		}
+	}
+
+	return fs
+}
+
+// winner returns the field, which dominates fields with the same name, or
+// nil if all of them are dropped.
+func winner(same []field) *types.Var {
+	depth := len(same[0].index)
+	for _, f := range same {
+		if len(f.index) < depth {
+			depth = len(f.index)
+		}
+	}
+
+	var top, tagged []field
+
+	for _, f := range same {
+		if len(f.index) != depth {
+			continue
+		}
+
+		top = append(top, f)
+		if f.tagged {
+			tagged = append(tagged, f)
+		}
+	}
+
+	switch {
+	case len(top) == 1:
+		return top[0].v
+	case len(tagged) == 1:
+		return tagged[0].v
+	}
+
+	return nil
+}
+
+// embedded returns a struct type of i-th field of st, if it's an embedded
+// struct, which encoding/json encodes as a part of st, and whether it's
+// embedded by pointer.
+func (g *generator) embedded(st *types.Struct, i int) (*types.Named, bool, bool) {
+	f := st.Field(i)
+	if !f.Embedded() {
+		return nil, false, false
+	}
+
+	t, ptr := unalias(f.Type()), false
+	if p, ok := t.(*types.Pointer); ok {
+		t, ptr = unalias(p.Elem()), true
+	}
+
+	named, ok := t.(*types.Named)
+	if !ok {
+		return nil, false, false
+	}
+
+	if _, ok := named.Underlying().(*types.Struct); !ok || g.known(named) {
+		return nil, false, false
+	}
+
+	return named, ptr, true
+}
+
+// local reports whether named type t gets its own schema.
+func (g *generator) local(t *types.Named) bool {
+	return t.Obj().Pkg() == g.pkg && t.Obj().Exported() && t.Obj().Parent() == g.pkg.Scope()
+}
+
+// schema returns a schema of values of Go type t.
+func (g *generator) schema(t types.Type) (ast.Schema, error) {
+	t = unalias(t)
+
+	if p, ok := t.(*types.Pointer); ok {
+		return g.schema(p.Elem())
+	}
+
+	if s, ok := g.recognized(t); ok {
+		return s, nil
+	}
+
+	named, _ := t.(*types.Named)
+
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		return basic(u)
+	case *types.Slice:
+		if b, ok := u.Elem().Underlying().(*types.Basic); ok && b.Kind() == types.Byte && !marshaler(u.Elem()) {
+			// encoding/json represents []byte as base64 string.
+			return ast.Schema{Type: ast.String}, nil
+		}
+
+		return g.array(u.Elem(), nil)
+	case *types.Array:
+		n := uint32(u.Len())
+
+		return g.array(u.Elem(), &n)
+	case *types.Map:
+		return ast.Schema{Type: ast.Object}, nil
+	case *types.Interface:
+		return ast.Schema{}, nil
+	case *types.Struct:
+		if named != nil && g.local(named) {
+			return ast.Schema{Ref: fileName(named.Obj().Name())}, nil
+		}
+
+		if named != nil {
+			if g.inline[named] {
+				return ast.Schema{}, fmt.Errorf("recursive type %s can't be inlined", named.Obj().Name())
+			}
+
+			g.inline[named] = true
+			defer delete(g.inline, named)
+		}
+
+		s := ast.Schema{Type: ast.Object}
+
+		return s, g.object(u, &s)
+	}
+
+	return ast.Schema{}, fmt.Errorf("unsupported type %s", t)
+}
+
+func (g *generator) array(elem types.Type, n *uint32) (ast.Schema, error) {
+	items, err := g.schema(elem)
+	if err != nil {
+		return ast.Schema{}, err
+	}
+
+	return ast.Schema{Type: ast.Array, Items: &items, MinItems: n, MaxItems: n}, nil
+}
+
+// formats are formats of recognized types by import path and type name.
+var formats = map[string]ast.StringFormat{
+	"time.Time":                          ast.FormatDateTime,
+	"github.com/google/uuid.UUID":        ast.FormatUUID,
+	"github.com/gofrs/uuid.UUID":         ast.FormatUUID,
+	"github.com/ekhabarov/jsg/rt.Date":   ast.FormatDate,
+	"github.com/ekhabarov/jsg/rt.URL":    ast.FormatURI,
+	"github.com/ekhabarov/jsg/rt.IPv4":   ast.FormatIPv4,
+	"github.com/ekhabarov/jsg/rt.IPv6":   ast.FormatIPv6,
+	"github.com/ekhabarov/jsg/rt.Regexp": ast.FormatRegex,
+
+	"github.com/ekhabarov/jsg/rt.TimeOfDay":   ast.FormatTime,
+	"github.com/ekhabarov/jsg/rt.ISODuration": ast.FormatDuration,
+}
+
+// schemaTypes are schema types of recognized types, which aren't strings.
+var schemaTypes = map[string]ast.SchemaType{
+	"encoding/json.Number":                 ast.Number,
+	"math/big.Int":                         ast.Integer,
+	"github.com/ekhabarov/jsg/rt.BigFloat": ast.Number,
+}
+
+// recognized returns a schema of t, if it's one of well-known types or
+// marshals itself.
+func (g *generator) recognized(t types.Type) (ast.Schema, bool) {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return ast.Schema{}, false
+	}
+
+	if f, ok := formats[qualified(named)]; ok {
+		return ast.Schema{Type: ast.String, Format: f}, true
+	}
+
+	if st, ok := schemaTypes[qualified(named)]; ok {
+		return ast.Schema{Type: st}, true
+	}
+
+	switch {
+	case hasMethod(named, "MarshalJSON"):
+		// Any JSON value.
+		return ast.Schema{}, true
+	case hasMethod(named, "MarshalText"):
+		return ast.Schema{Type: ast.String}, true
+	}
+
+	return ast.Schema{}, false
+}
+
+// known reports whether named type t is recognized rather than a struct.
+func (g *generator) known(t *types.Named) bool {
+	_, ok := g.recognized(t)
+
+	return ok
+}
+
+// qualified returns a type name qualified by import path, e.g. "time.Time".
+func qualified(t *types.Named) string {
+	if t.Obj().Pkg() == nil {
+		return t.Obj().Name()
+	}
+
+	return t.Obj().Pkg().Path() + "." + t.Obj().Name()
+}
+
+// hasMethod reports whether t or *t has method name.
+func hasMethod(t types.Type, name string) bool {
+	return types.NewMethodSet(types.NewPointer(t)).Lookup(nil, name) != nil
+}
+
+// marshaler reports whether t marshals itself.
+func marshaler(t types.Type) bool {
+	return hasMethod(t, "MarshalJSON") || hasMethod(t, "MarshalText")
+}
+
+// basic returns a schema of basic type b. Integers, which are narrower than
+// 64 bits, get bounds of their type.
+func basic(b *types.Basic) (ast.Schema, error) {
+	info := b.Info()
+
+	switch {
+	case info&types.IsBoolean != 0:
+		return ast.Schema{Type: ast.Boolean}, nil
+	case info&types.IsString != 0:
+		return ast.Schema{Type: ast.String}, nil
+	case info&types.IsFloat != 0:
+		return ast.Schema{Type: ast.Number}, nil
+	case info&types.IsInteger == 0:
+		return ast.Schema{}, fmt.Errorf("unsupported type %s", b)
+	}
+
+	s := ast.Schema{Type: ast.Integer}
+
+	switch b.Kind() {
+	case types.Int8:
+		s.Minimum, s.Maximum = ast.NewDecimal(-1<<7), ast.NewDecimal(1<<7-1)
+	case types.Int16:
+		s.Minimum, s.Maximum = ast.NewDecimal(-1<<15), ast.NewDecimal(1<<15-1)
+	case types.Int32:
+		s.Minimum, s.Maximum = ast.NewDecimal(-1<<31), ast.NewDecimal(1<<31-1)
+	case types.Uint8:
+		s.Minimum, s.Maximum = ast.NewDecimal(0), ast.NewDecimal(1<<8-1)
+	case types.Uint16:
+		s.Minimum, s.Maximum = ast.NewDecimal(0), ast.NewDecimal(1<<16-1)
+	case types.Uint32:
+		s.Minimum, s.Maximum = ast.NewDecimal(0), ast.NewDecimal(1<<32-1)
+	case types.Uint, types.Uint64, types.Uintptr:
+		s.Minimum = ast.NewDecimal(0)
+	}
+
+	return s, nil
+}
+
+// quotable reports whether ",string" tag option applies to values of schema
+// s, which is the case for strings, numbers and booleans.
+func quotable(s ast.Schema) bool {
+	return s.Ref == "" && s.Format == "" && (s.Type == ast.String || s.Type == ast.Number || s.Type == ast.Integer || s.Type == ast.Boolean)
+}
+
+// hasOption reports whether comma-separated tag options opts contain o.
+func hasOption(opts, o string) bool {
+	for _, v := range strings.Split(opts, ",") {
+		if v == o {
+			return true
+		}
+	}
+
+	return false
+}
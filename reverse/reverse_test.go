@@ -0,0 +1,206 @@
+package reverse_test
+
+import (
+	"encoding/json"
+
+	"github.com/ekhabarov/jsg/ast"
+	"github.com/ekhabarov/jsg/reverse"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+// properties of UserAccount, which don't depend on embedding.
+const properties = `
+	"name": {"type": "string"},
+	"email": {"type": "string"},
+	"age": {"type": "integer", "minimum": -32768, "maximum": 32767},
+	"score": {"type": "number"},
+	"balance": {"type": "string"},
+	"birthday": {"type": "string", "format": "date"},
+	"tags": {"type": "array", "items": {"type": "string"}},
+	"key": {
+		"type": "array",
+		"items": {"type": "integer", "minimum": 0, "maximum": 255},
+		"minItems": 2,
+		"maxItems": 2
+	},
+	"avatar": {"type": "string"},
+	"labels": {"type": "object"},
+	"extra": {},
+	"amount": {"type": "number"},
+	"friends": {"type": "array", "items": {"$ref": "user_account.json"}},
+	"address": {
+		"type": "object",
+		"properties": {"city": {"type": "string", "description": "City name."}},
+		"required": ["city"]
+	},
+	"Active": {"type": "boolean"},
+	"-": {"type": "string"},
+	"author": {"type": "string"},
+	"reviewer": {"type": "string"},
+	"version": {"type": "integer", "minimum": 0, "maximum": 255}`
+
+var _ = Describe("Reverse", func() {
+
+	Context("Schemas", func() {
+
+		DescribeTable("Models",
+			func(i int, exp string, opts ...reverse.Option) {
+				ss, err := reverse.Schemas("./testdata/models", append(opts, reverse.BaseURI("https://example.com/"))...)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ss).To(HaveLen(3))
+
+				b, err := json.Marshal(ss[i])
+				Expect(err).NotTo(HaveOccurred())
+				Expect(b).To(MatchJSON(exp))
+			},
+
+			Entry("doc comments", 0, `{
+				"$schema": "https://json-schema.org/draft/2020-12/schema",
+				"$id": "https://example.com/entity.json",
+				"title": "Entity",
+				"description": "Entity is a base of stored objects.",
+				"type": "object",
+				"properties": {
+					"id": {"type": "string", "description": "ID is a unique identifier."},
+					"created": {"type": "string", "format": "date-time"}
+				},
+				"required": ["id", "created"]
+			}`),
+
+			Entry("omitempty and omitzero", 1, `{
+				"$schema": "https://json-schema.org/draft/2020-12/schema",
+				"$id": "https://example.com/audit.json",
+				"title": "Audit",
+				"description": "Audit keeps the author of changes.",
+				"type": "object",
+				"properties": {"author": {"type": "string"}, "reviewer": {"type": "string"}}
+			}`),
+
+			Entry("allOf", 2, `{
+				"$schema": "https://json-schema.org/draft/2020-12/schema",
+				"$id": "https://example.com/user_account.json",
+				"title": "UserAccount",
+				"description": "UserAccount is a registered user.",
+				"type": "object",
+				"allOf": [{"$ref": "entity.json"}],
+				"properties": {`+properties+`},
+				"required": [
+					"version", "name", "score", "balance", "birthday", "key", "avatar",
+					"labels", "extra", "amount", "friends", "address", "Active", "-"
+				]
+			}`),
+
+			Entry("flatten", 2, `{
+				"$schema": "https://json-schema.org/draft/2020-12/schema",
+				"$id": "https://example.com/user_account.json",
+				"title": "UserAccount",
+				"description": "UserAccount is a registered user.",
+				"type": "object",
+				"properties": {
+					"id": {"type": "string", "description": "ID is a unique identifier."},
+					"created": {"type": "string", "format": "date-time"},`+properties+`
+				},
+				"required": [
+					"id", "created",
+					"version", "name", "score", "balance", "birthday", "key", "avatar",
+					"labels", "extra", "amount", "friends", "address", "Active", "-"
+				]
+			}`, reverse.Flatten()),
+		)
+
+		DescribeTable("Fields with the same name",
+			func(i int, exp string, opts ...reverse.Option) {
+				ss, err := reverse.Schemas("./testdata/conflicts", opts...)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ss).To(HaveLen(4))
+
+				b, err := json.Marshal(ss[i])
+				Expect(err).NotTo(HaveOccurred())
+				Expect(b).To(MatchJSON(exp))
+			},
+
+			Entry("shadowed field isn't referenced with allOf", 1, `{
+				"$schema": "https://json-schema.org/draft/2020-12/schema",
+				"$id": "outer.json",
+				"title": "Outer",
+				"description": "Outer shadows name of Base.",
+				"type": "object",
+				"properties": {"kind": {"type": "string"}, "name": {"type": "integer"}},
+				"required": ["kind", "name"]
+			}`),
+
+			Entry("shadowed field is flattened once", 1, `{
+				"$schema": "https://json-schema.org/draft/2020-12/schema",
+				"$id": "outer.json",
+				"title": "Outer",
+				"description": "Outer shadows name of Base.",
+				"type": "object",
+				"properties": {"kind": {"type": "string"}, "name": {"type": "integer"}},
+				"required": ["kind", "name"]
+			}`, reverse.Flatten()),
+
+			Entry("ambiguous fields are dropped", 2, `{
+				"$schema": "https://json-schema.org/draft/2020-12/schema",
+				"$id": "both.json",
+				"title": "Both",
+				"description": "Both has ambiguous id, which encoding/json drops.",
+				"type": "object",
+				"properties": {"left": {"type": "string"}, "right": {"type": "string"}},
+				"required": ["left", "right"]
+			}`),
+
+			Entry("tagged field dominates", 3, `{
+				"$schema": "https://json-schema.org/draft/2020-12/schema",
+				"$id": "tagged.json",
+				"title": "Tagged",
+				"description": "Tagged has Code of tagged, which dominates untagged one.",
+				"type": "object",
+				"properties": {"Code": {"type": "string"}},
+				"required": ["Code"]
+			}`),
+		)
+
+		It("builds ast schemas", func() {
+			ss, err := reverse.Schemas("./testdata/models")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(ss[1]).To(Equal(&ast.Schema{
+				Schema:      reverse.Draft,
+				ID:          "audit.json",
+				Title:       "Audit",
+				Description: "Audit keeps the author of changes.",
+				Type:        ast.Object,
+				Properties:  map[string]ast.Schema{"author": {Type: ast.String}, "reviewer": {Type: ast.String}},
+
+				PropertyOrder: []string{"author", "reviewer"},
+			}))
+		})
+
+		It("loads packages with cgo files", func() {
+			ss, err := reverse.Schemas("./testdata/cgo")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ss).To(HaveLen(1))
+			Expect(ss[0].Description).To(Equal("Answer is a type declared in a cgo file."))
+			Expect(ss[0].Required).To(Equal([]string{"value"}))
+		})
+
+		It("skips types declared in function bodies", func() {
+			ss, err := reverse.Schemas("./testdata/local")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ss).To(HaveLen(1))
+			Expect(ss[0].Title).To(Equal("Total"))
+		})
+
+		DescribeTable("Errors",
+			func(pkg, msg string) {
+				_, err := reverse.Schemas(pkg)
+				Expect(err).To(MatchError(ContainSubstring(msg)))
+			},
+
+			Entry("unsupported type", "./testdata/invalid", "Events: field C: unsupported type chan int"),
+			Entry("unknown package", "./testdata/none", "failed to list package ./testdata/none"),
+		)
+	})
+})
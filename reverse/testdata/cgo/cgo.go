@@ -0,0 +1,15 @@
+// Package cgo is a test input of reverse package with cgo files.
+package cgo
+
+// int answer(void) { return 42; }
+import "C"
+
+// Answer is a type declared in a cgo file.
+type Answer struct {
+	Value int `json:"value"`
+}
+
+// Get calls C code, so the package can't be type-checked without cgo.
+func Get() Answer {
+	return Answer{Value: int(C.answer())}
+}
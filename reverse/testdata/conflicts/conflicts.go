@@ -0,0 +1,45 @@
+// Package conflicts is a test input of reverse package with fields, which
+// have the same JSON names.
+package conflicts
+
+// Base has fields, which are shadowed by embedding structs.
+type Base struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+}
+
+// Outer shadows name of Base.
+type Outer struct {
+	Base
+	Name int `json:"name"`
+}
+
+type left struct {
+	ID   string `json:"id"`
+	Left string `json:"left"`
+}
+
+type right struct {
+	ID    int    `json:"id"`
+	Right string `json:"right"`
+}
+
+// Both has ambiguous id, which encoding/json drops.
+type Both struct {
+	left
+	right
+}
+
+type tagged struct {
+	Value string `json:"Code"`
+}
+
+type untagged struct {
+	Code int
+}
+
+// Tagged has Code of tagged, which dominates untagged one.
+type Tagged struct {
+	untagged
+	tagged
+}
@@ -0,0 +1,7 @@
+// Package invalid has types, which can't be marshaled to JSON.
+package invalid
+
+// Events has a channel field.
+type Events struct {
+	C chan int `json:"c"`
+}
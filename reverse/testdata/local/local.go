@@ -0,0 +1,17 @@
+// Package local is a test input of reverse package with types declared in
+// function bodies.
+package local
+
+// Total is a package level type.
+type Total struct {
+	Sum int `json:"sum"`
+}
+
+// Count declares a type in its body, which isn't in the package scope.
+func Count() Total {
+	type Local struct {
+		A int
+	}
+
+	return Total{Sum: Local{A: 1}.A}
+}
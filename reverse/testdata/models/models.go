@@ -0,0 +1,55 @@
+// Package models is a test input of reverse package.
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/ekhabarov/jsg/rt"
+)
+
+// Entity is a base of stored objects.
+type Entity struct {
+	// ID is a unique identifier.
+	ID      string    `json:"id"`
+	Created time.Time `json:"created"`
+}
+
+// Audit keeps the author of changes.
+type Audit struct {
+	Author   string `json:"author,omitempty"`
+	Reviewer string `json:"reviewer,omitzero"`
+}
+
+type meta struct {
+	Version uint8 `json:"version"`
+}
+
+// UserAccount is a registered user.
+type UserAccount struct {
+	Entity
+	*Audit
+	meta
+
+	Name     string            `json:"name"`
+	Email    *string           `json:"email"`
+	Age      int16             `json:"age,omitempty"`
+	Score    float64           `json:"score"`
+	Balance  int64             `json:"balance,string"`
+	Birthday rt.Date           `json:"birthday"`
+	Tags     []string          `json:"tags,omitempty"`
+	Key      [2]byte           `json:"key"`
+	Avatar   []byte            `json:"avatar"`
+	Labels   map[string]string `json:"labels"`
+	Extra    json.RawMessage   `json:"extra"`
+	Amount   json.Number       `json:"amount"`
+	Friends  []*UserAccount    `json:"friends"`
+	Address  struct {
+		City string `json:"city"` // City name.
+	} `json:"address"`
+
+	Active   bool
+	Internal string `json:"-"`
+	Dash     string `json:"-,"`
+	secret   string
+}
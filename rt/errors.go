@@ -0,0 +1,155 @@
+// Package rt is a runtime support package for code generated by jsg.
+package rt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ValidationError describes a value, which violates a schema constraint.
+type ValidationError struct {
+	// Path is a JSON pointer to the value within validated instance, empty
+	// string for the instance itself.
+	Path string `json:"path"`
+	// Keyword is a name of violated schema keyword, e.g. "maxLength".
+	Keyword string `json:"keyword"`
+	// Expected is a value of the keyword, e.g. 5 for "maxLength": 5. It's
+	// always encoded, so zero values aren't lost.
+	Expected interface{} `json:"expected"`
+	// Actual is a checked value or its checked property, e.g. string length
+	// for "maxLength".
+	Actual  interface{} `json:"actual"`
+	Message string      `json:"message"`
+	// Err is an underlying error, e.g. returned by format checker.
+	Err error `json:"-"`
+}
+
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return "/: " + e.Message
+	}
+
+	return e.Path + ": " + e.Message
+}
+
+// Unwrap returns an underlying error.
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// Errors is a list of violations returned by generated Validate methods.
+type Errors []*ValidationError
+
+func (e Errors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, v := range e {
+		msgs = append(msgs, v.Error())
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// As makes errors.As find the first ValidationError in the list.
+func (e Errors) As(target interface{}) bool {
+	t, ok := target.(**ValidationError)
+	if !ok || len(e) == 0 {
+		return false
+	}
+
+	*t = e[0]
+
+	return true
+}
+
+// Err returns e as error, or nil if e is empty.
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+
+	return e
+}
+
+// Add adds a violation of keyword by a value at path, where expected is a
+// keyword value and actual is a checked value.
+func (e *Errors) Add(path, keyword string, expected, actual interface{}) {
+	*e = append(*e, &ValidationError{
+		Path:     path,
+		Keyword:  keyword,
+		Expected: expected,
+		Actual:   actual,
+		Message:  message(keyword, expected, actual),
+	})
+}
+
+// Wrap adds a violation of keyword described by err, e.g. returned by format
+// checker.
+func (e *Errors) Wrap(path, keyword string, expected, actual interface{}, err error) {
+	*e = append(*e, &ValidationError{
+		Path:     path,
+		Keyword:  keyword,
+		Expected: expected,
+		Actual:   actual,
+		Message:  err.Error(),
+		Err:      err,
+	})
+}
+
+// Nest adds errors returned by Validate method of a nested value located at
+// path. Errors other than Errors are added as is.
+func (e *Errors) Nest(path string, err error) {
+	var errs Errors
+	if !errors.As(err, &errs) {
+		*e = append(*e, &ValidationError{Path: path, Message: err.Error(), Err: err})
+
+		return
+	}
+
+	for _, v := range errs {
+		n := *v
+		n.Path = path + v.Path
+		*e = append(*e, &n)
+	}
+}
+
+func message(keyword string, expected, actual interface{}) string {
+	switch keyword {
+	case "enum":
+		return fmt.Sprintf("%v is not one of %v", actual, expected)
+	case "multipleOf":
+		return fmt.Sprintf("%v is not a multiple of %v", actual, expected)
+	case "maximum":
+		return fmt.Sprintf("%v is greater than %v", actual, expected)
+	case "exclusiveMaximum":
+		return fmt.Sprintf("%v is greater than or equal to %v", actual, expected)
+	case "minimum":
+		return fmt.Sprintf("%v is less than %v", actual, expected)
+	case "exclusiveMinimum":
+		return fmt.Sprintf("%v is less than or equal to %v", actual, expected)
+	case "maxLength":
+		return fmt.Sprintf("length %v is greater than %v", actual, expected)
+	case "minLength":
+		return fmt.Sprintf("length %v is less than %v", actual, expected)
+	case "pattern":
+		return fmt.Sprintf("%q does not match pattern %q", actual, expected)
+	case "maxItems":
+		return fmt.Sprintf("%v items is more than %v", actual, expected)
+	case "minItems":
+		return fmt.Sprintf("%v items is less than %v", actual, expected)
+	case "uniqueItems":
+		if ij, ok := actual.([]int); ok && len(ij) == 2 {
+			return fmt.Sprintf("items at %d and %d are equal", ij[0], ij[1])
+		}
+
+		return "items are not unique"
+	case "maxProperties":
+		return fmt.Sprintf("%v properties is more than %v", actual, expected)
+	case "minProperties":
+		return fmt.Sprintf("%v properties is less than %v", actual, expected)
+	case "required":
+		return "value is required"
+	}
+
+	return fmt.Sprintf("%s: expected %v, got %v", keyword, expected, actual)
+}
@@ -0,0 +1,128 @@
+package rt
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+)
+
+// BigFloat is *big.Float, which is marshaled to JSON as number rather than
+// string, generated for numbers with gen.BigNumber. Its precision is enough
+// to keep decimal digits of unmarshaled numbers.
+type BigFloat struct {
+	*big.Float
+}
+
+// MarshalJSON implements json.Marshaler.
+func (f BigFloat) MarshalJSON() ([]byte, error) {
+	if f.Float == nil {
+		return []byte("null"), nil
+	}
+
+	if f.IsInf() {
+		return nil, errors.New("rt: infinite number")
+	}
+
+	return []byte(f.Text('g', -1)), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (f *BigFloat) UnmarshalJSON(b []byte) error {
+	s := string(b)
+	if s == "null" {
+		f.Float = nil
+
+		return nil
+	}
+
+	// 10 bits per 3 decimal digits.
+	prec := uint(len(s)*10/3 + 64)
+
+	v, _, err := big.ParseFloat(s, 10, prec, big.ToNearestEven)
+	if err != nil {
+		return fmt.Errorf("rt: invalid number %s: %w", s, err)
+	}
+
+	f.Float = v
+
+	return nil
+}
+
+// Rat returns the exact value of number x of a Go type generated for number
+// schemas: json.Number, *big.Int, BigFloat, *big.Float or a decimal type,
+// which implements fmt.Stringer or encoding.TextMarshaler, e.g.
+// decimal.Decimal. It returns false if x is nil, empty or isn't a finite
+// number.
+func Rat(x interface{}) (*big.Rat, bool) {
+	if v := reflect.ValueOf(x); !v.IsValid() || v.Kind() == reflect.Ptr && v.IsNil() {
+		return nil, false
+	}
+
+	switch v := x.(type) {
+	case json.Number:
+		return parse(string(v))
+	case *big.Int:
+		return new(big.Rat).SetInt(v), true
+	case big.Int:
+		return new(big.Rat).SetInt(&v), true
+	case BigFloat:
+		return float(v.Float)
+	case *BigFloat:
+		return float(v.Float)
+	case *big.Float:
+		return float(v)
+	case big.Float:
+		return float(&v)
+	case *big.Rat:
+		return new(big.Rat).Set(v), true
+	case encoding.TextMarshaler:
+		b, err := v.MarshalText()
+		if err != nil {
+			return nil, false
+		}
+
+		return parse(string(b))
+	case fmt.Stringer:
+		return parse(v.String())
+	}
+
+	return nil, false
+}
+
+func parse(s string) (*big.Rat, bool) {
+	return new(big.Rat).SetString(s)
+}
+
+func float(f *big.Float) (*big.Rat, bool) {
+	if f == nil || f.IsInf() {
+		return nil, false
+	}
+
+	r, _ := f.Rat(nil)
+
+	return r, true
+}
+
+// Compare compares x with number literal n, e.g. "0.01", and returns -1, 0
+// or +1 if x is less than, equal to or greater than n.
+func Compare(x *big.Rat, n string) int {
+	return x.Cmp(literal(n))
+}
+
+// MultipleOf reports whether x is a multiple of positive number literal n.
+func MultipleOf(x *big.Rat, n string) bool {
+	return new(big.Rat).Quo(x, literal(n)).IsInt()
+}
+
+// literal returns the value of number literal n written by generator.
+func literal(n string) *big.Rat {
+	r, ok := parse(n)
+	if !ok {
+		panic(fmt.Sprintf("rt: invalid number %q", n))
+	}
+
+	return r
+}
@@ -0,0 +1,13 @@
+package rt_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestRt(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Rt Suite")
+}
@@ -0,0 +1,404 @@
+package rt_test
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net"
+	"time"
+
+	"github.com/ekhabarov/jsg/rt"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Rt", func() {
+
+	Context("Errors", func() {
+
+		DescribeTable("Add",
+			func(keyword string, expected, actual interface{}, msg string) {
+				var errs rt.Errors
+				errs.Add("/a", keyword, expected, actual)
+
+				Expect(errs.Err()).To(MatchError(msg))
+			},
+
+			Entry("", "multipleOf", 2, 3, "/a: 3 is not a multiple of 2"),
+			Entry("", "maximum", 10, 11, "/a: 11 is greater than 10"),
+			Entry("", "exclusiveMinimum", 0.5, 0.5, "/a: 0.5 is less than or equal to 0.5"),
+			Entry("", "maxLength", 2, 3, "/a: length 3 is greater than 2"),
+			Entry("", "pattern", "^a$", "b", `/a: "b" does not match pattern "^a$"`),
+			Entry("", "uniqueItems", true, []int{0, 2}, "/a: items at 0 and 2 are equal"),
+			Entry("", "required", nil, nil, "/a: value is required"),
+			Entry("", "enum", []interface{}{"a", "b"}, "c", "/a: c is not one of [a b]"),
+			Entry("", "const", 1, 2, "/a: const: expected 1, got 2"),
+		)
+
+		It("returns nil error if empty", func() {
+			var errs rt.Errors
+			Expect(errs.Err()).To(BeNil())
+		})
+
+		It("aggregates violations", func() {
+			var errs rt.Errors
+			errs.Add("", "minProperties", 2, 1)
+			errs.Add("/b", "minLength", 1, 0)
+
+			Expect(errs.Err()).To(MatchError("/: 1 properties is less than 2; /b: length 0 is less than 1"))
+		})
+
+		It("supports errors.As", func() {
+			var errs rt.Errors
+			errs.Add("/a", "maximum", 1, 2)
+			errs.Add("/b", "maximum", 1, 3)
+
+			var ve *rt.ValidationError
+			Expect(errors.As(errs.Err(), &ve)).To(BeTrue())
+			Expect(ve.Path).To(Equal("/a"))
+			Expect(ve.Actual).To(Equal(2))
+
+			var all rt.Errors
+			Expect(errors.As(errs.Err(), &all)).To(BeTrue())
+			Expect(all).To(HaveLen(2))
+		})
+
+		It("wraps underlying errors", func() {
+			cause := errors.New("invalid email: '@' expected")
+
+			var errs rt.Errors
+			errs.Wrap("/email", "format", "email", "joe", cause)
+
+			Expect(errs.Err()).To(MatchError("/email: invalid email: '@' expected"))
+			Expect(errors.Is(errs[0], cause)).To(BeTrue())
+		})
+
+		It("nests errors of nested values", func() {
+			var inner rt.Errors
+			inner.Add("/name", "minLength", 1, 0)
+			inner.Add("", "required", nil, nil)
+
+			var errs rt.Errors
+			errs.Nest("/owner", inner.Err())
+			errs.Nest("/other", errors.New("failed"))
+
+			Expect(errs.Err()).To(MatchError("/owner/name: length 0 is less than 1; /owner: value is required; /other: failed"))
+			Expect(inner[0].Path).To(Equal("/name"))
+		})
+
+		It("marshals to JSON", func() {
+			var errs rt.Errors
+			errs.Add("/a", "maxItems", 1, 2)
+			errs.Add("/b", "required", nil, nil)
+			errs.Wrap("/c", "format", "email", "joe", errors.New("invalid email"))
+			errs.Add("/d", "minLength", 1, 0)
+
+			b, err := json.Marshal(errs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(b).To(MatchJSON(`[
+				{"path": "/a", "keyword": "maxItems", "expected": 1, "actual": 2, "message": "2 items is more than 1"},
+				{"path": "/b", "keyword": "required", "expected": null, "actual": null, "message": "value is required"},
+				{"path": "/c", "keyword": "format", "expected": "email", "actual": "joe", "message": "invalid email"},
+				{"path": "/d", "keyword": "minLength", "expected": 1, "actual": 0, "message": "length 0 is less than 1"}
+			]`))
+		})
+	})
+
+	Context("Date", func() {
+
+		It("round-trips JSON", func() {
+			var v struct{ D rt.Date }
+			Expect(json.Unmarshal([]byte(`{"D": "2020-02-29"}`), &v)).To(Succeed())
+			Expect(v.D).To(Equal(rt.Date{Year: 2020, Month: time.February, Day: 29}))
+
+			b, err := json.Marshal(v)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(b).To(MatchJSON(`{"D": "2020-02-29"}`))
+		})
+
+		It("converts to time.Time", func() {
+			d := rt.DateOf(time.Date(2021, 5, 1, 13, 0, 0, 0, time.UTC))
+			Expect(d.String()).To(Equal("2021-05-01"))
+			Expect(d.In(time.UTC)).To(Equal(time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC)))
+		})
+
+		It("doesn't marshal dates, which can't be parsed back", func() {
+			_, err := json.Marshal(struct{ D rt.Date }{})
+			Expect(err).To(MatchError(ContainSubstring("rt: zero Date can't be marshaled")))
+
+			_, err = json.Marshal(rt.Date{Year: 2021, Month: 2, Day: 29})
+			Expect(err).To(MatchError(ContainSubstring("rt: invalid date 2021-02-29")))
+		})
+
+		DescribeTable("Errors",
+			func(s string) {
+				var d rt.Date
+				Expect(json.Unmarshal([]byte(s), &d)).NotTo(Succeed())
+			},
+
+			Entry("", `"2021-02-29"`),
+			Entry("", `"2021-5-1"`),
+			Entry("", `"2021-05-01T00:00:00Z"`),
+			Entry("", `20210501`),
+		)
+	})
+
+	Context("TimeOfDay", func() {
+
+		DescribeTable("Round trip",
+			func(s string, t rt.TimeOfDay, out string) {
+				var v rt.TimeOfDay
+				Expect(json.Unmarshal([]byte(s), &v)).To(Succeed())
+				Expect(v).To(Equal(t))
+
+				b, err := json.Marshal(v)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(b)).To(Equal(out))
+			},
+
+			Entry("", `"13:00:00Z"`, rt.TimeOfDay{Hour: 13}, `"13:00:00Z"`),
+			Entry("", `"23:20:50.52+02:30"`, rt.TimeOfDay{Hour: 23, Minute: 20, Second: 50, Nanosecond: 520000000, Offset: 9000}, `"23:20:50.52+02:30"`),
+			Entry("", `"08:15:00.1234567891-05:00"`, rt.TimeOfDay{Hour: 8, Minute: 15, Nanosecond: 123456789, Offset: -18000}, `"08:15:00.123456789-05:00"`),
+			Entry("", `"23:59:60z"`, rt.TimeOfDay{Hour: 23, Minute: 59, Second: 60}, `"23:59:60Z"`),
+		)
+
+		It("converts to time.Time", func() {
+			t := rt.TimeOfDay{Hour: 10, Offset: 3600}.On(rt.Date{Year: 2021, Month: 5, Day: 1})
+			Expect(t.UTC()).To(Equal(time.Date(2021, 5, 1, 9, 0, 0, 0, time.UTC)))
+			Expect(rt.TimeOfDayOf(t).String()).To(Equal("10:00:00+01:00"))
+		})
+
+		DescribeTable("Errors",
+			func(s string) {
+				var t rt.TimeOfDay
+				Expect(json.Unmarshal([]byte(s), &t)).NotTo(Succeed())
+			},
+
+			Entry("", `"13:00:00"`),
+			Entry("", `"24:00:00Z"`),
+			Entry("", `"12:00:60Z"`),
+		)
+	})
+
+	Context("ISODuration", func() {
+
+		DescribeTable("Round trip",
+			func(s string, d rt.ISODuration, out string) {
+				var v rt.ISODuration
+				Expect(json.Unmarshal([]byte(s), &v)).To(Succeed())
+				Expect(v).To(Equal(d))
+
+				b, err := json.Marshal(v)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(b)).To(Equal(out))
+			},
+
+			Entry("", `"P1DT2H"`, rt.ISODuration{Days: 1, Hours: 2}, `"P1DT2H"`),
+			Entry("", `"P4W"`, rt.ISODuration{Weeks: 4}, `"P4W"`),
+			Entry("", `"P1Y2M3DT4H5M6S"`, rt.ISODuration{Years: 1, Months: 2, Days: 3, Hours: 4, Minutes: 5, Seconds: 6}, `"P1Y2M3DT4H5M6S"`),
+			Entry("", `"PT30M"`, rt.ISODuration{Minutes: 30}, `"PT30M"`),
+			Entry("", `"P1Y0M3D"`, rt.ISODuration{Years: 1, Days: 3}, `"P1Y0M3D"`),
+			Entry("", `"PT0S"`, rt.ISODuration{}, `"PT0S"`),
+		)
+
+		It("fills gaps and converts weeks to days", func() {
+			Expect(rt.ISODuration{Hours: 1, Seconds: 5}.String()).To(Equal("PT1H0M5S"))
+			Expect(rt.ISODuration{Weeks: 1, Days: 1}.String()).To(Equal("P8D"))
+		})
+
+		It("converts to time.Duration", func() {
+			d, ok := rt.ISODuration{Days: 1, Minutes: 1}.Duration()
+			Expect(ok).To(BeTrue())
+			Expect(d).To(Equal(24*time.Hour + time.Minute))
+
+			_, ok = rt.ISODuration{Months: 1}.Duration()
+			Expect(ok).To(BeFalse())
+		})
+
+		It("adds calendar components", func() {
+			t := rt.ISODuration{Months: 1, Hours: 1}.AddTo(time.Date(2021, 1, 31, 0, 0, 0, 0, time.UTC))
+			Expect(t).To(Equal(time.Date(2021, 3, 3, 1, 0, 0, 0, time.UTC)))
+		})
+
+		DescribeTable("Errors",
+			func(s string) {
+				var d rt.ISODuration
+				Expect(json.Unmarshal([]byte(s), &d)).NotTo(Succeed())
+			},
+
+			Entry("", `"P"`),
+			Entry("", `"P1W2D"`),
+			Entry("", `"PT1.5S"`),
+			Entry("", `3600`),
+			Entry("", `"P99999999999999999999D"`),
+		)
+	})
+
+	Context("Regexp", func() {
+
+		It("compiles ECMA-262 pattern on unmarshaling", func() {
+			var v struct{ R rt.Regexp }
+			Expect(json.Unmarshal([]byte(`{"R": "^\\d+\\p{L}$"}`), &v)).To(Succeed())
+			Expect(v.R.MatchString("12ä")).To(BeTrue())
+			Expect(v.R.String()).To(Equal(`^\d+\p{L}$`))
+
+			c := v
+			Expect(c.R.MatchString("x")).To(BeFalse())
+
+			b, err := json.Marshal(v)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(b).To(MatchJSON(`{"R": "^\\d+\\p{L}$"}`))
+		})
+
+		DescribeTable("Errors",
+			func(s string) {
+				var r rt.Regexp
+				Expect(json.Unmarshal([]byte(s), &r)).NotTo(Succeed())
+			},
+
+			Entry("invalid", `"a("`),
+			Entry("unsupported", `"a(?=b)"`),
+		)
+	})
+
+	Context("URL", func() {
+
+		It("round-trips JSON", func() {
+			var v struct{ U rt.URL }
+			Expect(json.Unmarshal([]byte(`{"U": "https://example.com/a?b=c#d"}`), &v)).To(Succeed())
+			Expect(v.U.URL().Host).To(Equal("example.com"))
+			Expect(v.U.URL().Fragment).To(Equal("d"))
+
+			b, err := json.Marshal(v)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(b).To(MatchJSON(`{"U": "https://example.com/a?b=c#d"}`))
+		})
+
+		DescribeTable("Errors",
+			func(s string) {
+				var u rt.URL
+				Expect(json.Unmarshal([]byte(s), &u)).NotTo(Succeed())
+			},
+
+			Entry("relative", `"/a/b"`),
+			Entry("invalid", `"http://exa mple.com"`),
+		)
+
+		It("fails to marshal zero URL", func() {
+			_, err := json.Marshal(struct{ U rt.URL }{})
+			Expect(err).To(MatchError(ContainSubstring("rt: zero URL can't be marshaled")))
+		})
+
+		It("doesn't unmarshal binary bypassing validation", func() {
+			var u interface{} = &rt.URL{}
+			_, ok := u.(encoding.BinaryUnmarshaler)
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Context("IP", func() {
+
+		It("round-trips IPv4", func() {
+			var v struct{ IP rt.IPv4 }
+			Expect(json.Unmarshal([]byte(`{"IP": "192.0.2.1"}`), &v)).To(Succeed())
+			Expect(v.IP).To(HaveLen(4))
+			Expect(v.IP.IP().Equal(net.IPv4(192, 0, 2, 1))).To(BeTrue())
+
+			b, err := json.Marshal(v)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(b).To(MatchJSON(`{"IP": "192.0.2.1"}`))
+		})
+
+		It("round-trips IPv6", func() {
+			var v struct{ A, B rt.IPv6 }
+			Expect(json.Unmarshal([]byte(`{"A": "2001:DB8::1", "B": "::ffff:192.0.2.1"}`), &v)).To(Succeed())
+			Expect(v.A).To(HaveLen(16))
+
+			b, err := json.Marshal(v)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(b).To(MatchJSON(`{"A": "2001:db8::1", "B": "::ffff:192.0.2.1"}`))
+		})
+
+		DescribeTable("Errors",
+			func(s string, v interface{}) {
+				Expect(json.Unmarshal([]byte(s), v)).NotTo(Succeed())
+			},
+
+			Entry("IPv6 as IPv4", `"2001:db8::1"`, new(rt.IPv4)),
+			Entry("IPv4 as IPv6", `"192.0.2.1"`, new(rt.IPv6)),
+			Entry("invalid IPv4", `"192.0.2.256"`, new(rt.IPv4)),
+		)
+
+		DescribeTable("Zero values",
+			func(v interface{}, msg string) {
+				_, err := json.Marshal(v)
+				Expect(err).To(MatchError(ContainSubstring(msg)))
+
+				// The empty string, which zero values would be marshaled to,
+				// doesn't unmarshal either.
+				Expect(json.Unmarshal([]byte(`""`), v)).NotTo(Succeed())
+			},
+
+			Entry("IPv4", new(rt.IPv4), "rt: zero IPv4 can't be marshaled"),
+			Entry("IPv6", new(rt.IPv6), "rt: zero IPv6 can't be marshaled"),
+		)
+	})
+
+	Context("Numbers", func() {
+
+		DescribeTable("Rat",
+			func(x interface{}, exp string) {
+				r, ok := rt.Rat(x)
+				Expect(ok).To(BeTrue())
+				Expect(r.RatString()).To(Equal(exp))
+			},
+
+			Entry("json.Number", json.Number("0.30"), "3/10"),
+			Entry("*big.Int", big.NewInt(-7), "-7"),
+			Entry("*big.Float", big.NewFloat(0.5), "1/2"),
+			Entry("fmt.Stringer", decimal("12.5"), "25/2"),
+			Entry("pointer to fmt.Stringer", func() *json.Number { n := json.Number("1e2"); return &n }(), "100"),
+		)
+
+		DescribeTable("Rat: not a number",
+			func(x interface{}) {
+				_, ok := rt.Rat(x)
+				Expect(ok).To(BeFalse())
+			},
+
+			Entry("nil", nil),
+			Entry("nil *big.Int", (*big.Int)(nil)),
+			Entry("empty json.Number", json.Number("")),
+			Entry("infinity", new(big.Float).SetInf(false)),
+			Entry("float64", 1.5),
+		)
+
+		It("marshals BigFloat as JSON number", func() {
+			var v struct{ A, B, C rt.BigFloat }
+			Expect(json.Unmarshal([]byte(`{"A": 0.1, "B": 123456789012345678901234567890.5, "C": null}`), &v)).To(Succeed())
+			Expect(v.C.Float).To(BeNil())
+
+			b, err := json.Marshal(v)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(b).To(MatchJSON(`{"A": 0.1, "B": 1.234567890123456789012345678905e+29, "C": null}`))
+		})
+
+		It("compares numbers exactly", func() {
+			r, _ := rt.Rat(json.Number("0.3"))
+			Expect(rt.Compare(r, "0.3")).To(Equal(0))
+			Expect(rt.Compare(r, "0.30000000000000001")).To(Equal(-1))
+			Expect(rt.MultipleOf(r, "0.1")).To(BeTrue())
+			Expect(rt.MultipleOf(r, "0.2")).To(BeFalse())
+		})
+	})
+
+})
+
+// decimal is a decimal type, which implements fmt.Stringer.
+type decimal string
+
+func (d decimal) String() string {
+	return string(d)
+}
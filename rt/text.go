@@ -0,0 +1,209 @@
+package rt
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"regexp"
+
+	"github.com/ekhabarov/jsg/formats"
+	"github.com/ekhabarov/jsg/regex"
+)
+
+// Regexp is ECMA-262 regular expression generated for "regex" format. It's
+// compiled on unmarshaling, so patterns, which RE2 can't express, e.g. with
+// lookahead, are rejected. Zero value must not be used.
+type Regexp struct {
+	re     *regexp.Regexp
+	source string
+}
+
+// CompileRegexp compiles ECMA-262 regular expression.
+func CompileRegexp(s string) (Regexp, error) {
+	re, err := regex.Compile(s)
+	if err != nil {
+		return Regexp{}, err
+	}
+
+	return Regexp{re: re, source: s}, nil
+}
+
+// Regexp returns compiled RE2 regular expression.
+func (r Regexp) Regexp() *regexp.Regexp {
+	return r.re
+}
+
+// MatchString reports whether s contains any match of r.
+func (r Regexp) MatchString(s string) bool {
+	return r.re.MatchString(s)
+}
+
+// String returns the source pattern.
+func (r Regexp) String() string {
+	return r.source
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (r Regexp) MarshalText() ([]byte, error) {
+	return []byte(r.source), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (r *Regexp) UnmarshalText(b []byte) error {
+	v, err := CompileRegexp(string(b))
+	if err != nil {
+		return err
+	}
+
+	*r = v
+
+	return nil
+}
+
+// URL is RFC 3986 absolute URI generated for "uri" format. It's parsed on
+// unmarshaling, so relative and invalid URIs are rejected. Zero value can't
+// be marshaled.
+type URL struct {
+	u url.URL
+}
+
+// ParseURL parses RFC 3986 absolute URI.
+func ParseURL(s string) (URL, error) {
+	if err := formats.URI(s); err != nil {
+		return URL{}, err
+	}
+
+	u, err := url.Parse(s)
+	if err != nil {
+		return URL{}, err
+	}
+
+	return URL{u: *u}, nil
+}
+
+// URL returns a copy of the parsed URI.
+func (u URL) URL() *url.URL {
+	c := u.u
+
+	return &c
+}
+
+func (u URL) String() string {
+	return u.u.String()
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (u URL) MarshalText() ([]byte, error) {
+	if u.u == (url.URL{}) {
+		return nil, errors.New("rt: zero URL can't be marshaled")
+	}
+
+	return []byte(u.u.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (u *URL) UnmarshalText(b []byte) error {
+	v, err := ParseURL(string(b))
+	if err != nil {
+		return err
+	}
+
+	*u = v
+
+	return nil
+}
+
+// IPv4 is an IPv4 address in dotted-quad notation generated for "ipv4"
+// format. Unlike net.IP it rejects IPv6 addresses. Zero value can't be
+// marshaled.
+type IPv4 net.IP
+
+// ParseIPv4 parses an IPv4 address in dotted-quad notation.
+func ParseIPv4(s string) (IPv4, error) {
+	if err := formats.IPv4(s); err != nil {
+		return nil, err
+	}
+
+	return IPv4(net.ParseIP(s).To4()), nil
+}
+
+// IP returns the address as net.IP.
+func (ip IPv4) IP() net.IP {
+	return net.IP(ip)
+}
+
+func (ip IPv4) String() string {
+	return net.IP(ip).String()
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (ip IPv4) MarshalText() ([]byte, error) {
+	if len(ip) == 0 {
+		return nil, errors.New("rt: zero IPv4 can't be marshaled")
+	}
+
+	return net.IP(ip).MarshalText()
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (ip *IPv4) UnmarshalText(b []byte) error {
+	v, err := ParseIPv4(string(b))
+	if err != nil {
+		return err
+	}
+
+	*ip = v
+
+	return nil
+}
+
+// IPv6 is an IPv6 address generated for "ipv6" format. Unlike net.IP it
+// rejects IPv4 addresses in dotted-quad notation. Zero value can't be
+// marshaled.
+type IPv6 net.IP
+
+// ParseIPv6 parses an IPv6 address.
+func ParseIPv6(s string) (IPv6, error) {
+	if err := formats.IPv6(s); err != nil {
+		return nil, err
+	}
+
+	return IPv6(net.ParseIP(s).To16()), nil
+}
+
+// IP returns the address as net.IP.
+func (ip IPv6) IP() net.IP {
+	return net.IP(ip)
+}
+
+// String returns the address in RFC 5952 form. IPv4-mapped addresses keep
+// IPv6 notation, e.g. "::ffff:192.0.2.1".
+func (ip IPv6) String() string {
+	s := net.IP(ip).String()
+	if len(ip) == net.IPv6len && net.IP(ip).To4() != nil {
+		return "::ffff:" + s
+	}
+
+	return s
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (ip IPv6) MarshalText() ([]byte, error) {
+	if len(ip) == 0 {
+		return nil, errors.New("rt: zero IPv6 can't be marshaled")
+	}
+
+	return []byte(ip.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (ip *IPv6) UnmarshalText(b []byte) error {
+	v, err := ParseIPv6(string(b))
+	if err != nil {
+		return err
+	}
+
+	*ip = v
+
+	return nil
+}
@@ -0,0 +1,340 @@
+package rt
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ekhabarov/jsg/formats"
+)
+
+// Date is RFC 3339 full-date, e.g. "1985-04-12", generated for "date"
+// format.
+type Date struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+// DateOf returns the date of t in its location.
+func DateOf(t time.Time) Date {
+	y, m, d := t.Date()
+
+	return Date{Year: y, Month: m, Day: d}
+}
+
+// ParseDate parses RFC 3339 full-date.
+func ParseDate(s string) (Date, error) {
+	if err := formats.Date(s); err != nil {
+		return Date{}, err
+	}
+
+	y, _ := strconv.Atoi(s[0:4])
+	m, _ := strconv.Atoi(s[5:7])
+	d, _ := strconv.Atoi(s[8:10])
+
+	return Date{Year: y, Month: time.Month(m), Day: d}, nil
+}
+
+// In returns the start of the date in location loc.
+func (d Date) In(loc *time.Location) time.Time {
+	return time.Date(d.Year, d.Month, d.Day, 0, 0, 0, 0, loc)
+}
+
+// IsZero reports whether d is a zero value.
+func (d Date) IsZero() bool {
+	return d == Date{}
+}
+
+func (d Date) String() string {
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
+
+// MarshalText implements encoding.TextMarshaler. It fails for zero and
+// invalid dates, which ParseDate would reject.
+func (d Date) MarshalText() ([]byte, error) {
+	if d.IsZero() {
+		return nil, errors.New("rt: zero Date can't be marshaled")
+	}
+
+	s := d.String()
+	if err := formats.Date(s); err != nil {
+		return nil, fmt.Errorf("rt: invalid date %s: %w", s, err)
+	}
+
+	return []byte(s), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Date) UnmarshalText(b []byte) error {
+	v, err := ParseDate(string(b))
+	if err != nil {
+		return err
+	}
+
+	*d = v
+
+	return nil
+}
+
+// TimeOfDay is RFC 3339 full-time, e.g. "23:20:50.52Z", generated for "time"
+// format.
+type TimeOfDay struct {
+	Hour   int
+	Minute int
+	// Second is 60 for leap seconds.
+	Second     int
+	Nanosecond int
+	// Offset is a time offset in seconds east of UTC.
+	Offset int
+}
+
+// TimeOfDayOf returns the time of day of t in its location.
+func TimeOfDayOf(t time.Time) TimeOfDay {
+	_, off := t.Zone()
+
+	return TimeOfDay{Hour: t.Hour(), Minute: t.Minute(), Second: t.Second(), Nanosecond: t.Nanosecond(), Offset: off}
+}
+
+// ParseTimeOfDay parses RFC 3339 full-time. Fractions of a second beyond
+// nanoseconds are truncated.
+func ParseTimeOfDay(s string) (TimeOfDay, error) {
+	if err := formats.Time(s); err != nil {
+		return TimeOfDay{}, err
+	}
+
+	var t TimeOfDay
+
+	t.Hour, _ = strconv.Atoi(s[0:2])
+	t.Minute, _ = strconv.Atoi(s[3:5])
+	t.Second, _ = strconv.Atoi(s[6:8])
+	s = s[8:]
+
+	if s[0] == '.' {
+		i := strings.IndexAny(s, "Zz+-")
+		frac := (s[1:i] + "000000000")[:9]
+		t.Nanosecond, _ = strconv.Atoi(frac)
+		s = s[i:]
+	}
+
+	if len(s) == 6 {
+		h, _ := strconv.Atoi(s[1:3])
+		m, _ := strconv.Atoi(s[4:6])
+
+		t.Offset = (h*60 + m) * 60
+		if s[0] == '-' {
+			t.Offset = -t.Offset
+		}
+	}
+
+	return t, nil
+}
+
+// On returns time t at date d.
+func (t TimeOfDay) On(d Date) time.Time {
+	loc := time.UTC
+	if t.Offset != 0 {
+		loc = time.FixedZone("", t.Offset)
+	}
+
+	return time.Date(d.Year, d.Month, d.Day, t.Hour, t.Minute, t.Second, t.Nanosecond, loc)
+}
+
+func (t TimeOfDay) String() string {
+	b := strings.Builder{}
+	fmt.Fprintf(&b, "%02d:%02d:%02d", t.Hour, t.Minute, t.Second)
+
+	if t.Nanosecond != 0 {
+		b.WriteString(strings.TrimRight(fmt.Sprintf(".%09d", t.Nanosecond), "0"))
+	}
+
+	if t.Offset == 0 {
+		b.WriteString("Z")
+
+		return b.String()
+	}
+
+	sign, off := '+', t.Offset/60
+	if off < 0 {
+		sign, off = '-', -off
+	}
+
+	fmt.Fprintf(&b, "%c%02d:%02d", sign, off/60, off%60)
+
+	return b.String()
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (t TimeOfDay) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (t *TimeOfDay) UnmarshalText(b []byte) error {
+	v, err := ParseTimeOfDay(string(b))
+	if err != nil {
+		return err
+	}
+
+	*t = v
+
+	return nil
+}
+
+// ISODuration is ISO 8601 duration, e.g. "P1DT2H" or "P4W", generated for
+// "duration" format. Unlike time.Duration it keeps calendar components, which
+// have no fixed length.
+type ISODuration struct {
+	Years   int
+	Months  int
+	Weeks   int
+	Days    int
+	Hours   int
+	Minutes int
+	Seconds int
+}
+
+// ParseISODuration parses ISO 8601 duration as defined in RFC 3339 appendix
+// A.
+func ParseISODuration(s string) (ISODuration, error) {
+	if err := formats.Duration(s); err != nil {
+		return ISODuration{}, err
+	}
+
+	var (
+		d      ISODuration
+		date   = true
+		digits = ""
+	)
+
+	for _, r := range s[1:] {
+		if r >= '0' && r <= '9' {
+			digits += string(r)
+
+			continue
+		}
+
+		n := 0
+		if digits != "" {
+			v, err := strconv.ParseInt(digits, 10, strconv.IntSize)
+			if err != nil {
+				return ISODuration{}, fmt.Errorf("rt: invalid duration %s: %w", s, err)
+			}
+
+			n = int(v)
+		}
+
+		switch {
+		case r == 'T':
+			date = false
+		case r == 'Y':
+			d.Years = n
+		case r == 'M' && date:
+			d.Months = n
+		case r == 'W':
+			d.Weeks = n
+		case r == 'D':
+			d.Days = n
+		case r == 'H':
+			d.Hours = n
+		case r == 'M':
+			d.Minutes = n
+		case r == 'S':
+			d.Seconds = n
+		}
+
+		digits = ""
+	}
+
+	return d, nil
+}
+
+// AddTo returns t plus d, where calendar components are added with
+// time.AddDate.
+func (d ISODuration) AddTo(t time.Time) time.Time {
+	t = t.AddDate(d.Years, d.Months, d.Weeks*7+d.Days)
+
+	return t.Add(time.Duration(d.Hours)*time.Hour + time.Duration(d.Minutes)*time.Minute + time.Duration(d.Seconds)*time.Second)
+}
+
+// Duration returns d as time.Duration, where a day is 24 hours. It returns
+// false if d has years or months.
+func (d ISODuration) Duration() (time.Duration, bool) {
+	if d.Years != 0 || d.Months != 0 {
+		return 0, false
+	}
+
+	h := (d.Weeks*7+d.Days)*24 + d.Hours
+
+	return time.Duration(h)*time.Hour + time.Duration(d.Minutes)*time.Minute + time.Duration(d.Seconds)*time.Second, true
+}
+
+// IsZero reports whether d is a zero value.
+func (d ISODuration) IsZero() bool {
+	return d == ISODuration{}
+}
+
+// String returns d in ISO 8601 format. Weeks can't be combined with other
+// components, so they're converted to days if there are any.
+func (d ISODuration) String() string {
+	if d.IsZero() {
+		return "PT0S"
+	}
+
+	if d.Weeks != 0 && d == (ISODuration{Weeks: d.Weeks}) {
+		return fmt.Sprintf("P%dW", d.Weeks)
+	}
+
+	b := strings.Builder{}
+	b.WriteString("P")
+	components(&b, []int{d.Years, d.Months, d.Weeks*7 + d.Days}, "YMD")
+
+	if d.Hours != 0 || d.Minutes != 0 || d.Seconds != 0 {
+		b.WriteString("T")
+		components(&b, []int{d.Hours, d.Minutes, d.Seconds}, "HMS")
+	}
+
+	return b.String()
+}
+
+// components writes duration components n with designators. RFC 3339 doesn't
+// allow gaps, e.g. "P1Y3D", so zeros between non-zero components are written
+// too: "P1Y0M3D".
+func components(b *strings.Builder, n []int, designators string) {
+	first, last := -1, -1
+
+	for i, v := range n {
+		if v == 0 {
+			continue
+		}
+
+		if first < 0 {
+			first = i
+		}
+
+		last = i
+	}
+
+	for i := first; first >= 0 && i <= last; i++ {
+		fmt.Fprintf(b, "%d%c", n[i], designators[i])
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d ISODuration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *ISODuration) UnmarshalText(b []byte) error {
+	v, err := ParseISODuration(string(b))
+	if err != nil {
+		return err
+	}
+
+	*d = v
+
+	return nil
+}
@@ -0,0 +1,477 @@
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ekhabarov/jsg/ast"
+	"github.com/ekhabarov/jsg/lib"
+	"github.com/ekhabarov/jsg/regex"
+)
+
+// schema is a compiled schema ready for evaluation.
+type schema struct {
+	// location is an absolute URI of the schema including JSON pointer
+	// fragment.
+	location string
+
+	types ast.SchemaType
+	enum  []interface{}
+
+	multipleOf       *big.Rat
+	maximum          *big.Rat
+	exclusiveMaximum *big.Rat
+	minimum          *big.Rat
+	exclusiveMinimum *big.Rat
+
+	maxLength *uint32
+	minLength *uint32
+	pattern   *regexp.Regexp
+	format    ast.StringFormat
+	// assertFormat makes format an assertion rather than annotation.
+	assertFormat bool
+
+	maxItems    *uint32
+	minItems    *uint32
+	uniqueItems bool
+	items       *schema
+
+	maxProperties *uint32
+	minProperties *uint32
+	required      []string
+
+	ref   *schema
+	allOf []*schema
+	not   *schema
+
+	// never is set for false schemas, which accept no instances.
+	never bool
+
+	properties map[string]*schema
+	// names are sorted property names, which makes evaluation order stable.
+	names []string
+}
+
+type compiler struct {
+	extra           []*ast.Schema
+	formatAssertion bool
+	// resources maps absolute URIs without fragment to schema resources.
+	resources map[string]*ast.Schema
+	// schemas maps absolute schema locations to compiled schemas, which also
+	// stops recursion on cyclic references.
+	schemas map[string]*schema
+}
+
+func newCompiler() *compiler {
+	return &compiler{
+		resources: map[string]*ast.Schema{},
+		schemas:   map[string]*schema{},
+	}
+}
+
+func (c *compiler) compile(root *ast.Schema) (*schema, error) {
+	for _, s := range append([]*ast.Schema{root}, c.extra...) {
+		if err := c.index(s, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	cs, err := c.compileAt(root, "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	// Compiled schemas are checked in order of their locations, so the same
+	// cycle is always reported the same way.
+	locs := make([]string, 0, len(c.schemas))
+	for l := range c.schemas {
+		locs = append(locs, l)
+	}
+
+	sort.Strings(locs)
+
+	done := map[*schema]bool{}
+	for _, l := range locs {
+		if err := cycle(c.schemas[l], map[*schema]bool{}, done); err != nil {
+			return nil, err
+		}
+	}
+
+	return cs, nil
+}
+
+// unsupported are keywords, which affect validation result, but validate
+// package doesn't evaluate. Schemas with them fail to compile, so instances
+// aren't reported as valid by mistake. Other unknown keywords, e.g.
+// "examples", are annotations.
+var unsupported = map[string]bool{
+	"$dynamicRef":           true,
+	"$recursiveRef":         true,
+	"additionalItems":       true,
+	"additionalProperties":  true,
+	"anyOf":                 true,
+	"const":                 true,
+	"contains":              true,
+	"dependencies":          true,
+	"dependentRequired":     true,
+	"dependentSchemas":      true,
+	"else":                  true,
+	"if":                    true,
+	"maxContains":           true,
+	"minContains":           true,
+	"oneOf":                 true,
+	"patternProperties":     true,
+	"prefixItems":           true,
+	"propertyNames":         true,
+	"then":                  true,
+	"unevaluatedItems":      true,
+	"unevaluatedProperties": true,
+}
+
+// cycle returns an error if schema s applies itself to the same instance
+// location through "$ref", "allOf" or "not", e.g. {"$ref": "#"}, which would
+// make evaluation endless. Schemas in path are being checked, done are
+// already checked.
+func cycle(s *schema, path, done map[*schema]bool) error {
+	if path[s] {
+		return fmt.Errorf("%s: reference cycle doesn't reach new instance location", s.location)
+	}
+
+	if done[s] {
+		return nil
+	}
+
+	path[s] = true
+
+	for _, n := range append([]*schema{s.ref, s.not}, s.allOf...) {
+		if n == nil {
+			continue
+		}
+
+		if err := cycle(n, path, done); err != nil {
+			return err
+		}
+	}
+
+	delete(path, s)
+	done[s] = true
+
+	return nil
+}
+
+// index registers schema s and all its subschemas with "$id" as resources.
+func (c *compiler) index(s *ast.Schema, base string) error {
+	if s.ID != "" {
+		id, err := resolve(base, s.ID)
+		if err != nil {
+			return fmt.Errorf("invalid $id %q: %w", s.ID, err)
+		}
+
+		base = id
+	}
+
+	if _, ok := c.resources[base]; !ok {
+		c.resources[base] = s
+	}
+
+	if s.Items != nil {
+		if err := c.index(s.Items, base); err != nil {
+			return err
+		}
+	}
+
+	for i := range s.AllOf {
+		if err := c.index(&s.AllOf[i], base); err != nil {
+			return err
+		}
+	}
+
+	for _, d := range s.Defs {
+		d := d
+		if err := c.index(&d, base); err != nil {
+			return err
+		}
+	}
+
+	for _, p := range s.Properties {
+		p := p
+		if err := c.index(&p, base); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *compiler) compileAt(s *ast.Schema, base, ptr string) (*schema, error) {
+	if s.ID != "" {
+		id, err := resolve(base, s.ID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid $id %q: %w", s.ID, err)
+		}
+
+		base, ptr = id, ""
+	}
+
+	loc := base + "#" + ptr
+	if cs, ok := c.schemas[loc]; ok {
+		return cs, nil
+	}
+
+	var enum []interface{}
+	if s.Enum != nil {
+		enum = make([]interface{}, 0, len(s.Enum))
+	}
+
+	for i, e := range s.Enum {
+		v, err := Decode(bytes.NewReader(e))
+		if err != nil {
+			return nil, fmt.Errorf("%s/enum/%d: %w", loc, i, err)
+		}
+
+		enum = append(enum, v)
+	}
+
+	cs := &schema{
+		location:         loc,
+		types:            s.Type,
+		enum:             enum,
+		multipleOf:       rat(s.MultipleOf),
+		maximum:          rat(s.Maximum),
+		exclusiveMaximum: rat(s.ExclusiveMaximum),
+		minimum:          rat(s.Minimum),
+		exclusiveMinimum: rat(s.ExclusiveMinimum),
+		maxLength:        s.MaxLength,
+		minLength:        s.MinLength,
+		format:           s.Format,
+		assertFormat:     c.formatAssertion,
+		maxItems:         s.MaxItems,
+		minItems:         s.MinItems,
+		uniqueItems:      s.UniqueItems,
+		maxProperties:    s.MaxProperties,
+		minProperties:    s.MinProperties,
+		required:         s.Required,
+	}
+	c.schemas[loc] = cs
+
+	if v, ok := s.Boolean(); ok && !v {
+		cs.never = true
+
+		return cs, nil
+	}
+
+	if c.formatAssertion && s.Format != "" && !s.Format.Known() {
+		return nil, fmt.Errorf("%s/format: unknown format %q can't be asserted", loc, s.Format.Name())
+	}
+
+	keywords := make([]string, 0, len(s.Unknown))
+	for k := range s.Unknown {
+		if unsupported[k] {
+			keywords = append(keywords, k)
+		}
+	}
+
+	if len(keywords) > 0 {
+		sort.Strings(keywords)
+		return nil, fmt.Errorf("%s/%s: unsupported keyword", loc, lib.EscapePointer(keywords[0]))
+	}
+
+	if s.Pattern != "" {
+		re, err := regex.Compile(s.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%s/pattern: %w", loc, err)
+		}
+
+		cs.pattern = re
+	}
+
+	if s.Ref != "" {
+		ref, err := c.resolveRef(base, s.Ref)
+		if err != nil {
+			return nil, fmt.Errorf("%s/$ref: %w", loc, err)
+		}
+
+		cs.ref = ref
+	}
+
+	for i := range s.AllOf {
+		a, err := c.compileAt(&s.AllOf[i], base, ptr+"/allOf/"+strconv.Itoa(i))
+		if err != nil {
+			return nil, err
+		}
+
+		cs.allOf = append(cs.allOf, a)
+	}
+
+	if raw, ok := s.Unknown["not"]; ok {
+		var not ast.Schema
+		if err := json.Unmarshal(raw, &not); err != nil {
+			return nil, fmt.Errorf("%s/not: %w", loc, err)
+		}
+
+		ns, err := c.compileAt(&not, base, ptr+"/not")
+		if err != nil {
+			return nil, err
+		}
+
+		cs.not = ns
+	}
+
+	if s.Items != nil {
+		items, err := c.compileAt(s.Items, base, ptr+"/items")
+		if err != nil {
+			return nil, err
+		}
+
+		cs.items = items
+	}
+
+	if len(s.Properties) > 0 {
+		cs.properties = make(map[string]*schema, len(s.Properties))
+
+		for n, p := range s.Properties {
+			p := p
+
+			ps, err := c.compileAt(&p, base, ptr+"/properties/"+lib.EscapePointer(n))
+			if err != nil {
+				return nil, err
+			}
+
+			cs.properties[n] = ps
+			cs.names = append(cs.names, n)
+		}
+
+		sort.Strings(cs.names)
+	}
+
+	return cs, nil
+}
+
+// resolveRef finds and compiles a schema referenced by ref.
+func (c *compiler) resolveRef(base, ref string) (*schema, error) {
+	uri, err := resolve(base, ref)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reference %q: %w", ref, err)
+	}
+
+	fragment := ""
+	if i := strings.Index(ref, "#"); i >= 0 {
+		fragment, err = url.PathUnescape(ref[i+1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid fragment in %q: %w", ref, err)
+		}
+	}
+
+	res, ok := c.resources[uri]
+	if !ok {
+		return nil, fmt.Errorf("unknown schema resource %q", uri)
+	}
+
+	if fragment != "" && fragment[0] != '/' {
+		return nil, fmt.Errorf("unsupported fragment %q, JSON pointer expected", fragment)
+	}
+
+	s, err := pointer(res, fragment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %q: %w", ref, err)
+	}
+
+	return c.compileAt(s, uri, fragment)
+}
+
+// pointer returns a subschema of s located by JSON pointer ptr.
+func pointer(s *ast.Schema, ptr string) (*ast.Schema, error) {
+	if ptr == "" {
+		return s, nil
+	}
+
+	tokens := strings.Split(ptr[1:], "/")
+
+	for i := 0; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "properties":
+			if i+1 == len(tokens) {
+				return nil, fmt.Errorf("property name expected in %q", ptr)
+			}
+
+			i++
+
+			p, ok := s.Properties[lib.UnescapePointer(tokens[i])]
+			if !ok {
+				return nil, fmt.Errorf("property %q not found", tokens[i])
+			}
+
+			s = &p
+		case "$defs":
+			if i+1 == len(tokens) {
+				return nil, fmt.Errorf("definition name expected in %q", ptr)
+			}
+
+			i++
+
+			d, ok := s.Defs[lib.UnescapePointer(tokens[i])]
+			if !ok {
+				return nil, fmt.Errorf("definition %q not found", tokens[i])
+			}
+
+			s = &d
+		case "items":
+			if s.Items == nil {
+				return nil, fmt.Errorf("items not found")
+			}
+
+			s = s.Items
+		case "allOf":
+			if i+1 == len(tokens) {
+				return nil, fmt.Errorf("allOf index expected in %q", ptr)
+			}
+
+			i++
+
+			n, err := strconv.Atoi(tokens[i])
+			if err != nil || n < 0 || n >= len(s.AllOf) {
+				return nil, fmt.Errorf("allOf/%s not found", tokens[i])
+			}
+
+			s = &s.AllOf[n]
+		default:
+			return nil, fmt.Errorf("unsupported keyword %q in pointer %q", tokens[i], ptr)
+		}
+	}
+
+	return s, nil
+}
+
+// resolve resolves ref against base URI and drops a fragment.
+func resolve(base, ref string) (string, error) {
+	b, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+
+	r, err := url.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+
+	u := b.ResolveReference(r)
+	u.Fragment, u.RawFragment = "", ""
+
+	return u.String(), nil
+}
+
+// rat converts keyword value d to rational number, or nil if it's absent.
+func rat(d *ast.Decimal) *big.Rat {
+	if d == nil {
+		return nil
+	}
+
+	return d.Rat()
+}
@@ -0,0 +1,331 @@
+package validate
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ekhabarov/jsg/ast"
+	"github.com/ekhabarov/jsg/formats"
+	"github.com/ekhabarov/jsg/internal/jsonvalue"
+	"github.com/ekhabarov/jsg/lib"
+)
+
+// unit is a result of evaluation of a schema or a keyword against an instance
+// location. Units form a tree which follows the evaluation path.
+type unit struct {
+	keywordLocation         string
+	absoluteKeywordLocation string
+	instanceLocation        string
+	valid                   bool
+	// err is set for failed assertions only, not for failed applicators,
+	// whose children hold the details.
+	err string
+	// annotation is a value collected by keyword, e.g. format name.
+	annotation interface{}
+	children   []*unit
+}
+
+func (u *unit) add(c *unit) {
+	u.children = append(u.children, c)
+
+	if !c.valid {
+		u.valid = false
+	}
+}
+
+// walk calls f for u and all its descendants, depth first.
+func (u *unit) walk(f func(*unit)) {
+	f(u)
+
+	for _, c := range u.children {
+		c.walk(f)
+	}
+}
+
+// evaluate evaluates instance i against s, where kw is the keyword location of
+// s and inst is the location of i.
+func (s *schema) evaluate(i interface{}, kw, inst string) *unit {
+	u := &unit{
+		keywordLocation:         kw,
+		absoluteKeywordLocation: s.location,
+		instanceLocation:        inst,
+		valid:                   true,
+	}
+
+	// False schemas fail at their own location rather than at the "not"
+	// keyword, which they're decoded with.
+	if s.never {
+		u.valid = false
+		u.err = "false schema"
+
+		return u
+	}
+
+	if s.ref != nil {
+		u.add(s.ref.evaluate(i, kw+"/$ref", inst))
+	}
+
+	for n, a := range s.allOf {
+		u.add(a.evaluate(i, kw+"/allOf/"+strconv.Itoa(n), inst))
+	}
+
+	if s.not != nil {
+		nu := s.not.evaluate(i, kw+"/not", inst)
+		u.add(s.assert(kw, inst, "not", !nu.valid, "value is valid against schema in not"))
+	}
+
+	t, n := typeOf(i)
+
+	// Integer is a subset of number.
+	if s.types != 0 && s.types&t == 0 && !(t == ast.Integer && s.types&ast.Number != 0) {
+		u.add(s.assert(kw, inst, "type", false, "expected %s, got %s", typeNames(s.types), typeNames(t)))
+	}
+
+	if s.enum != nil {
+		u.add(s.assert(kw, inst, "enum", s.oneOf(i), "value is not one of enum values"))
+	}
+
+	if n != nil {
+		s.evaluateNumber(u, n, kw, inst)
+	}
+
+	if str, ok := i.(string); ok {
+		s.evaluateString(u, str, kw, inst)
+	}
+
+	if arr, ok := i.([]interface{}); ok {
+		s.evaluateArray(u, arr, kw, inst)
+	}
+
+	if obj, ok := i.(map[string]interface{}); ok {
+		s.evaluateObject(u, obj, kw, inst)
+	}
+
+	return u
+}
+
+func (s *schema) evaluateNumber(u *unit, n *big.Rat, kw, inst string) {
+	if s.multipleOf != nil && s.multipleOf.Sign() > 0 {
+		q := new(big.Rat).Quo(n, s.multipleOf)
+		u.add(s.assert(kw, inst, "multipleOf", q.IsInt(), "%s is not a multiple of %s", num(n), num(s.multipleOf)))
+	}
+
+	if s.maximum != nil {
+		u.add(s.assert(kw, inst, "maximum", n.Cmp(s.maximum) <= 0, "%s is greater than %s", num(n), num(s.maximum)))
+	}
+
+	if s.exclusiveMaximum != nil {
+		u.add(s.assert(kw, inst, "exclusiveMaximum", n.Cmp(s.exclusiveMaximum) < 0, "%s is greater than or equal to %s", num(n), num(s.exclusiveMaximum)))
+	}
+
+	if s.minimum != nil {
+		u.add(s.assert(kw, inst, "minimum", n.Cmp(s.minimum) >= 0, "%s is less than %s", num(n), num(s.minimum)))
+	}
+
+	if s.exclusiveMinimum != nil {
+		u.add(s.assert(kw, inst, "exclusiveMinimum", n.Cmp(s.exclusiveMinimum) > 0, "%s is less than or equal to %s", num(n), num(s.exclusiveMinimum)))
+	}
+}
+
+func (s *schema) evaluateString(u *unit, str, kw, inst string) {
+	l := uint32(utf8.RuneCountInString(str))
+
+	if s.maxLength != nil {
+		u.add(s.assert(kw, inst, "maxLength", l <= *s.maxLength, "length %d is greater than %d", l, *s.maxLength))
+	}
+
+	if s.minLength != nil {
+		u.add(s.assert(kw, inst, "minLength", l >= *s.minLength, "length %d is less than %d", l, *s.minLength))
+	}
+
+	if s.pattern != nil {
+		u.add(s.assert(kw, inst, "pattern", s.pattern.MatchString(str), "%q does not match pattern %q", str, s.pattern))
+	}
+
+	// Since 2020-12 format is an annotation by default, so it affects
+	// validation result only if assertion is enabled explicitly.
+	if s.format != "" {
+		a := s.applicator(kw, inst, "format")
+		a.annotation = s.format.Name()
+
+		if s.assertFormat {
+			if err := formats.Check(s.format, str); err != nil {
+				a.valid = false
+				a.err = err.Error()
+			}
+		}
+
+		u.add(a)
+	}
+}
+
+func (s *schema) evaluateArray(u *unit, arr []interface{}, kw, inst string) {
+	l := uint32(len(arr))
+
+	if s.maxItems != nil {
+		u.add(s.assert(kw, inst, "maxItems", l <= *s.maxItems, "%d items is more than %d", l, *s.maxItems))
+	}
+
+	if s.minItems != nil {
+		u.add(s.assert(kw, inst, "minItems", l >= *s.minItems, "%d items is less than %d", l, *s.minItems))
+	}
+
+	if s.uniqueItems {
+		i, j := duplicate(arr)
+		u.add(s.assert(kw, inst, "uniqueItems", i < 0, "items at %d and %d are equal", i, j))
+	}
+
+	if s.items != nil && len(arr) > 0 {
+		iu := s.applicator(kw, inst, "items")
+
+		for i, v := range arr {
+			iu.add(s.items.evaluate(v, kw+"/items", inst+"/"+strconv.Itoa(i)))
+		}
+
+		// Annotation is true if items was applied to all elements.
+		iu.annotation = true
+		u.add(iu)
+	}
+}
+
+func (s *schema) evaluateObject(u *unit, obj map[string]interface{}, kw, inst string) {
+	l := uint32(len(obj))
+
+	if s.maxProperties != nil {
+		u.add(s.assert(kw, inst, "maxProperties", l <= *s.maxProperties, "%d properties is more than %d", l, *s.maxProperties))
+	}
+
+	if s.minProperties != nil {
+		u.add(s.assert(kw, inst, "minProperties", l >= *s.minProperties, "%d properties is less than %d", l, *s.minProperties))
+	}
+
+	if len(s.required) > 0 {
+		missing := []string{}
+
+		for _, n := range s.required {
+			if _, ok := obj[n]; !ok {
+				missing = append(missing, strconv.Quote(n))
+			}
+		}
+
+		u.add(s.assert(kw, inst, "required", len(missing) == 0, "missing properties: %s", strings.Join(missing, ", ")))
+	}
+
+	if len(s.properties) == 0 {
+		return
+	}
+
+	pu := s.applicator(kw, inst, "properties")
+	// names of evaluated properties
+	names := []string{}
+
+	for _, n := range s.names {
+		v, ok := obj[n]
+		if !ok {
+			continue
+		}
+
+		t := lib.EscapePointer(n)
+		pu.add(s.properties[n].evaluate(v, kw+"/properties/"+t, inst+"/"+t))
+		names = append(names, n)
+	}
+
+	if len(names) > 0 {
+		pu.annotation = names
+	}
+
+	u.add(pu)
+}
+
+// oneOf reports whether i is equal to one of enum values.
+func (s *schema) oneOf(i interface{}) bool {
+	for _, e := range s.enum {
+		if jsonvalue.Equal(i, e) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// duplicate returns indexes of the first pair of equal items in arr, or -1
+// if all items are unique.
+func duplicate(arr []interface{}) (int, int) {
+	for i := range arr {
+		for j := i + 1; j < len(arr); j++ {
+			if jsonvalue.Equal(arr[i], arr[j]) {
+				return i, j
+			}
+		}
+	}
+
+	return -1, -1
+}
+
+// assert returns a result of assertion keyword, where msg and args describe
+// an error if assertion is not valid.
+func (s *schema) assert(kw, inst, keyword string, valid bool, msg string, args ...interface{}) *unit {
+	u := s.applicator(kw, inst, keyword)
+	u.valid = valid
+
+	if !valid {
+		u.err = fmt.Sprintf(msg, args...)
+	}
+
+	return u
+}
+
+// applicator returns an empty result for keyword, which is filled with
+// results of subschemas.
+func (s *schema) applicator(kw, inst, keyword string) *unit {
+	return &unit{
+		keywordLocation:         kw + "/" + keyword,
+		absoluteKeywordLocation: s.location + "/" + keyword,
+		instanceLocation:        inst,
+		valid:                   true,
+	}
+}
+
+// types maps JSON type names to schema types.
+var types = map[string]ast.SchemaType{
+	"null":    ast.Null,
+	"boolean": ast.Boolean,
+	"string":  ast.String,
+	"array":   ast.Array,
+	"object":  ast.Object,
+	"integer": ast.Integer,
+	"number":  ast.Number,
+}
+
+// typeOf returns JSON type of decoded value i. Numbers are returned as
+// rationals too.
+func typeOf(i interface{}) (ast.SchemaType, *big.Rat) {
+	return types[jsonvalue.Type(i)], jsonvalue.Rat(i)
+}
+
+// num formats rational number for messages.
+func num(r *big.Rat) string {
+	if r.IsInt() {
+		return r.Num().String()
+	}
+
+	f, _ := r.Float64()
+
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// typeNames returns lower case names of types set in st.
+func typeNames(st ast.SchemaType) string {
+	names := []string{}
+
+	for t := ast.String; t <= ast.Null; t <<= 1 {
+		if st&t != 0 {
+			names = append(names, strings.ToLower(t.String()))
+		}
+	}
+
+	return strings.Join(names, " or ")
+}
@@ -0,0 +1,165 @@
+package validate
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// OutputFormat is one of the standard output formats.
+//
+// https://json-schema.org/draft/2020-12/json-schema-core.html#rfc.section.12.4
+type OutputFormat string
+
+const (
+	// Flag reports validity only.
+	Flag OutputFormat = "flag"
+	// Basic reports failed assertions, or collected annotations for valid
+	// instance, as a flat list.
+	Basic OutputFormat = "basic"
+	// Detailed reports results as a tree following the schema structure,
+	// where only failed units, or annotations for valid instance, are kept and
+	// units with a single child are replaced by the child.
+	Detailed OutputFormat = "detailed"
+	// Verbose reports the whole evaluation tree, including valid units.
+	Verbose OutputFormat = "verbose"
+)
+
+// Output is an output unit, which is serialized into JSON according to the
+// specification.
+//
+// https://json-schema.org/draft/2020-12/json-schema-core.html#rfc.section.12.3
+type Output struct {
+	Valid bool `json:"valid"`
+
+	// KeywordLocation is a JSON pointer to the keyword along the evaluation
+	// path, including "$ref"s.
+	KeywordLocation string `json:"keywordLocation"`
+	// AbsoluteKeywordLocation is an absolute URI of the keyword within its
+	// schema resource. It's empty if the resource doesn't have an absolute
+	// "$id".
+	AbsoluteKeywordLocation string `json:"absoluteKeywordLocation,omitempty"`
+	// InstanceLocation is a JSON pointer to the evaluated part of the
+	// instance.
+	InstanceLocation string `json:"instanceLocation"`
+
+	Error      string      `json:"error,omitempty"`
+	Annotation interface{} `json:"annotation,omitempty"`
+
+	Errors      []Output `json:"errors,omitempty"`
+	Annotations []Output `json:"annotations,omitempty"`
+
+	// top is set for top level units of flag and basic formats, which don't
+	// have locations.
+	top bool
+}
+
+type output Output
+
+// MarshalJSON implements json.Marshaler.
+func (o Output) MarshalJSON() ([]byte, error) {
+	if !o.top {
+		return json.Marshal(output(o))
+	}
+
+	return json.Marshal(struct {
+		Valid       bool     `json:"valid"`
+		Errors      []Output `json:"errors,omitempty"`
+		Annotations []Output `json:"annotations,omitempty"`
+	}{o.Valid, o.Errors, o.Annotations})
+}
+
+// Output returns the result in format f.
+func (r *Result) Output(f OutputFormat) Output {
+	switch f {
+	case Basic:
+		return basic(r.root)
+	case Detailed:
+		return detailed(r.root, r.root.valid, true)
+	case Verbose:
+		return verbose(r.root)
+	default:
+		return Output{Valid: r.root.valid, top: true}
+	}
+}
+
+func basic(root *unit) Output {
+	o := Output{Valid: root.valid, top: true}
+
+	root.walk(func(u *unit) {
+		switch {
+		case !root.valid && !u.valid && u.err != "":
+			o.Errors = append(o.Errors, unitOutput(u))
+		case root.valid && u.annotation != nil:
+			o.Annotations = append(o.Annotations, unitOutput(u))
+		}
+	})
+
+	return o
+}
+
+// detailed returns a condensed tree of units with the same validity as the
+// whole result, i.e. errors for invalid result and annotations for valid one.
+func detailed(u *unit, valid, root bool) Output {
+	o := unitOutput(u)
+
+	for _, c := range u.children {
+		if c.valid != valid {
+			continue
+		}
+
+		co := detailed(c, valid, false)
+		if co.Error == "" && co.Annotation == nil && len(co.Errors)+len(co.Annotations) == 0 {
+			continue
+		}
+
+		if valid {
+			o.Annotations = append(o.Annotations, co)
+		} else {
+			o.Errors = append(o.Errors, co)
+		}
+	}
+
+	if !root && o.Error == "" && o.Annotation == nil && len(o.Errors)+len(o.Annotations) == 1 {
+		if valid {
+			return o.Annotations[0]
+		}
+
+		return o.Errors[0]
+	}
+
+	return o
+}
+
+func verbose(u *unit) Output {
+	o := unitOutput(u)
+
+	for _, c := range u.children {
+		if c.valid {
+			o.Annotations = append(o.Annotations, verbose(c))
+		} else {
+			o.Errors = append(o.Errors, verbose(c))
+		}
+	}
+
+	return o
+}
+
+func unitOutput(u *unit) Output {
+	o := Output{
+		Valid:            u.valid,
+		KeywordLocation:  u.keywordLocation,
+		InstanceLocation: u.instanceLocation,
+		Error:            u.err,
+	}
+
+	if !strings.HasPrefix(u.absoluteKeywordLocation, "#") {
+		o.AbsoluteKeywordLocation = u.absoluteKeywordLocation
+	}
+
+	// Failed schemas don't produce annotations.
+	if u.valid {
+		o.Annotation = u.annotation
+	}
+
+	return o
+}
@@ -0,0 +1,177 @@
+// Package validate validates JSON instances against JSON schema represented by
+// AST.
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ekhabarov/jsg/ast"
+)
+
+var ErrTrailingData = errors.New("unexpected data after top-level value")
+
+// Validator validates instances against compiled schema. It's safe for
+// concurrent use.
+type Validator struct {
+	root *schema
+}
+
+// Option configures schema compilation.
+type Option func(*compiler)
+
+// WithResource makes schema s available for "$ref" resolution by its "$id",
+// including subschemas with their own "$id".
+func WithResource(s *ast.Schema) Option {
+	return func(c *compiler) {
+		c.extra = append(c.extra, s)
+	}
+}
+
+// WithFormatAssertion makes "format" keyword an assertion, so strings which
+// don't match their formats fail validation. By default "format" is an
+// annotation only. With assertion enabled, schemas with unknown formats fail
+// to compile, custom formats must be registered with ast.RegisterFormat.
+func WithFormatAssertion() Option {
+	return func(c *compiler) {
+		c.formatAssertion = true
+	}
+}
+
+// Compile compiles schema s into validator. All "$ref"s must be resolvable
+// either within s or within resources provided with WithResource option.
+func Compile(s *ast.Schema, opts ...Option) (*Validator, error) {
+	c := newCompiler()
+
+	for _, o := range opts {
+		o(c)
+	}
+
+	root, err := c.compile(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile schema: %w", err)
+	}
+
+	return &Validator{root: root}, nil
+}
+
+// Validate validates JSON document b.
+func (v *Validator) Validate(b []byte) error {
+	return v.ValidateReader(bytes.NewReader(b))
+}
+
+// ValidateReader validates JSON document read from r.
+func (v *Validator) ValidateReader(r io.Reader) error {
+	i, err := Decode(r)
+	if err != nil {
+		return err
+	}
+
+	return v.ValidateValue(i)
+}
+
+// ValidateValue validates decoded JSON value i, i.e. nil, bool, string, number
+// of any Go numeric type or json.Number, []interface{} or
+// map[string]interface{}.
+func (v *Validator) ValidateValue(i interface{}) error {
+	r := v.Evaluate(i)
+	if r.Valid() {
+		return nil
+	}
+
+	return r.Errors()
+}
+
+// Evaluate evaluates decoded JSON value i and returns a result, which can be
+// reported in standard output formats.
+func (v *Validator) Evaluate(i interface{}) *Result {
+	return &Result{root: v.root.evaluate(i, "", "")}
+}
+
+// Decode decodes JSON document read from r into a value suitable for
+// ValidateValue and Evaluate. It keeps numbers as json.Number, so they don't
+// lose precision.
+func Decode(r io.Reader) (interface{}, error) {
+	d := json.NewDecoder(r)
+	d.UseNumber()
+
+	var i interface{}
+	if err := d.Decode(&i); err != nil {
+		return nil, fmt.Errorf("failed to decode instance: %w", err)
+	}
+
+	if _, err := d.Token(); err != io.EOF {
+		return nil, ErrTrailingData
+	}
+
+	return i, nil
+}
+
+// Result is a result of instance evaluation.
+type Result struct {
+	root *unit
+}
+
+// Valid reports whether the instance is valid.
+func (r *Result) Valid() bool {
+	return r.root.valid
+}
+
+// Errors returns all failed assertions, it's nil for valid instance.
+func (r *Result) Errors() Errors {
+	var errs Errors
+
+	r.root.walk(func(u *unit) {
+		if !u.valid && u.err != "" {
+			errs = append(errs, Error{
+				KeywordLocation:         u.keywordLocation,
+				AbsoluteKeywordLocation: u.absoluteKeywordLocation,
+				InstanceLocation:        u.instanceLocation,
+				Message:                 u.err,
+			})
+		}
+	})
+
+	return errs
+}
+
+// Error describes a failed assertion.
+type Error struct {
+	// KeywordLocation is a JSON pointer to the failed keyword along the
+	// evaluation path, including "$ref"s.
+	KeywordLocation string
+	// AbsoluteKeywordLocation is an absolute URI of the failed keyword.
+	AbsoluteKeywordLocation string
+	// InstanceLocation is a JSON pointer to the invalid part of the instance.
+	InstanceLocation string
+	Message          string
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("%s: %s", location(e.InstanceLocation), e.Message)
+}
+
+// Errors is a list of failed assertions returned by validation.
+type Errors []Error
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+func location(ptr string) string {
+	if ptr == "" {
+		return "/"
+	}
+
+	return ptr
+}
@@ -0,0 +1,445 @@
+package validate_test
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/ekhabarov/jsg/ast"
+	"github.com/ekhabarov/jsg/validate"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+func compile(schema string, opts ...validate.Option) *validate.Validator {
+	s, err := ast.Parse(strings.NewReader(schema))
+	Expect(err).NotTo(HaveOccurred())
+
+	v, err := validate.Compile(s, opts...)
+	Expect(err).NotTo(HaveOccurred())
+
+	return v
+}
+
+// failed returns "instanceLocation keywordLocation" pairs of errors.
+func failed(err error) []string {
+	if err == nil {
+		return nil
+	}
+
+	errs, ok := err.(validate.Errors)
+	Expect(ok).To(BeTrue(), err.Error())
+
+	got := []string{}
+	for _, e := range errs {
+		got = append(got, e.InstanceLocation+" "+e.KeywordLocation)
+	}
+
+	return got
+}
+
+var _ = Describe("Validate", func() {
+
+	Context("Validate", func() {
+
+		DescribeTable("Keywords",
+			func(schema, instance string, errs ...string) {
+				err := compile(schema).Validate([]byte(instance))
+				Expect(failed(err)).To(ConsistOf(errs))
+			},
+
+			// type
+
+			Entry("type: valid", `{"type": "string"}`, `"a"`),
+			Entry("type: invalid", `{"type": "string"}`, `1`, " /type"),
+			Entry("type: integer", `{"type": "integer"}`, `1.0`),
+			Entry("type: integer, fraction", `{"type": "integer"}`, `1.5`, " /type"),
+			Entry("type: number accepts integer", `{"type": "number"}`, `1`),
+			Entry("type: multi", `{"type": ["string", "null"]}`, `null`),
+			Entry("type: multi, invalid", `{"type": ["string", "null"]}`, `{}`, " /type"),
+			Entry("type: absent", `{}`, `[1, 2]`),
+
+			// enum
+
+			Entry("enum", `{"enum": ["a", 1, null, {"b": [1]}]}`, `1.0`),
+			Entry("enum: object", `{"enum": ["a", 1, null, {"b": [1]}]}`, `{"b": [1]}`),
+			Entry("enum: invalid", `{"enum": ["a", 1, null]}`, `"b"`, " /enum"),
+			Entry("enum: empty", `{"enum": []}`, `null`, " /enum"),
+
+			// numbers
+
+			Entry("multipleOf", `{"multipleOf": 0.1}`, `0.3`),
+			Entry("multipleOf: invalid", `{"multipleOf": 2}`, `3`, " /multipleOf"),
+			Entry("maximum", `{"maximum": 10}`, `10`),
+			Entry("maximum: invalid", `{"maximum": 10}`, `10.5`, " /maximum"),
+			Entry("exclusiveMaximum: invalid", `{"exclusiveMaximum": 10}`, `10`, " /exclusiveMaximum"),
+			Entry("minimum: zero", `{"minimum": 0}`, `-1`, " /minimum"),
+			Entry("exclusiveMinimum: invalid", `{"exclusiveMinimum": 0}`, `0`, " /exclusiveMinimum"),
+			Entry("numeric keywords ignore strings", `{"minimum": 5}`, `"1"`),
+
+			// strings
+
+			Entry("maxLength: counts characters", `{"maxLength": 2}`, `"日本"`),
+			Entry("maxLength: invalid", `{"maxLength": 2}`, `"abc"`, " /maxLength"),
+			Entry("minLength: invalid", `{"minLength": 2}`, `"a"`, " /minLength"),
+			Entry("pattern", `{"pattern": "^[a-z]+$"}`, `"abc"`),
+			Entry("pattern: not anchored", `{"pattern": "b"}`, `"abc"`),
+			Entry("pattern: invalid", `{"pattern": "^[a-z]+$"}`, `"ab1"`, " /pattern"),
+			Entry("pattern: ECMA-262 whitespace", `{"pattern": "^\\s$"}`, `"\u00a0"`),
+			Entry("pattern: ECMA-262 dot", `{"pattern": "^.$"}`, `"\r"`, " /pattern"),
+			Entry("format is annotation", `{"format": "email"}`, `"not an email"`),
+
+			// arrays
+
+			Entry("maxItems: invalid", `{"maxItems": 1}`, `[1, 2]`, " /maxItems"),
+			Entry("minItems: invalid", `{"minItems": 1}`, `[]`, " /minItems"),
+			Entry("uniqueItems", `{"uniqueItems": true}`, `[1, "1", [1], {"a": 1}, {"a": 2}]`),
+			Entry("uniqueItems: numbers", `{"uniqueItems": true}`, `[1, 1.0]`, " /uniqueItems"),
+			Entry("uniqueItems: objects", `{"uniqueItems": true}`, `[{"a": [1]}, {"a": [1]}]`, " /uniqueItems"),
+			Entry("items", `{"items": {"type": "integer"}}`, `[1, "a", 2, null]`, "/1 /items/type", "/3 /items/type"),
+			Entry("array keywords ignore objects", `{"minItems": 1}`, `{}`),
+
+			// objects
+
+			Entry("maxProperties: invalid", `{"maxProperties": 1}`, `{"a": 1, "b": 2}`, " /maxProperties"),
+			Entry("minProperties: invalid", `{"minProperties": 1}`, `{}`, " /minProperties"),
+			Entry("required", `{"required": ["a"]}`, `{"a": null}`),
+			Entry("required: invalid", `{"required": ["a", "b"]}`, `{"b": 1}`, " /required"),
+			Entry("object keywords ignore arrays", `{"required": ["a"]}`, `[]`),
+
+			// properties
+
+			Entry("properties", `{
+				"properties": {
+					"a": {"type": "string"},
+					"b/c": {"type": "integer", "maximum": 3}
+				}
+			}`, `{"a": 1, "b/c": 4.5, "d": 1}`,
+				"/a /properties/a/type",
+				"/b~1c /properties/b~1c/type",
+				"/b~1c /properties/b~1c/maximum",
+			),
+
+			Entry("properties: nested", `{
+				"properties": {"a": {"properties": {"b": {"minLength": 1}}}}
+			}`, `{"a": {"b": ""}}`, "/a/b /properties/a/properties/b/minLength"),
+
+			// $ref
+
+			Entry("$ref: JSON pointer", `{
+				"properties": {
+					"a": {"type": "string"},
+					"b": {"$ref": "#/properties/a"}
+				}
+			}`, `{"b": 1}`, "/b /properties/b/$ref/type"),
+
+			Entry("$ref: $defs", `{
+				"$defs": {"positive": {"exclusiveMinimum": 0}},
+				"properties": {"a": {"$ref": "#/$defs/positive"}}
+			}`, `{"a": 0}`, "/a /properties/a/$ref/exclusiveMinimum"),
+
+			Entry("$ref: items", `{
+				"items": {"type": "string"},
+				"properties": {"a": {"$ref": "#/items"}}
+			}`, `{"a": 1}`, "/a /properties/a/$ref/type"),
+
+			Entry("$ref: recursive", `{
+				"$id": "https://example.com/tree.json",
+				"type": "object",
+				"properties": {
+					"value": {"type": "integer"},
+					"next": {"$ref": "tree.json"}
+				}
+			}`, `{"value": 1, "next": {"value": 2, "next": {"value": "3"}}}`,
+				"/next/next/value /properties/next/$ref/properties/next/$ref/properties/value/type",
+			),
+
+			Entry("$ref: embedded resource", `{
+				"$id": "https://example.com/root.json",
+				"properties": {
+					"a": {"$id": "a.json", "type": "string"},
+					"b": {"$ref": "https://example.com/a.json"}
+				}
+			}`, `{"b": true}`, "/b /properties/b/$ref/type"),
+
+			// allOf
+
+			Entry("allOf", `{
+				"allOf": [
+					{"properties": {"a": {"type": "string"}}},
+					{"properties": {"b": {"type": "integer"}}}
+				]
+			}`, `{"a": 1, "b": "2"}`,
+				"/a /allOf/0/properties/a/type",
+				"/b /allOf/1/properties/b/type",
+			),
+
+			Entry("allOf: $ref", `{
+				"$defs": {"name": {"properties": {"name": {"minLength": 1}}}},
+				"allOf": [{"$ref": "#/$defs/name"}],
+				"properties": {"a": {"$ref": "#/allOf/0"}}
+			}`, `{"name": "", "a": {"name": ""}}`,
+				"/name /allOf/0/$ref/properties/name/minLength",
+				"/a/name /properties/a/$ref/$ref/properties/name/minLength",
+			),
+
+			// not
+
+			Entry("not", `{"not": {"type": "string"}}`, `1`),
+			Entry("not: invalid", `{"not": {}}`, `1`, " /not"),
+			Entry("boolean schemas", `{"properties": {"a": true, "b": false}}`, `{"a": 1, "b": 1}`, "/b /properties/b"),
+			Entry("false schema: root", `false`, `1`, " "),
+			Entry("not: nested", `{"properties": {"a": {"not": {"not": {"type": "string"}}}}}`, `{"a": 1}`, "/a /properties/a/not"),
+
+			// annotations
+
+			Entry("unknown annotations", `{"examples": [1], "deprecated": true, "x-go-name": "A"}`, `"a"`),
+		)
+
+		It("resolves $ref to external resource", func() {
+			err := compile(`{
+				"$id": "https://example.com/root.json",
+				"properties": {"a": {"$ref": "other.json"}}
+			}`, validate.WithResource(&ast.Schema{
+				ID:   "https://example.com/other.json",
+				Type: ast.String,
+			})).Validate([]byte(`{"a": 1}`))
+
+			Expect(err).To(MatchError("/a: expected string, got integer"))
+		})
+
+		It("fails on malformed JSON", func() {
+			Expect(compile(`{}`).Validate([]byte(`{`))).To(MatchError(ContainSubstring("failed to decode instance")))
+			Expect(compile(`{}`).Validate([]byte(`{} {}`))).To(MatchError(validate.ErrTrailingData))
+		})
+	})
+
+	Context("WithFormatAssertion", func() {
+
+		DescribeTable("Formats",
+			func(instance string, errs ...string) {
+				v := compile(`{"properties": {"d": {"format": "date"}, "e": {"format": "email"}}}`, validate.WithFormatAssertion())
+				Expect(failed(v.Validate([]byte(instance)))).To(ConsistOf(errs))
+			},
+
+			Entry("valid", `{"d": "2021-12-01", "e": "a@example.com"}`),
+			Entry("invalid", `{"d": "2021-12-32", "e": "a@example.com"}`, "/d /properties/d/format"),
+			Entry("non-strings are ignored", `{"d": 1, "e": null}`),
+		)
+
+		It("reports checker error", func() {
+			v := compile(`{"format": "ipv4"}`, validate.WithFormatAssertion())
+			Expect(v.Validate([]byte(`"1.2.3"`))).To(MatchError("/: invalid ipv4: four octets expected"))
+		})
+	})
+
+	Context("ValidateReader", func() {
+
+		It("keeps numbers exact", func() {
+			v := compile(`{"maximum": 10}`)
+
+			Expect(v.ValidateReader(strings.NewReader(`10`))).To(Succeed())
+			Expect(v.ValidateReader(strings.NewReader(`10.000000000000000001`))).NotTo(Succeed())
+		})
+	})
+
+	Context("ValidateValue", func() {
+
+		DescribeTable("Go values",
+			func(instance interface{}, valid bool) {
+				v := compile(`{
+					"type": ["integer", "object"],
+					"maximum": 10,
+					"properties": {"s": {"type": "string", "maxLength": 1}}
+				}`)
+
+				if valid {
+					Expect(v.ValidateValue(instance)).To(Succeed())
+				} else {
+					Expect(v.ValidateValue(instance)).NotTo(Succeed())
+				}
+			},
+
+			Entry("int", 5, true),
+			Entry("uint8", uint8(11), false),
+			Entry("float64", 5.0, true),
+			Entry("float64 fraction", 5.5, false),
+			Entry("map", map[string]interface{}{"s": "a"}, true),
+			Entry("map, invalid property", map[string]interface{}{"s": "ab"}, false),
+			Entry("string", "a", false),
+		)
+	})
+
+	Context("Compile", func() {
+
+		DescribeTable("Errors",
+			func(schema, msg string) {
+				s, err := ast.Parse(strings.NewReader(schema))
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = validate.Compile(s)
+				Expect(err).To(MatchError(ContainSubstring(msg)))
+			},
+
+			Entry("pattern", `{"pattern": "("}`, "#/pattern"),
+			Entry("pattern: lookahead", `{"pattern": "a(?=b)"}`, "#/pattern: lookahead is not supported at offset 1"),
+			Entry("unknown resource", `{"$ref": "https://example.com/none.json"}`, `unknown schema resource "https://example.com/none.json"`),
+			Entry("unknown property", `{"$ref": "#/properties/none"}`, `property "none" not found`),
+			Entry("unknown allOf", `{"allOf": [{}], "$ref": "#/allOf/1"}`, `allOf/1 not found`),
+			Entry("unsupported keyword", `{"additionalProperties": false}`, "#/additionalProperties: unsupported keyword"),
+			Entry("unsupported keyword in subschema", `{"properties": {"a": {"oneOf": [{}]}}}`, "#/properties/a/oneOf: unsupported keyword"),
+			Entry("unsupported keyword in not", `{"not": {"const": 1}}`, "#/not/const: unsupported keyword"),
+			Entry("$ref cycle", `{"$ref": "#"}`, "#: reference cycle doesn't reach new instance location"),
+			Entry("$ref cycle through allOf", `{
+				"$defs": {"a": {"allOf": [{"$ref": "#/$defs/b"}]}, "b": {"$ref": "#/$defs/a"}},
+				"properties": {"x": {"$ref": "#/$defs/a"}}
+			}`, "reference cycle doesn't reach new instance location"),
+		)
+
+		It("rejects unknown formats in assertion mode", func() {
+			s, err := ast.Parse(strings.NewReader(`{"format": "assert-unknown"}`))
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = validate.Compile(s)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = validate.Compile(s, validate.WithFormatAssertion())
+			Expect(err).To(MatchError(ContainSubstring(`unknown format "assert-unknown" can't be asserted`)))
+		})
+	})
+
+	Context("Output", func() {
+		schema := `{
+			"$id": "https://example.com/out.json",
+			"properties": {
+				"a": {"type": "string", "format": "email"},
+				"b": {"$ref": "#/properties/a"}
+			}
+		}`
+
+		DescribeTable("Formats",
+			func(instance string, f validate.OutputFormat, exp string) {
+				i, err := validate.Decode(strings.NewReader(instance))
+				Expect(err).NotTo(HaveOccurred())
+
+				out, err := json.Marshal(compile(schema).Evaluate(i).Output(f))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(out).To(MatchJSON(exp))
+			},
+
+			Entry("flag, invalid", `{"b": 1}`, validate.Flag, `{"valid": false}`),
+			Entry("flag, valid", `{"b": "x"}`, validate.Flag, `{"valid": true}`),
+
+			Entry("basic, invalid", `{"a": 1, "b": 1}`, validate.Basic, `{
+				"valid": false,
+				"errors": [{
+					"valid": false,
+					"keywordLocation": "/properties/a/type",
+					"absoluteKeywordLocation": "https://example.com/out.json#/properties/a/type",
+					"instanceLocation": "/a",
+					"error": "expected string, got integer"
+				}, {
+					"valid": false,
+					"keywordLocation": "/properties/b/$ref/type",
+					"absoluteKeywordLocation": "https://example.com/out.json#/properties/a/type",
+					"instanceLocation": "/b",
+					"error": "expected string, got integer"
+				}]
+			}`),
+
+			Entry("basic, valid", `{"a": "x"}`, validate.Basic, `{
+				"valid": true,
+				"annotations": [{
+					"valid": true,
+					"keywordLocation": "/properties",
+					"absoluteKeywordLocation": "https://example.com/out.json#/properties",
+					"instanceLocation": "",
+					"annotation": ["a"]
+				}, {
+					"valid": true,
+					"keywordLocation": "/properties/a/format",
+					"absoluteKeywordLocation": "https://example.com/out.json#/properties/a/format",
+					"instanceLocation": "/a",
+					"annotation": "email"
+				}]
+			}`),
+
+			Entry("detailed, invalid", `{"a": 1, "b": 1}`, validate.Detailed, `{
+				"valid": false,
+				"keywordLocation": "",
+				"absoluteKeywordLocation": "https://example.com/out.json#",
+				"instanceLocation": "",
+				"errors": [{
+					"valid": false,
+					"keywordLocation": "/properties",
+					"absoluteKeywordLocation": "https://example.com/out.json#/properties",
+					"instanceLocation": "",
+					"errors": [{
+						"valid": false,
+						"keywordLocation": "/properties/a/type",
+						"absoluteKeywordLocation": "https://example.com/out.json#/properties/a/type",
+						"instanceLocation": "/a",
+						"error": "expected string, got integer"
+					}, {
+						"valid": false,
+						"keywordLocation": "/properties/b/$ref/type",
+						"absoluteKeywordLocation": "https://example.com/out.json#/properties/a/type",
+						"instanceLocation": "/b",
+						"error": "expected string, got integer"
+					}]
+				}]
+			}`),
+
+			Entry("verbose, invalid", `{"b": 1}`, validate.Verbose, `{
+				"valid": false,
+				"keywordLocation": "",
+				"absoluteKeywordLocation": "https://example.com/out.json#",
+				"instanceLocation": "",
+				"errors": [{
+					"valid": false,
+					"keywordLocation": "/properties",
+					"absoluteKeywordLocation": "https://example.com/out.json#/properties",
+					"instanceLocation": "",
+					"errors": [{
+						"valid": false,
+						"keywordLocation": "/properties/b",
+						"absoluteKeywordLocation": "https://example.com/out.json#/properties/b",
+						"instanceLocation": "/b",
+						"errors": [{
+							"valid": false,
+							"keywordLocation": "/properties/b/$ref",
+							"absoluteKeywordLocation": "https://example.com/out.json#/properties/a",
+							"instanceLocation": "/b",
+							"errors": [{
+								"valid": false,
+								"keywordLocation": "/properties/b/$ref/type",
+								"absoluteKeywordLocation": "https://example.com/out.json#/properties/a/type",
+								"instanceLocation": "/b",
+								"error": "expected string, got integer"
+							}]
+						}]
+					}]
+				}]
+			}`),
+		)
+
+		It("reports false schemas at their own location", func() {
+			v := compile(`{"$id": "https://example.com/false.json", "properties": {"a": false}}`)
+
+			out, err := json.Marshal(v.Evaluate(map[string]interface{}{"a": 1.0}).Output(validate.Basic))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out).To(MatchJSON(`{
+				"valid": false,
+				"errors": [{
+					"valid": false,
+					"keywordLocation": "/properties/a",
+					"absoluteKeywordLocation": "https://example.com/false.json#/properties/a",
+					"instanceLocation": "/a",
+					"error": "false schema"
+				}]
+			}`))
+		})
+	})
+
+})